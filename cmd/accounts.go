@@ -18,11 +18,16 @@ var accountsCmd = &cobra.Command{
 	RunE:  runAccounts,
 }
 
+var accountsArchived bool
+
 func init() {
 	rootCmd.AddCommand(accountsCmd)
+	accountsCmd.Flags().BoolVar(&accountsArchived, "archived", false, "List removed accounts whose history is kept")
 }
 
 func runAccounts(cmd *cobra.Command, args []string) error {
+	loc := displayLocation()
+
 	db, err := database.New(config.DBPath())
 	if err != nil {
 		return fmt.Errorf("failed to open database: %w", err)
@@ -30,11 +35,19 @@ func runAccounts(cmd *cobra.Command, args []string) error {
 	defer db.Close()
 
 	repo := account.NewRepository(db)
-	accounts, err := repo.List()
+	list, title := repo.List, "Monitored Accounts"
+	if accountsArchived {
+		list, title = repo.ListArchived, "Archived Accounts"
+	}
+	accounts, err := list()
 	if err != nil {
 		return fmt.Errorf("failed to list accounts: %w", err)
 	}
 
+	if len(accounts) == 0 && accountsArchived {
+		fmt.Println("No archived accounts.")
+		return nil
+	}
 	if len(accounts) == 0 {
 		fmt.Println("No accounts being monitored.")
 		fmt.Println("Run 'xmon add <username>' to add accounts.")
@@ -45,7 +58,7 @@ func runAccounts(cmd *cobra.Command, args []string) error {
 	userStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("205"))
 	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
 
-	fmt.Printf("\n%s\n\n", titleStyle.Render("Monitored Accounts"))
+	fmt.Printf("\n%s\n\n", titleStyle.Render(title))
 
 	for _, acc := range accounts {
 		fmt.Printf("  %s", userStyle.Render("@"+acc.Username))
@@ -53,7 +66,14 @@ func runAccounts(cmd *cobra.Command, args []string) error {
 			fmt.Printf(" (%s)", acc.Name)
 		}
 		fmt.Println()
-		fmt.Printf("    %s\n", dimStyle.Render(fmt.Sprintf("%d followers", acc.Followers)))
+		details := fmt.Sprintf("%d followers", acc.Followers)
+		if acc.Priority != "" && acc.Priority != "normal" {
+			details += fmt.Sprintf(" · %s priority", acc.Priority)
+		}
+		if acc.ArchivedAt != nil {
+			details += fmt.Sprintf(" · archived %s", acc.ArchivedAt.In(loc).Format("2006-01-02"))
+		}
+		fmt.Printf("    %s\n", dimStyle.Render(details))
 	}
 
 	fmt.Printf("\n%s\n", dimStyle.Render(fmt.Sprintf("Total: %d accounts", len(accounts))))
@@ -7,6 +7,8 @@ import (
 	"github.com/jpequegn/xmon/internal/account"
 	"github.com/jpequegn/xmon/internal/config"
 	"github.com/jpequegn/xmon/internal/database"
+	"github.com/jpequegn/xmon/internal/tweet"
+	"github.com/jpequegn/xmon/internal/usage"
 	"github.com/jpequegn/xmon/internal/x"
 	"github.com/spf13/cobra"
 )
@@ -19,13 +21,20 @@ var addCmd = &cobra.Command{
 	RunE:  runAdd,
 }
 
+var addPriority string
+
 func init() {
 	rootCmd.AddCommand(addCmd)
+	addCmd.Flags().StringVar(&addPriority, "priority", usage.PriorityNormal, "Fetch priority when quota runs low (high, normal, low)")
 }
 
 func runAdd(cmd *cobra.Command, args []string) error {
 	username := args[0]
 
+	if !validPriority(addPriority) {
+		return fmt.Errorf("invalid priority %q (expected high, normal or low)", addPriority)
+	}
+
 	cfg, err := config.Load()
 	if err != nil {
 		return fmt.Errorf("failed to load config: %w (run 'xmon init' first)", err)
@@ -46,8 +55,29 @@ func runAdd(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("account @%s is already being monitored", username)
 	}
 
+	// Archived accounts are restored in place, keeping their history
+	if acc, err := repo.Get(username); err == nil && acc.Archived() {
+		if err := repo.Restore(acc.Username); err != nil {
+			return fmt.Errorf("failed to restore account: %w", err)
+		}
+		if addPriority != usage.PriorityNormal {
+			if err := repo.SetPriority(acc.Username, addPriority); err != nil {
+				return fmt.Errorf("failed to set priority: %w", err)
+			}
+		}
+		tweets, _ := tweet.NewRepository(db).CountByAccount(acc.ID)
+		fmt.Printf("Restored @%s (%d tweets kept)\n", acc.Username, tweets)
+		return nil
+	}
+
+	usageRepo, err := usageRepository(cfg, db)
+	if err != nil {
+		return err
+	}
+
 	// Fetch user info from X API
 	client := x.NewClient(cfg.X.BearerToken)
+	client.OnRequest(func(endpoint string) { usageRepo.AddRequest(endpoint) })
 	user, err := client.GetUser(username)
 	if err != nil {
 		return fmt.Errorf("failed to fetch user @%s: %w", username, err)
@@ -57,6 +87,11 @@ func runAdd(cmd *cobra.Command, args []string) error {
 	if err := repo.Add(user.ID, user.Username, user.Name, user.Description, user.PublicMetrics.FollowersCount); err != nil {
 		return fmt.Errorf("failed to add account: %w", err)
 	}
+	if addPriority != usage.PriorityNormal {
+		if err := repo.SetPriority(user.Username, addPriority); err != nil {
+			return fmt.Errorf("failed to set priority: %w", err)
+		}
+	}
 
 	fmt.Printf("Added @%s (%s) - %d followers\n", user.Username, user.Name, user.PublicMetrics.FollowersCount)
 	return nil
@@ -0,0 +1,153 @@
+// cmd/backup.go
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jpequegn/xmon/internal/backup"
+	"github.com/jpequegn/xmon/internal/config"
+	"github.com/jpequegn/xmon/internal/database"
+	"github.com/jpequegn/xmon/internal/lock"
+	"github.com/spf13/cobra"
+)
+
+var backupCmd = &cobra.Command{
+	Use:   "backup [path]",
+	Short: "Back up the database",
+	Long: `Copies the database with SQLite's online backup API, so it is safe to run while
+the daemon is writing. Without a path, a timestamped backup is written to
+backup.dir (default ~/.xmon/backups) and old backups beyond backup.keep are
+deleted. A path ending in .gz is gzipped.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runBackup,
+}
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore <file>",
+	Short: "Restore the database from a backup",
+	Long: `Checks a backup's integrity and schema version, then swaps it in as the
+database. The current database is kept next to it with a .pre-restore suffix.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runRestore,
+}
+
+var (
+	backupGzip bool
+	backupKeep int
+)
+
+func init() {
+	rootCmd.AddCommand(backupCmd)
+	rootCmd.AddCommand(restoreCmd)
+	backupCmd.Flags().BoolVar(&backupGzip, "gzip", false, "Gzip the backup (default: backup.gzip)")
+	backupCmd.Flags().IntVar(&backupKeep, "keep", 0, "Number of backups to keep in the backup directory (default: backup.keep)")
+}
+
+func runBackup(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		cfg = config.DefaultConfig()
+	}
+	if cmd.Flags().Changed("gzip") {
+		cfg.Backup.Gzip = backupGzip
+	}
+	if cmd.Flags().Changed("keep") {
+		cfg.Backup.Keep = backupKeep
+	}
+
+	if _, err := os.Stat(config.DBPath()); err != nil {
+		return fmt.Errorf("no database at %s (run 'xmon init' first)", config.DBPath())
+	}
+
+	// Without a file name, back up into a directory and rotate it
+	dir := cfg.Backup.Dir
+	if dir == "" {
+		dir = config.BackupDir()
+	}
+	path := ""
+	if len(args) == 1 {
+		if info, err := os.Stat(args[0]); err == nil && info.IsDir() {
+			dir = args[0]
+		} else {
+			path = args[0]
+			dir = ""
+		}
+	}
+	if path == "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create backup directory: %w", err)
+		}
+		path = filepath.Join(dir, backup.Name(time.Now(), cfg.Backup.Gzip))
+	} else if cmd.Flags().Changed("gzip") && backupGzip && !strings.HasSuffix(path, ".gz") {
+		path += ".gz"
+	}
+
+	db, err := database.Open(config.DBPath())
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	if err := backup.Write(db, path); err != nil {
+		return fmt.Errorf("failed to back up database: %w", err)
+	}
+
+	size := int64(0)
+	if info, err := os.Stat(path); err == nil {
+		size = info.Size()
+	}
+	fmt.Printf("Backed up database to %s (%s)\n", path, formatBytes(size))
+
+	if dir != "" {
+		removed, err := backup.Rotate(dir, cfg.Backup.Keep)
+		if err != nil {
+			return fmt.Errorf("failed to rotate backups: %w", err)
+		}
+		if len(removed) > 0 {
+			fmt.Printf("Removed %d old backup(s), keeping %d\n", len(removed), cfg.Backup.Keep)
+		}
+	}
+
+	return nil
+}
+
+func runRestore(cmd *cobra.Command, args []string) error {
+	src := args[0]
+	if _, err := os.Stat(src); err != nil {
+		return fmt.Errorf("backup not found: %w", err)
+	}
+
+	// Swapping the file under a running fetch would lose its writes
+	fetchLock, holder, err := lock.TryAcquire(config.FetchLockPath())
+	if errors.Is(err, lock.ErrLocked) {
+		return fmt.Errorf("a fetch is running (pid %d); wait for it or stop the daemon before restoring", holder)
+	}
+	if err != nil {
+		return err
+	}
+	defer fetchLock.Release()
+
+	version, err := backup.Restore(src, config.DBPath())
+	if err != nil {
+		return fmt.Errorf("failed to restore %s: %w", src, err)
+	}
+
+	fmt.Printf("Restored database from %s (schema version %d)\n", src, version)
+	if _, err := os.Stat(config.DBPath() + ".pre-restore"); err == nil {
+		fmt.Printf("Previous database saved as %s\n", config.DBPath()+".pre-restore")
+	}
+
+	// Bring an older backup up to the current schema right away
+	db, err := database.New(config.DBPath())
+	if err != nil {
+		return fmt.Errorf("failed to open restored database: %w", err)
+	}
+	db.Close()
+
+	return nil
+}
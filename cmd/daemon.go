@@ -8,6 +8,7 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/jpequegn/xmon/internal/config"
 	"github.com/spf13/cobra"
 )
 
@@ -25,6 +26,7 @@ var (
 func init() {
 	rootCmd.AddCommand(daemonCmd)
 	daemonCmd.Flags().IntVar(&daemonInterval, "interval", 60, "Fetch interval in minutes")
+	daemonCmd.Flags().BoolVar(&fetchAllowOverQuota, "allow-over-quota", false, "Fetch even if it eats into the quota reserve")
 }
 
 func runDaemon(cmd *cobra.Command, args []string) error {
@@ -42,6 +44,15 @@ func runDaemon(cmd *cobra.Command, args []string) error {
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
+	// Prune on the retention schedule; a nil channel never fires
+	var pruneC <-chan time.Time
+	if cfg, err := config.Load(); err == nil && cfg.Retention.TweetDays > 0 && cfg.Retention.PruneEveryHours > 0 {
+		pruneTicker := time.NewTicker(time.Duration(cfg.Retention.PruneEveryHours) * time.Hour)
+		defer pruneTicker.Stop()
+		pruneC = pruneTicker.C
+		fmt.Printf("Pruning tweets older than %d days every %dh\n", cfg.Retention.TweetDays, cfg.Retention.PruneEveryHours)
+	}
+
 	// Handle graceful shutdown
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
@@ -53,6 +64,11 @@ func runDaemon(cmd *cobra.Command, args []string) error {
 			if err := runFetch(cmd, args); err != nil {
 				fmt.Printf("Fetch error: %v\n", err)
 			}
+		case <-pruneC:
+			fmt.Printf("\n[%s] Running scheduled prune...\n", time.Now().Format("15:04:05"))
+			if err := runPrune(cmd, args); err != nil {
+				fmt.Printf("Prune error: %v\n", err)
+			}
 		case <-sigChan:
 			fmt.Println("\nShutting down daemon...")
 			return nil
@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/jpequegn/xmon/internal/config"
+	"github.com/jpequegn/xmon/internal/database"
+	"github.com/jpequegn/xmon/internal/rollup"
+	"github.com/spf13/cobra"
+)
+
+var dbCmd = &cobra.Command{
+	Use:   "db",
+	Short: "Manage the xmon database",
+	Long:  `Inspect and maintain the active profile's SQLite database (~/.xmon/xmon.db by default).`,
+}
+
+var dbStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show schema version and pending migrations",
+	Long:  `Shows which schema migrations have been applied to the database and which are pending, without changing anything.`,
+	Args:  cobra.NoArgs,
+	RunE:  runDBStatus,
+}
+
+var dbMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Apply pending schema migrations",
+	Long:  `Applies pending schema migrations in order, each in its own transaction. Other commands do this automatically on startup.`,
+	Args:  cobra.NoArgs,
+	RunE:  runDBMigrate,
+}
+
+var dbRebuildRollupsCmd = &cobra.Command{
+	Use:   "rebuild-rollups",
+	Short: "Recompute daily rollups from stored tweets",
+	Long: `Recomputes the per-account daily rollups (tweets by type, engagement, topics and
+amplified users) from the tweets in the database. Fetch keeps them current, so
+this is only needed after upgrading or editing the database by hand. Days whose
+tweets were pruned keep their rollups.`,
+	Args: cobra.NoArgs,
+	RunE: runDBRebuildRollups,
+}
+
+func init() {
+	rootCmd.AddCommand(dbCmd)
+	dbCmd.AddCommand(dbStatusCmd)
+	dbCmd.AddCommand(dbMigrateCmd)
+	dbCmd.AddCommand(dbRebuildRollupsCmd)
+}
+
+func runDBStatus(cmd *cobra.Command, args []string) error {
+	loc := displayLocation()
+
+	if _, err := os.Stat(config.DBPath()); os.IsNotExist(err) {
+		fmt.Printf("No database at %s. Run 'xmon init' first.\n", config.DBPath())
+		return nil
+	}
+
+	db, err := database.Open(config.DBPath())
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	version, err := db.SchemaVersion()
+	if err != nil {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+	applied, err := db.AppliedMigrations()
+	if err != nil {
+		return fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+
+	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("39"))
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+	warnStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
+
+	fmt.Printf("\n%s\n\n", titleStyle.Render("Database"))
+	fmt.Printf("  Path:    %s\n", config.DBPath())
+	fmt.Printf("  Version: %d (latest %d)\n\n", version, database.LatestVersion())
+
+	for _, m := range applied {
+		fmt.Printf("  ✓ %04d_%s %s\n", m.Version, m.Name,
+			dimStyle.Render(m.AppliedAt.In(loc).Format("2006-01-02 15:04")))
+	}
+
+	pending, err := db.PendingMigrations()
+	if err != nil {
+		fmt.Printf("\n  %s\n", warnStyle.Render(err.Error()))
+		fmt.Println("  Upgrade xmon to use this database.")
+		return nil
+	}
+	for _, m := range pending {
+		fmt.Printf("  %s\n", warnStyle.Render(fmt.Sprintf("• %04d_%s (pending)", m.Version, m.Name)))
+	}
+
+	if len(pending) > 0 {
+		fmt.Printf("\n%d pending migration(s). Run 'xmon db migrate' to apply.\n", len(pending))
+	} else {
+		fmt.Printf("\n%s\n", dimStyle.Render("Schema is up to date."))
+	}
+
+	return nil
+}
+
+func runDBMigrate(cmd *cobra.Command, args []string) error {
+	db, err := database.Open(config.DBPath())
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	applied, err := db.Migrate()
+	for _, m := range applied {
+		fmt.Printf("Applied %04d_%s\n", m.Version, m.Name)
+	}
+	if err != nil {
+		return fmt.Errorf("migration failed: %w", err)
+	}
+
+	if len(applied) == 0 {
+		fmt.Println("Schema is up to date.")
+		return nil
+	}
+
+	version, _ := db.SchemaVersion()
+	fmt.Printf("Database is now at version %d\n", version)
+	return nil
+}
+
+func runDBRebuildRollups(cmd *cobra.Command, args []string) error {
+	db, err := database.New(config.DBPath())
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	days, err := rollup.NewRepository(db).Rebuild()
+	if err != nil {
+		return fmt.Errorf("failed to rebuild rollups: %w", err)
+	}
+	fmt.Printf("Rebuilt rollups for %d account-days\n", days)
+	return nil
+}
@@ -12,7 +12,10 @@ import (
 	"github.com/jpequegn/xmon/internal/analysis"
 	"github.com/jpequegn/xmon/internal/config"
 	"github.com/jpequegn/xmon/internal/database"
+	"github.com/jpequegn/xmon/internal/fetchrun"
 	"github.com/jpequegn/xmon/internal/llm"
+	"github.com/jpequegn/xmon/internal/rollup"
+	"github.com/jpequegn/xmon/internal/timerange"
 	"github.com/jpequegn/xmon/internal/tweet"
 	"github.com/spf13/cobra"
 )
@@ -25,96 +28,87 @@ var digestCmd = &cobra.Command{
 }
 
 var (
-	digestDays  int
+	digestRange timerange.Options
 	digestSmart bool
+	digestTrend bool
 )
 
 func init() {
 	rootCmd.AddCommand(digestCmd)
-	digestCmd.Flags().IntVar(&digestDays, "days", 7, "Number of days to include in digest")
+	addRangeFlags(digestCmd, &digestRange, "digest")
 	digestCmd.Flags().BoolVar(&digestSmart, "smart", false, "Use LLM for intelligent analysis")
+	digestCmd.Flags().BoolVar(&digestTrend, "trend", false, "Add the last 12 months from daily rollups")
 }
 
 func runDigest(cmd *cobra.Command, args []string) error {
+	rng, loc, err := resolveRange(digestRange)
+	if err != nil {
+		return err
+	}
+	since, until := rng.Start, rng.End
+
 	db, err := database.New(config.DBPath())
 	if err != nil {
 		return fmt.Errorf("failed to open database: %w", err)
 	}
 	defer db.Close()
 
-	since := time.Now().AddDate(0, 0, -digestDays)
-	endDate := time.Now()
-
 	accountRepo := account.NewRepository(db)
 	tweetRepo := tweet.NewRepository(db)
+	rollups := rollup.NewRepository(db)
 
-	accounts, _ := accountRepo.List()
+	// Archived accounts still own tweets in the window, so map them too
+	accounts, _ := accountRepo.ListAll()
 	accountMap := make(map[int64]*account.Account)
+	monitored := 0
 	for i := range accounts {
 		accountMap[accounts[i].ID] = &accounts[i]
+		if !accounts[i].Archived() {
+			monitored++
+		}
 	}
 
-	originals, retweets, quotes, _ := tweetRepo.CountByType(since)
-	totalTweets := originals + retweets + quotes
+	window := tweet.Filter{Since: since, Until: until}
+	counts, _ := tweetRepo.CountByType(window)
+	originals, retweets, quotes := counts.Originals, counts.Retweets, counts.Quotes
+	totalTweets := counts.Total()
+	active, _ := tweetRepo.MostActive(window, 5)
 
 	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("205"))
 	sectionStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("39"))
 	userStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("39"))
 	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+	warnStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
 
-	fmt.Printf("\n%s (%s - %s)\n",
-		titleStyle.Render("X DIGEST"),
-		since.Format("Jan 2"),
-		endDate.Format("Jan 2, 2006"))
+	fmt.Printf("\n%s (%s)\n", titleStyle.Render("X DIGEST"), rng.Label(loc, false))
 	fmt.Println(lipgloss.NewStyle().Foreground(lipgloss.Color("240")).Render("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━"))
 
 	fmt.Printf("\n📊 Summary: %d accounts · %d tweets · %d retweets · %d quotes\n\n",
-		len(accounts), originals, retweets, quotes)
+		monitored, originals, retweets, quotes)
 
-	// Most Active
-	if totalTweets > 0 {
-		fmt.Printf("%s\n", sectionStyle.Render("🔥 Most Active"))
-		tweets, _ := tweetRepo.GetSince(since)
-
-		tweetCounts := make(map[int64]int)
-		for _, t := range tweets {
-			tweetCounts[t.AccountID]++
-		}
-
-		type accountTweets struct {
-			username string
-			count    int
-		}
-		var sorted []accountTweets
-		for accID, count := range tweetCounts {
-			if acc, ok := accountMap[accID]; ok {
-				sorted = append(sorted, accountTweets{acc.Username, count})
-			}
-		}
-
-		// Simple sort
-		for i := 0; i < len(sorted); i++ {
-			for j := i + 1; j < len(sorted); j++ {
-				if sorted[j].count > sorted[i].count {
-					sorted[i], sorted[j] = sorted[j], sorted[i]
-				}
-			}
+	// Data freshness
+	cfg, err := config.Load()
+	if err != nil {
+		cfg = config.DefaultConfig()
+	}
+	if warnings := freshnessWarnings(fetchrun.NewRepository(db), cfg, since, until); len(warnings) > 0 {
+		for _, warning := range warnings {
+			fmt.Printf("  %s\n", warnStyle.Render(warning))
 		}
+		fmt.Println()
+	}
 
-		limit := 5
-		if len(sorted) < limit {
-			limit = len(sorted)
-		}
-		for i := 0; i < limit; i++ {
-			fmt.Printf("  %-20s %d tweets\n",
-				userStyle.Render("@"+sorted[i].username),
-				sorted[i].count)
+	// Most Active
+	if len(active) > 0 {
+		fmt.Printf("%s\n", sectionStyle.Render("🔥 Most Active"))
+		for _, a := range active {
+			fmt.Printf("  %-20s %d tweets\n", userStyle.Render("@"+a.Username), a.Count)
 		}
 		fmt.Println()
 	}
 
 	// Most Amplified
-	amplified, _ := tweetRepo.GetMostAmplified(since, 5)
+	amplified, _ := tweetRepo.GetMostAmplified(since, until, 5)
 	if len(amplified) > 0 {
 		fmt.Printf("%s\n", sectionStyle.Render("🔁 Most Amplified"))
 		for _, a := range amplified {
@@ -125,23 +119,71 @@ func runDigest(cmd *cobra.Command, args []string) error {
 		fmt.Println()
 	}
 
-	// Trending Topics
-	tweets, _ := tweetRepo.GetSince(since)
-	var tweetContents []string
-	for _, t := range tweets {
-		if t.Content != "" {
-			tweetContents = append(tweetContents, t.Content)
+	// Most Shared Posts
+	posts, _ := tweetRepo.GetAmplifiedPosts(since, until, 2, 3)
+	if len(posts) > 0 {
+		fmt.Printf("%s\n", sectionStyle.Render("📣 Most Shared Posts"))
+		for _, p := range posts {
+			author := "unknown author"
+			if p.Post.AuthorUsername != "" {
+				author = "@" + p.Post.AuthorUsername
+			}
+			content := truncate(strings.ReplaceAll(p.Post.Content, "\n", " "), 80)
+			if content == "" {
+				content = dimStyle.Render("(text not fetched yet)")
+			}
+			fmt.Printf("  %s: %s\n", userStyle.Render(author), content)
+			fmt.Printf("    %s\n", dimStyle.Render(fmt.Sprintf("↳ %s by %d of your accounts: @%s · %d likes",
+				sharedVerb(p), len(p.AmplifiedBy), strings.Join(p.AmplifiedBy, ", @"), p.Post.Likes)))
 		}
+		fmt.Println()
 	}
 
-	topics := analysis.ExtractTopics(tweetContents, 8)
-	if len(topics) > 0 {
+	// Trending Topics, against the windows before this one
+	corpus, tweetContents, _ := windowCorpus(tweetRepo, window)
+	trends, hasBaseline, _ := topicTrends(rollups, corpus, since, until, 5)
+	topics := rising(trends)
+	if len(trends) > 0 {
 		fmt.Printf("%s\n", sectionStyle.Render("📢 Trending Topics"))
-		fmt.Printf("  %s\n\n", dimStyle.Render(strings.Join(topics, " · ")))
+		for _, line := range trendArrows(trends) {
+			if strings.HasPrefix(line, "↑") {
+				fmt.Printf("  %s\n", line)
+			} else {
+				fmt.Printf("  %s\n", dimStyle.Render(line))
+			}
+		}
+		fmt.Println()
+	} else if topics = analysis.ExtractTopics(tweetContents, 8); len(topics) > 0 {
+		note := "(no shifts against the previous windows)"
+		if !hasBaseline {
+			note = "(no earlier tweets to compare against yet)"
+		}
+		fmt.Printf("%s\n", sectionStyle.Render("📢 Trending Topics"))
+		fmt.Printf("  %s\n", strings.Join(topics, " · "))
+		fmt.Printf("  %s\n\n", dimStyle.Render(note))
+	}
+
+	// Themes, clustered offline
+	themes := analysis.DetectThemes(corpus, 4)
+	if len(themes) > 0 {
+		fmt.Printf("%s\n", sectionStyle.Render("🧩 Themes"))
+		for _, theme := range themes {
+			fmt.Printf("  %s %s\n", theme.Name, dimStyle.Render(fmt.Sprintf("(%d tweets · %s)",
+				theme.Count, themeAccounts(theme, accountMap, 3))))
+			fmt.Printf("    %s\n", dimStyle.Render(strings.Join(theme.Terms[:min(6, len(theme.Terms))], " · ")))
+			for _, t := range theme.Tweets[:min(2, len(theme.Tweets))] {
+				if acc, ok := accountMap[t.AccountID]; ok {
+					content := truncate(strings.ReplaceAll(t.Text, "\n", " "), 70)
+					fmt.Printf("    %s: %s\n", userStyle.Render("@"+acc.Username), content)
+				}
+			}
+		}
+		fmt.Println()
 	}
 
 	// Notable Tweets
-	topTweets, _ := tweetRepo.GetTopTweets(since, 3)
+	top, _ := tweetRepo.Query(notableFilter(since, until, 3))
+	topTweets := top.Tweets
 	if len(topTweets) > 0 {
 		fmt.Printf("%s\n", sectionStyle.Render("💬 Notable Tweets"))
 		for _, t := range topTweets {
@@ -157,6 +199,15 @@ func runDigest(cmd *cobra.Command, args []string) error {
 		fmt.Println()
 	}
 
+	// Long-range trend
+	if digestTrend {
+		fmt.Printf("%s\n", sectionStyle.Render("📈 Last 12 Months"))
+		if err := printTrend(rollups, nil, "  "); err != nil {
+			return err
+		}
+		fmt.Println()
+	}
+
 	// Smart analysis with LLM
 	if digestSmart {
 		cfg, err := config.Load()
@@ -166,7 +217,7 @@ func runDigest(cmd *cobra.Command, args []string) error {
 			fmt.Printf("%s\n", sectionStyle.Render("💡 Key Themes (AI-generated)"))
 
 			// Get enhanced amplification data
-			amplifiedUsers, _ := tweetRepo.GetAmplifiedWithSources(since, 2)
+			amplifiedUsers, _ := tweetRepo.GetAmplifiedWithSources(since, until, 2, 10)
 			var llmAmplified []llm.AmplifiedUser
 			for _, a := range amplifiedUsers {
 				llmAmplified = append(llmAmplified, llm.AmplifiedUser{
@@ -175,34 +226,17 @@ func runDigest(cmd *cobra.Command, args []string) error {
 				})
 			}
 
-			// Get most active
-			tweetCounts := make(map[int64]int)
-			for _, t := range tweets {
-				tweetCounts[t.AccountID]++
-			}
 			var llmActive []llm.UserActivity
-			for accID, count := range tweetCounts {
-				if acc, ok := accountMap[accID]; ok {
-					llmActive = append(llmActive, llm.UserActivity{
-						Username: acc.Username,
-						Count:    count,
-					})
-				}
-			}
-			// Sort by count
-			for i := 0; i < len(llmActive); i++ {
-				for j := i + 1; j < len(llmActive); j++ {
-					if llmActive[j].Count > llmActive[i].Count {
-						llmActive[i], llmActive[j] = llmActive[j], llmActive[i]
-					}
-				}
-			}
-			if len(llmActive) > 5 {
-				llmActive = llmActive[:5]
+			for _, a := range active {
+				llmActive = append(llmActive, llm.UserActivity{
+					Username: a.Username,
+					Count:    a.Count,
+				})
 			}
 
 			// Get notable tweets
-			topTweets, _ := tweetRepo.GetTopTweets(since, 3)
+			top, _ := tweetRepo.Query(notableFilter(since, until, 3))
+			topTweets := top.Tweets
 			var llmNotable []llm.NotableTweet
 			for _, t := range topTweets {
 				if acc, ok := accountMap[t.AccountID]; ok {
@@ -227,7 +261,7 @@ func runDigest(cmd *cobra.Command, args []string) error {
 			}
 
 			prompt := llm.GenerateDigestPrompt(digestData)
-			client := llm.NewClient("http://localhost:11434", cfg.APIs.LLMModel)
+			client := llm.NewClient(cfg.APIs.LLMBaseURL, cfg.APIs.LLMModel)
 
 			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
 			response, err := client.Generate(ctx, prompt)
@@ -249,3 +283,61 @@ func runDigest(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// notableFilter selects the most engaging original tweets in a window
+func notableFilter(since, until time.Time, limit int) tweet.Filter {
+	return tweet.Filter{
+		Since: since,
+		Until: until,
+		Types: []string{"original"},
+		Sort:  tweet.SortEngagement,
+		Limit: limit,
+	}
+}
+
+// sharedVerb describes how tracked accounts amplified a post
+func sharedVerb(p tweet.AmplifiedPost) string {
+	switch {
+	case p.Quotes == 0:
+		return "RT'd"
+	case p.Retweets == 0:
+		return "quoted"
+	default:
+		return "RT'd or quoted"
+	}
+}
+
+// freshnessWarnings reports accounts whose fetch failed or was skipped
+// during [since, until) and, when the window reaches the present, stale data
+func freshnessWarnings(runRepo *fetchrun.Repository, cfg *config.Config, since, until time.Time) []string {
+	var warnings []string
+
+	// Only a window reaching the present can be stale; one ending within
+	// the allowance counts, as ranges ending now were resolved a moment ago
+	staleAfter := 2 * time.Duration(cfg.Fetch.DefaultInterval) * time.Minute
+	if time.Since(until) < staleAfter {
+		lastSuccess, err := runRepo.LastSuccess()
+		if err == nil {
+			if lastSuccess == nil {
+				warnings = append(warnings, "⚠️  No successful fetch recorded yet. Run 'xmon fetch'.")
+			} else if age := time.Since(*lastSuccess); age > staleAfter {
+				warnings = append(warnings, fmt.Sprintf("⚠️  Data is stale: last successful fetch was %s ago",
+					age.Round(time.Hour)))
+			}
+		}
+	}
+
+	failed, err := runRepo.FailedAccountsBetween(since, until)
+	if err == nil && len(failed) > 0 {
+		warnings = append(warnings, fmt.Sprintf("⚠️  Fetch failed for %d account(s) in this window: @%s (see 'xmon fetch history --failed')",
+			len(failed), strings.Join(failed, ", @")))
+	}
+
+	skipped, err := runRepo.SkippedAccountsBetween(since, until)
+	if err == nil && len(skipped) > 0 {
+		warnings = append(warnings, fmt.Sprintf("⚠️  Coverage gap: %d account(s) skipped to protect the API quota: @%s",
+			len(skipped), strings.Join(skipped, ", @")))
+	}
+
+	return warnings
+}
@@ -0,0 +1,108 @@
+// cmd/doctor.go
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/jpequegn/xmon/internal/config"
+	"github.com/jpequegn/xmon/internal/database"
+	"github.com/jpequegn/xmon/internal/doctor"
+	"github.com/jpequegn/xmon/internal/llm"
+	"github.com/jpequegn/xmon/internal/usage"
+	"github.com/jpequegn/xmon/internal/x"
+	"github.com/spf13/cobra"
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check config, database and API health",
+	Long: `Checks config validity, the X API token, database integrity and schema
+version, orphaned tweets, accounts that were never or not recently fetched,
+quota health and whether the LLM is reachable. Prints a fix for each problem
+and exits nonzero if any check fails, so it can run from cron.`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runDoctor,
+}
+
+var (
+	doctorOffline bool
+	doctorStrict  bool
+)
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+	doctorCmd.Flags().BoolVar(&doctorOffline, "offline", false, "Skip checks that call the X API or the LLM")
+	doctorCmd.Flags().BoolVar(&doctorStrict, "strict", false, "Exit nonzero on warnings too")
+}
+
+func runDoctor(cmd *cobra.Command, args []string) error {
+	env := doctor.Env{ConfigPath: config.ConfigPath()}
+	env.Config, env.ConfigErr = config.Load()
+
+	// Open without migrating, so pending migrations are reported rather
+	// than applied
+	if _, err := os.Stat(config.DBPath()); err != nil {
+		env.DBErr = fmt.Errorf("no database at %s", config.DBPath())
+	} else if env.DB, env.DBErr = database.Open(config.DBPath()); env.DBErr == nil {
+		defer env.DB.Close()
+	}
+
+	if env.Config != nil && !doctorOffline {
+		if env.Config.X.BearerToken != "" {
+			env.X = x.NewClient(env.Config.X.BearerToken)
+			if env.DB != nil {
+				if policy, err := usage.PolicyFromConfig(env.Config.Usage); err == nil {
+					usageRepo := usage.NewRepositoryWithPolicy(env.DB, policy)
+					env.X.OnRequest(func(endpoint string) { usageRepo.AddRequest(endpoint) })
+				}
+			}
+		}
+		env.LLM = llm.NewClient(env.Config.APIs.LLMBaseURL, env.Config.APIs.LLMModel)
+	}
+
+	results := doctor.Run(env)
+
+	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("39"))
+	okStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+	warnStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
+	errStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+
+	fmt.Printf("\n%s\n\n", titleStyle.Render("xmon doctor"))
+
+	failures, warnings := 0, 0
+	for _, r := range results {
+		var mark string
+		switch r.Status {
+		case doctor.StatusOK:
+			mark = okStyle.Render("✓")
+		case doctor.StatusWarn:
+			mark = warnStyle.Render("!")
+			warnings++
+		case doctor.StatusFail:
+			mark = errStyle.Render("✗")
+			failures++
+		default:
+			mark = dimStyle.Render("-")
+		}
+
+		fmt.Printf("  %s %-20s %s\n", mark, r.Name, r.Message)
+		if r.Fix != "" && (r.Status == doctor.StatusWarn || r.Status == doctor.StatusFail) {
+			fmt.Printf("    %s\n", dimStyle.Render("→ "+r.Fix))
+		}
+	}
+	fmt.Println()
+
+	if doctor.Failed(results, doctorStrict) {
+		return fmt.Errorf("doctor found %d failure(s) and %d warning(s)", failures, warnings)
+	}
+	if warnings > 0 {
+		fmt.Printf("%d warning(s), no failures.\n", warnings)
+	} else {
+		fmt.Println("All checks passed.")
+	}
+	return nil
+}
@@ -0,0 +1,200 @@
+// cmd/dump.go
+package cmd
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/jpequegn/xmon/internal/config"
+	"github.com/jpequegn/xmon/internal/database"
+	"github.com/jpequegn/xmon/internal/dump"
+	"github.com/spf13/cobra"
+)
+
+var dumpCmd = &cobra.Command{
+	Use:   "dump [file]",
+	Short: "Write the dataset as portable JSONL",
+	Long: `Writes accounts, tweets, daily aggregates, API usage and fetch history as
+versioned JSONL, one record per line, to share with teammates or move to
+another machine. Without a file, or with -, the dump goes to stdout. A file
+ending in .gz is gzipped.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runDump,
+}
+
+var loadCmd = &cobra.Command{
+	Use:   "load <file>",
+	Short: "Merge a JSONL dump into the database",
+	Long: `Merges a dump written by 'xmon dump'. Accounts are matched on their X user id
+and tweets on their tweet id, keeping the larger like and retweet counts, so
+loading the same dump twice is harmless. Use - to read from stdin; gzipped
+dumps are detected automatically.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runLoad,
+}
+
+var (
+	dumpAccounts   []string
+	dumpNoUsage    bool
+	dumpNoHistory  bool
+	loadDryRun     bool
+	loadArchiveNew bool
+)
+
+func init() {
+	rootCmd.AddCommand(dumpCmd)
+	rootCmd.AddCommand(loadCmd)
+	dumpCmd.Flags().StringSliceVar(&dumpAccounts, "account", nil, "Only dump these accounts and their tweets (repeatable)")
+	dumpCmd.Flags().BoolVar(&dumpNoUsage, "no-usage", false, "Leave out API usage counters")
+	dumpCmd.Flags().BoolVar(&dumpNoHistory, "no-history", false, "Leave out fetch history")
+	loadCmd.Flags().BoolVar(&loadDryRun, "dry-run", false, "Show what would be merged without changing the database")
+	loadCmd.Flags().BoolVar(&loadArchiveNew, "archive-new", false, "Add accounts you do not monitor yet as archived, so fetch does not poll them")
+}
+
+func runDump(cmd *cobra.Command, args []string) error {
+	db, err := database.New(config.DBPath())
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	path := "-"
+	if len(args) == 1 {
+		path = args[0]
+	}
+
+	opts := dump.Options{Usernames: dumpAccounts, NoUsage: dumpNoUsage, NoHistory: dumpNoHistory}
+
+	if path == "-" {
+		w := bufio.NewWriter(os.Stdout)
+		counts, err := dump.Write(w, db, opts)
+		if err != nil {
+			return fmt.Errorf("failed to write dump: %w", err)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Dumped %s\n", formatCounts(counts))
+		return nil
+	}
+
+	// Write next to the target so a failed dump never leaves a partial file
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("failed to create dump: %w", err)
+	}
+	defer os.Remove(tmp)
+
+	var w io.Writer = f
+	var gz *gzip.Writer
+	if strings.HasSuffix(path, ".gz") {
+		gz = gzip.NewWriter(f)
+		w = gz
+	}
+	bw := bufio.NewWriter(w)
+
+	counts, err := dump.Write(bw, db, opts)
+	if err == nil {
+		err = bw.Flush()
+	}
+	if err == nil && gz != nil {
+		err = gz.Close()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write dump: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write dump: %w", err)
+	}
+
+	fmt.Printf("✓ Dumped %s to %s\n", formatCounts(counts), path)
+	return nil
+}
+
+func runLoad(cmd *cobra.Command, args []string) error {
+	var in io.Reader = os.Stdin
+	if args[0] != "-" {
+		f, err := os.Open(args[0])
+		if err != nil {
+			return fmt.Errorf("failed to open dump: %w", err)
+		}
+		defer f.Close()
+		in = f
+	}
+
+	// Sniff the gzip magic rather than trusting the file name, so piped
+	// dumps work too
+	br := bufio.NewReader(in)
+	if magic, _ := br.Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return fmt.Errorf("failed to read gzipped dump: %w", err)
+		}
+		defer gz.Close()
+		in = gz
+	} else {
+		in = br
+	}
+
+	db, err := database.New(config.DBPath())
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	stats, err := dump.Load(in, db, dump.LoadOptions{DryRun: loadDryRun, ArchiveNew: loadArchiveNew})
+	if err != nil {
+		return fmt.Errorf("failed to load dump: %w", err)
+	}
+
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+
+	kinds := make([]string, 0, len(stats))
+	for kind := range stats {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+
+	if loadDryRun {
+		fmt.Println("Dry run, nothing was changed:")
+	} else {
+		fmt.Printf("✓ Loaded %s\n", args[0])
+	}
+	for _, kind := range kinds {
+		t := stats[kind]
+		line := fmt.Sprintf("  %-16s %d new, %d merged", kind, t.Added, t.Merged)
+		if t.Skipped > 0 {
+			line += dimStyle.Render(fmt.Sprintf(" (%d skipped)", t.Skipped))
+		}
+		fmt.Println(line)
+	}
+	return nil
+}
+
+// formatCounts renders record counts like "2 account, 10 tweet"
+func formatCounts(counts dump.Counts) string {
+	kinds := make([]string, 0, len(counts))
+	for kind := range counts {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+
+	parts := make([]string, 0, len(kinds))
+	for _, kind := range kinds {
+		parts = append(parts, fmt.Sprintf("%d %s", counts[kind], kind))
+	}
+	if len(parts) == 0 {
+		return "nothing"
+	}
+	return strings.Join(parts, ", ")
+}
@@ -11,6 +11,8 @@ import (
 	"github.com/jpequegn/xmon/internal/analysis"
 	"github.com/jpequegn/xmon/internal/config"
 	"github.com/jpequegn/xmon/internal/database"
+	"github.com/jpequegn/xmon/internal/rollup"
+	"github.com/jpequegn/xmon/internal/timerange"
 	"github.com/jpequegn/xmon/internal/tweet"
 	"github.com/spf13/cobra"
 )
@@ -23,91 +25,101 @@ var exportCmd = &cobra.Command{
 }
 
 var (
-	exportDays int
+	exportRange timerange.Options
 )
 
 func init() {
 	rootCmd.AddCommand(exportCmd)
-	exportCmd.Flags().IntVar(&exportDays, "days", 7, "Number of days to include in export")
+	addRangeFlags(exportCmd, &exportRange, "export")
 }
 
 func runExport(cmd *cobra.Command, args []string) error {
+	rng, loc, err := resolveRange(exportRange)
+	if err != nil {
+		return err
+	}
+	since, until := rng.Start, rng.End
+
 	db, err := database.New(config.DBPath())
 	if err != nil {
 		return fmt.Errorf("failed to open database: %w", err)
 	}
 	defer db.Close()
 
-	since := time.Now().AddDate(0, 0, -exportDays)
-	endDate := time.Now()
-
 	accountRepo := account.NewRepository(db)
 	tweetRepo := tweet.NewRepository(db)
+	rollups := rollup.NewRepository(db)
 
-	accounts, _ := accountRepo.List()
+	// Archived accounts still own tweets in the window, so map them too
+	accounts, _ := accountRepo.ListAll()
 	accountMap := make(map[int64]*account.Account)
+	monitored := 0
 	for i := range accounts {
 		accountMap[accounts[i].ID] = &accounts[i]
+		if !accounts[i].Archived() {
+			monitored++
+		}
 	}
 
-	originals, retweets, quotes, _ := tweetRepo.CountByType(since)
-	totalTweets := originals + retweets + quotes
-	tweets, _ := tweetRepo.GetSince(since)
+	window := tweet.Filter{Since: since, Until: until}
+	counts, _ := tweetRepo.CountByType(window)
+	originals, retweets, quotes := counts.Originals, counts.Retweets, counts.Quotes
+	totalTweets := counts.Total()
 
 	var sb strings.Builder
 
 	// Header
 	sb.WriteString(fmt.Sprintf("# X Activity Digest\n\n"))
-	sb.WriteString(fmt.Sprintf("**Period:** %s - %s\n\n",
-		since.Format("January 2, 2006"),
-		endDate.Format("January 2, 2006")))
+	sb.WriteString(fmt.Sprintf("**Period:** %s\n\n", rng.Label(loc, true)))
 
 	// Summary
 	sb.WriteString("## Summary\n\n")
-	sb.WriteString(fmt.Sprintf("- **Accounts monitored:** %d\n", len(accounts)))
+	sb.WriteString(fmt.Sprintf("- **Accounts monitored:** %d\n", monitored))
 	sb.WriteString(fmt.Sprintf("- **Total tweets:** %d\n", totalTweets))
 	sb.WriteString(fmt.Sprintf("- **Original tweets:** %d\n", originals))
 	sb.WriteString(fmt.Sprintf("- **Retweets:** %d\n", retweets))
 	sb.WriteString(fmt.Sprintf("- **Quote tweets:** %d\n\n", quotes))
 
-	// Most Active
-	if len(tweets) > 0 {
+	// Most Active, with what each talks about that the others don't
+	current, previous, err := signatures(rollups, since, until)
+	if err != nil {
+		return err
+	}
+	if active, _ := tweetRepo.MostActive(window, 10); len(active) > 0 {
 		sb.WriteString("## Most Active\n\n")
-		sb.WriteString("| Account | Tweets |\n")
-		sb.WriteString("|---------|-------:|\n")
-
-		tweetCounts := make(map[int64]int)
-		for _, t := range tweets {
-			tweetCounts[t.AccountID]++
+		sb.WriteString("| Account | Tweets | Signature topics |\n")
+		sb.WriteString("|---------|-------:|------------------|\n")
+		for _, a := range active {
+			sb.WriteString(fmt.Sprintf("| [@%s](https://x.com/%s) | %d | %s |\n",
+				a.Username, a.Username, a.Count, strings.Join(termNames(current[a.AccountID]), ", ")))
 		}
+		sb.WriteString("\n")
+	}
 
-		type accountTweets struct {
-			username string
-			count    int
-		}
-		var sorted []accountTweets
-		for accID, count := range tweetCounts {
-			if acc, ok := accountMap[accID]; ok {
-				sorted = append(sorted, accountTweets{acc.Username, count})
-			}
+	// Signature Shifts
+	var shifted []string
+	for accountID, topics := range current {
+		acc, ok := accountMap[accountID]
+		if !ok {
+			continue
 		}
-		sort.Slice(sorted, func(i, j int) bool {
-			return sorted[i].count > sorted[j].count
-		})
-
-		limit := 10
-		if len(sorted) < limit {
-			limit = len(sorted)
+		if shift := analysis.CompareSignatures(previous[accountID], topics); shift.Shifted {
+			shifted = append(shifted, fmt.Sprintf("- **@%s**: now %s, previously %s\n",
+				acc.Username, strings.Join(termNames(topics), ", "), strings.Join(shift.Dropped, ", ")))
 		}
-		for i := 0; i < limit; i++ {
-			sb.WriteString(fmt.Sprintf("| [@%s](https://x.com/%s) | %d |\n",
-				sorted[i].username, sorted[i].username, sorted[i].count))
+	}
+	if len(shifted) > 0 {
+		sort.Strings(shifted)
+		sb.WriteString("## Signature Shifts\n\n")
+		sb.WriteString("Accounts whose signature topics changed since the previous period of the same length.\n\n")
+		for _, line := range shifted {
+			sb.WriteString(line)
 		}
 		sb.WriteString("\n")
 	}
 
 	// Most Amplified
-	amplifiedUsers, _ := tweetRepo.GetAmplifiedWithSources(since, 2)
+	amplifiedUsers, _ := tweetRepo.GetAmplifiedWithSources(since, until, 2, 0)
 	if len(amplifiedUsers) > 0 {
 		sb.WriteString("## Most Amplified (retweeted by multiple follows)\n\n")
 		for _, a := range amplifiedUsers {
@@ -117,22 +129,58 @@ func runExport(cmd *cobra.Command, args []string) error {
 		sb.WriteString("\n")
 	}
 
-	// Trending Topics
-	var tweetContents []string
-	for _, t := range tweets {
-		if t.Content != "" {
-			tweetContents = append(tweetContents, t.Content)
+	// Most Shared Posts
+	posts, _ := tweetRepo.GetAmplifiedPosts(since, until, 2, 5)
+	if len(posts) > 0 {
+		sb.WriteString("## Most Shared Posts\n\n")
+		for _, p := range posts {
+			author := "unknown author"
+			link := "https://x.com/i/status/" + p.Post.TweetID
+			if p.Post.AuthorUsername != "" {
+				author = "@" + p.Post.AuthorUsername
+				link = fmt.Sprintf("https://x.com/%s/status/%s", p.Post.AuthorUsername, p.Post.TweetID)
+			}
+			content := truncate(strings.ReplaceAll(p.Post.Content, "\n", " "), 200)
+			sb.WriteString(fmt.Sprintf("**[%s](%s)**: %s\n", author, link, content))
+			sb.WriteString(fmt.Sprintf("> %s by %d of your accounts (%s) · %d likes · %d RTs\n\n",
+				sharedVerb(p), len(p.AmplifiedBy), "@"+strings.Join(p.AmplifiedBy, ", @"), p.Post.Likes, p.Post.Retweets))
 		}
 	}
-	topics := analysis.ExtractTopics(tweetContents, 10)
-	if len(topics) > 0 {
+
+	// Trending Topics, against the windows before this one
+	corpus, tweetContents, _ := windowCorpus(tweetRepo, window)
+	trends, _, _ := topicTrends(rollups, corpus, since, until, 5)
+	if len(trends) > 0 {
+		sb.WriteString("## Trending Topics\n\n")
+		for _, line := range trendArrows(trends) {
+			sb.WriteString(fmt.Sprintf("- %s\n", line))
+		}
+		sb.WriteString("\n")
+	} else if topics := analysis.ExtractTopics(tweetContents, 10); len(topics) > 0 {
 		sb.WriteString("## Trending Topics\n\n")
 		sb.WriteString(strings.Join(topics, " · "))
 		sb.WriteString("\n\n")
 	}
 
+	// Themes, clustered offline
+	if themes := analysis.DetectThemes(corpus, 6); len(themes) > 0 {
+		sb.WriteString("## Themes\n\n")
+		for _, theme := range themes {
+			sb.WriteString(fmt.Sprintf("### %s\n\n", theme.Name))
+			sb.WriteString(fmt.Sprintf("*%d tweets from %s*\n\n", theme.Count, themeAccounts(theme, accountMap, 5)))
+			sb.WriteString(fmt.Sprintf("Terms: %s\n\n", strings.Join(theme.Terms, " · ")))
+			for _, t := range theme.Tweets {
+				if acc, ok := accountMap[t.AccountID]; ok {
+					content := truncate(strings.ReplaceAll(t.Text, "\n", " "), 200)
+					sb.WriteString(fmt.Sprintf("> **@%s**: %s\n\n", acc.Username, content))
+				}
+			}
+		}
+	}
+
 	// Notable Tweets
-	topTweets, _ := tweetRepo.GetTopTweets(since, 5)
+	top, _ := tweetRepo.Query(notableFilter(since, until, 5))
+	topTweets := top.Tweets
 	if len(topTweets) > 0 {
 		sb.WriteString("## Notable Tweets\n\n")
 		for _, t := range topTweets {
@@ -152,7 +200,7 @@ func runExport(cmd *cobra.Command, args []string) error {
 	// Footer
 	sb.WriteString("---\n\n")
 	sb.WriteString(fmt.Sprintf("*Generated by [xmon](https://github.com/jpequegn/xmon) on %s*\n",
-		time.Now().Format("2006-01-02 15:04")))
+		time.Now().In(loc).Format("2006-01-02 15:04 MST")))
 
 	fmt.Print(sb.String())
 	return nil
@@ -2,11 +2,19 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"net/http"
+	"sort"
+	"time"
 
+	"github.com/charmbracelet/lipgloss"
 	"github.com/jpequegn/xmon/internal/account"
 	"github.com/jpequegn/xmon/internal/config"
 	"github.com/jpequegn/xmon/internal/database"
+	"github.com/jpequegn/xmon/internal/fetchrun"
+	"github.com/jpequegn/xmon/internal/ingest"
+	"github.com/jpequegn/xmon/internal/lock"
 	"github.com/jpequegn/xmon/internal/tweet"
 	"github.com/jpequegn/xmon/internal/usage"
 	"github.com/jpequegn/xmon/internal/x"
@@ -20,8 +28,28 @@ var fetchCmd = &cobra.Command{
 	RunE:  runFetch,
 }
 
+var fetchHistoryCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Show past fetch runs",
+	Long:  `Lists recent fetch runs with per-account results, errors and rate-limit state.`,
+	Args:  cobra.NoArgs,
+	RunE:  runFetchHistory,
+}
+
+var (
+	fetchAllowOverQuota bool
+	fetchWait           bool
+	historyFailed       bool
+	historyLimit        int
+)
+
 func init() {
 	rootCmd.AddCommand(fetchCmd)
+	fetchCmd.Flags().BoolVar(&fetchAllowOverQuota, "allow-over-quota", false, "Fetch even if it eats into the quota reserve")
+	fetchCmd.Flags().BoolVar(&fetchWait, "wait", false, "If another fetch is running, wait for it to finish and show its results")
+	fetchCmd.AddCommand(fetchHistoryCmd)
+	fetchHistoryCmd.Flags().BoolVar(&historyFailed, "failed", false, "Only show runs with errors or skipped accounts")
+	fetchHistoryCmd.Flags().IntVar(&historyLimit, "limit", 10, "Number of runs to show")
 }
 
 func runFetch(cmd *cobra.Command, args []string) error {
@@ -34,6 +62,17 @@ func runFetch(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("X API bearer token not set. Add it to %s", config.ConfigPath())
 	}
 
+	// Only one fetch at a time across processes, so a manual fetch never
+	// spends quota on the same tweets as the daemon
+	fetchLock, holder, err := lock.TryAcquire(config.FetchLockPath())
+	if errors.Is(err, lock.ErrLocked) {
+		return handOffFetch(holder)
+	}
+	if err != nil {
+		return err
+	}
+	defer fetchLock.Release()
+
 	db, err := database.New(config.DBPath())
 	if err != nil {
 		return fmt.Errorf("failed to open database: %w", err)
@@ -41,15 +80,30 @@ func runFetch(cmd *cobra.Command, args []string) error {
 	defer db.Close()
 
 	accountRepo := account.NewRepository(db)
-	tweetRepo := tweet.NewRepository(db)
-	usageRepo := usage.NewRepository(db)
+	usageRepo, err := usageRepository(cfg, db)
+	if err != nil {
+		return err
+	}
+	runRepo := fetchrun.NewRepository(db)
+
+	runID, err := runRepo.Start()
+	if err != nil {
+		return fmt.Errorf("failed to record fetch run: %w", err)
+	}
+
+	client := x.NewClient(cfg.X.BearerToken)
+	client.OnRequest(func(endpoint string) { usageRepo.AddRequest(endpoint) })
+	writer := ingest.NewWriter(db, usageRepo)
 
 	accounts, err := accountRepo.List()
 	if err != nil {
-		return fmt.Errorf("failed to list accounts: %w", err)
+		err = fmt.Errorf("failed to list accounts: %w", err)
+		runRepo.Finish(runID, 0, time.Time{}, err)
+		return err
 	}
 
 	if len(accounts) == 0 {
+		runRepo.Finish(runID, 0, time.Time{}, nil)
 		fmt.Println("No accounts to fetch. Run 'xmon add <username>' first.")
 		return nil
 	}
@@ -59,64 +113,137 @@ func runFetch(cmd *cobra.Command, args []string) error {
 		fmt.Println(warning)
 	}
 
-	client := x.NewClient(cfg.X.BearerToken)
+	current, err := usageRepo.GetCurrentCycle()
+	if err != nil {
+		err = fmt.Errorf("failed to load usage: %w", err)
+		runRepo.Finish(runID, 0, time.Time{}, err)
+		return err
+	}
+	guard := usage.NewGuard(usageRepo.Policy(), current.TweetsRead, fetchAllowOverQuota)
+	if fetchAllowOverQuota {
+		fmt.Println("⚠️  Quota guard overridden (--allow-over-quota)")
+	}
+
+	// Fetch high-priority accounts first so they get the quota that is left
+	sort.SliceStable(accounts, func(i, j int) bool {
+		return priorityRank(accounts[i].Priority) < priorityRank(accounts[j].Priority)
+	})
 
 	fmt.Printf("Fetching tweets for %d accounts...\n\n", len(accounts))
 
 	totalTweets := 0
+	skipped := 0
 
 	for _, acc := range accounts {
+		result := fetchrun.AccountResult{
+			RunID:     runID,
+			AccountID: acc.ID,
+			Username:  acc.Username,
+		}
+
+		pageSize, reason := guard.Allow(acc.Priority)
+		if pageSize == 0 {
+			fmt.Printf("  @%s: skipped - %s\n", acc.Username, reason)
+			skipped++
+			result.Skipped = reason
+			if err := runRepo.RecordAccount(result); err != nil {
+				fmt.Printf("  @%s: failed to record fetch result - %v\n", acc.Username, err)
+			}
+			continue
+		}
+
 		client.WaitForRateLimit()
 
-		tweetsResp, err := client.GetUserTweets(acc.UserID, "")
+		tweetsResp, err := client.GetUserTweets(acc.UserID, acc.SinceID, pageSize)
 		if err != nil {
 			fmt.Printf("  @%s: error - %v\n", acc.Username, err)
+			result.HTTPStatus = x.StatusCode(err)
+			result.Error = err.Error()
+			if err := runRepo.RecordAccount(result); err != nil {
+				fmt.Printf("  @%s: failed to record fetch result - %v\n", acc.Username, err)
+			}
 			continue
 		}
 
-		count := 0
+		page := ingest.Page{
+			AccountID:  acc.ID,
+			NewestID:   tweetsResp.Meta.NewestID,
+			TweetsRead: len(tweetsResp.Data),
+		}
+		guard.Spend(page.TweetsRead)
 		for _, tw := range tweetsResp.Data {
-			tweetType := x.GetTweetType(tw)
-
-			// Get referenced user for RTs/quotes
+			// Get referenced post and its author for RTs/quotes
 			refUser := ""
 			refTweetID := ""
 			if len(tw.ReferencedTweets) > 0 {
 				refTweetID = tw.ReferencedTweets[0].ID
-				// Try to find the author in includes
-				for _, u := range tweetsResp.Includes.Users {
-					refUser = u.Username
-					break
+			}
+			if post, author := tweetsResp.Referenced(tw); post != nil {
+				refTweetID = post.ID
+				ref := tweet.Referenced{
+					TweetID:   post.ID,
+					AuthorID:  post.AuthorID,
+					Content:   post.FullText(),
+					Likes:     post.PublicMetrics.LikeCount,
+					Retweets:  post.PublicMetrics.RetweetCount,
+					CreatedAt: post.CreatedAt,
+				}
+				if author != nil {
+					refUser = author.Username
+					ref.AuthorUsername = author.Username
 				}
+				page.Referenced = append(page.Referenced, ref)
 			}
 
-			err := tweetRepo.Add(
-				acc.ID,
-				tw.ID,
-				tweetType,
-				tw.Text,
-				refUser,
-				refTweetID,
-				tw.PublicMetrics.LikeCount,
-				tw.PublicMetrics.RetweetCount,
-				tw.CreatedAt,
-			)
-			if err == nil {
-				count++
-			}
+			page.Tweets = append(page.Tweets, tweet.Tweet{
+				AccountID:         acc.ID,
+				TweetID:           tw.ID,
+				TweetType:         x.GetTweetType(tw),
+				Content:           tw.Text,
+				ReferencedUser:    refUser,
+				ReferencedTweetID: refTweetID,
+				Likes:             tw.PublicMetrics.LikeCount,
+				Retweets:          tw.PublicMetrics.RetweetCount,
+				CreatedAt:         tw.CreatedAt,
+				Lang:              tw.Lang,
+			})
 		}
 
-		// Track API usage
-		if len(tweetsResp.Data) > 0 {
-			usageRepo.AddTweetsRead(len(tweetsResp.Data))
+		count, err := writer.Write(page)
+		if err != nil {
+			fmt.Printf("  @%s: failed to store tweets - %v\n", acc.Username, err)
+			// The API call was billed even though nothing was stored
+			if page.TweetsRead > 0 {
+				usageRepo.AddTweetsRead(page.TweetsRead)
+			}
+			result.HTTPStatus = http.StatusOK
+			result.TweetsRead = page.TweetsRead
+			result.Error = fmt.Sprintf("store: %v", err)
+			if err := runRepo.RecordAccount(result); err != nil {
+				fmt.Printf("  @%s: failed to record fetch result - %v\n", acc.Username, err)
+			}
+			continue
 		}
 
-		accountRepo.UpdateLastFetched(acc.ID)
 		fmt.Printf("  @%s: %d tweets\n", acc.Username, count)
 		totalTweets += count
+
+		result.HTTPStatus = http.StatusOK
+		result.NewTweets = count
+		result.TweetsRead = page.TweetsRead
+		if err := runRepo.RecordAccount(result); err != nil {
+			fmt.Printf("  @%s: failed to record fetch result - %v\n", acc.Username, err)
+		}
+	}
+
+	if err := runRepo.Finish(runID, client.RateLimitRemaining(), client.RateLimitReset(), nil); err != nil {
+		fmt.Printf("Failed to record fetch run: %v\n", err)
 	}
 
 	fmt.Printf("\nFetch complete: %d new tweets\n", totalTweets)
+	if skipped > 0 {
+		fmt.Printf("%d account(s) skipped to protect the quota reserve. Use --allow-over-quota to override.\n", skipped)
+	}
 
 	if client.RateLimitRemaining() > 0 {
 		fmt.Printf("Rate limit: %d requests remaining (resets %s)\n",
@@ -129,10 +256,155 @@ func runFetch(cmd *cobra.Command, args []string) error {
 		fmt.Println(warning)
 	} else {
 		remaining, _ := usageRepo.GetRemainingQuota()
-		fmt.Printf("API quota: %d/%d tweets remaining this month\n", remaining, usage.MonthlyLimit)
+		policy := usageRepo.Policy()
+		fmt.Printf("API quota: %d/%d tweets remaining this cycle (%s tier, resets %s)\n",
+			remaining, policy.Limits.MonthlyReads, policy.Tier,
+			policy.CycleStart(time.Now()).AddDate(0, 1, 0).Format("Jan 2"))
 	}
 
 	fmt.Println("Run 'xmon digest' to see the summary.")
 
 	return nil
 }
+
+func runFetchHistory(cmd *cobra.Command, args []string) error {
+	loc := displayLocation()
+
+	db, err := database.New(config.DBPath())
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	runRepo := fetchrun.NewRepository(db)
+	runs, err := runRepo.List(historyLimit, historyFailed)
+	if err != nil {
+		return fmt.Errorf("failed to list fetch runs: %w", err)
+	}
+
+	if len(runs) == 0 {
+		if historyFailed {
+			fmt.Println("No failed fetch runs.")
+		} else {
+			fmt.Println("No fetch runs recorded yet. Run 'xmon fetch' first.")
+		}
+		return nil
+	}
+
+	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("39"))
+	userStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("205"))
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+	errStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+
+	fmt.Printf("\n%s\n\n", titleStyle.Render("Fetch History"))
+
+	for _, run := range runs {
+		duration := "running"
+		if run.FinishedAt != nil {
+			duration = run.FinishedAt.Sub(run.StartedAt).Round(time.Second).String()
+		}
+
+		status := run.Status
+		if run.Status == fetchrun.StatusFailed || run.Status == fetchrun.StatusPartial {
+			status = errStyle.Render(status)
+		}
+
+		fmt.Printf("  #%d %s  %s  %s\n",
+			run.ID,
+			run.StartedAt.In(loc).Format("2006-01-02 15:04"),
+			status,
+			dimStyle.Render(duration))
+		fmt.Printf("    %s\n", dimStyle.Render(fmt.Sprintf("%d new tweets · %d read · %d errors · %d skipped",
+			run.NewTweets, run.TweetsRead, run.Errors, run.Skipped)))
+		if run.Error != "" {
+			fmt.Printf("    %s\n", errStyle.Render(run.Error))
+		}
+		if run.RateLimitRemaining != nil && run.RateLimitReset != nil {
+			fmt.Printf("    %s\n", dimStyle.Render(fmt.Sprintf("rate limit: %d remaining (resets %s)",
+				*run.RateLimitRemaining, run.RateLimitReset.In(loc).Format("15:04"))))
+		}
+
+		results, err := runRepo.Accounts(run.ID)
+		if err != nil {
+			return fmt.Errorf("failed to load results for run %d: %w", run.ID, err)
+		}
+		for _, res := range results {
+			if historyFailed && !res.Failed() && res.Skipped == "" {
+				continue
+			}
+			if res.Skipped != "" {
+				fmt.Printf("    %s %s\n", userStyle.Render("@"+res.Username), dimStyle.Render("skipped: "+res.Skipped))
+			} else if res.Failed() {
+				msg := res.Error
+				if res.HTTPStatus != 0 {
+					msg = fmt.Sprintf("HTTP %d: %s", res.HTTPStatus, res.Error)
+				}
+				fmt.Printf("    %s %s\n", userStyle.Render("@"+res.Username), errStyle.Render(msg))
+			} else {
+				fmt.Printf("    %s %d new\n", userStyle.Render("@"+res.Username), res.NewTweets)
+			}
+		}
+		fmt.Println()
+	}
+
+	return nil
+}
+
+// handOffFetch handles a fetch that is already running in another process.
+// Fetching again right after it would only re-read the same tweets, so we
+// either leave it to finish or wait and report what it fetched.
+func handOffFetch(holder int) error {
+	who := "another xmon process"
+	if holder > 0 {
+		who = fmt.Sprintf("another xmon process (pid %d)", holder)
+	}
+
+	if !fetchWait {
+		fmt.Printf("A fetch is already running in %s. Its tweets will show up in 'xmon digest'.\n", who)
+		fmt.Println("Use --wait to wait for it and see its results.")
+		return nil
+	}
+
+	fmt.Printf("Waiting for the fetch running in %s...\n", who)
+	if err := lock.Wait(config.FetchLockPath(), 0); err != nil {
+		return fmt.Errorf("failed waiting for fetch lock: %w", err)
+	}
+
+	db, err := database.New(config.DBPath())
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	runs, err := fetchrun.NewRepository(db).List(1, false)
+	if err != nil || len(runs) == 0 {
+		fmt.Println("Fetch finished.")
+		return err
+	}
+	run := runs[0]
+	fmt.Printf("Fetch #%d finished (%s): %d new tweets, %d errors, %d skipped\n",
+		run.ID, run.Status, run.NewTweets, run.Errors, run.Skipped)
+	fmt.Println("Run 'xmon digest' to see the summary.")
+	return nil
+}
+
+// priorityRank orders account priorities for fetching, high first
+func priorityRank(priority string) int {
+	switch priority {
+	case usage.PriorityHigh:
+		return 0
+	case usage.PriorityLow:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// usageRepository tracks API usage against the billing policy from the config
+func usageRepository(cfg *config.Config, db *database.DB) (*usage.Repository, error) {
+	policy, err := usage.PolicyFromConfig(cfg.Usage)
+	if err != nil {
+		return nil, fmt.Errorf("invalid usage config in %s: %w", config.ConfigPath(), err)
+	}
+	return usage.NewRepositoryWithPolicy(db, policy), nil
+}
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/jpequegn/xmon/internal/account"
+	"github.com/jpequegn/xmon/internal/config"
+	"github.com/jpequegn/xmon/internal/database"
+	"github.com/jpequegn/xmon/internal/usage"
+	"github.com/spf13/cobra"
+)
+
+var priorityCmd = &cobra.Command{
+	Use:   "priority <username> <high|normal|low>",
+	Short: "Set an account's fetch priority",
+	Long:  `Sets how early an account is skipped when the API quota runs low. Low-priority accounts are skipped first.`,
+	Args:  cobra.ExactArgs(2),
+	RunE:  runPriority,
+}
+
+func init() {
+	rootCmd.AddCommand(priorityCmd)
+}
+
+func runPriority(cmd *cobra.Command, args []string) error {
+	username, priority := args[0], args[1]
+
+	if !validPriority(priority) {
+		return fmt.Errorf("invalid priority %q (expected high, normal or low)", priority)
+	}
+
+	db, err := database.New(config.DBPath())
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	repo := account.NewRepository(db)
+	if !repo.Exists(username) {
+		return fmt.Errorf("account @%s is not being monitored", username)
+	}
+
+	if err := repo.SetPriority(username, priority); err != nil {
+		return fmt.Errorf("failed to set priority: %w", err)
+	}
+
+	fmt.Printf("Set @%s to %s priority\n", username, priority)
+	return nil
+}
+
+func validPriority(priority string) bool {
+	switch priority {
+	case usage.PriorityHigh, usage.PriorityNormal, usage.PriorityLow:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,129 @@
+// cmd/profile.go
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/jpequegn/xmon/internal/config"
+	"github.com/jpequegn/xmon/internal/database"
+	"github.com/spf13/cobra"
+)
+
+var profileCmd = &cobra.Command{
+	Use:   "profile",
+	Short: "Manage profiles with separate watchlists, tokens and databases",
+	Long: `Profiles keep separate configs and databases, e.g. one watchlist per topic.
+The default profile lives in the xmon home directory (~/.xmon, $XMON_HOME or
+--home); others live in its profiles/ directory. Select one per command
+with --profile, or switch with 'xmon profile use'.`,
+}
+
+var profileListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List profiles",
+	Args:  cobra.NoArgs,
+	RunE:  runProfileList,
+}
+
+var profileCreateCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "Create a profile",
+	Long:  `Creates a profile with a default config and an empty database. The X API token is copied from the active profile unless --no-token is given.`,
+	Args:  cobra.ExactArgs(1),
+	RunE:  runProfileCreate,
+}
+
+var profileUseCmd = &cobra.Command{
+	Use:   "use <name>",
+	Short: "Switch the current profile",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runProfileUse,
+}
+
+var profileNoToken bool
+
+func init() {
+	rootCmd.AddCommand(profileCmd)
+	profileCmd.AddCommand(profileListCmd)
+	profileCmd.AddCommand(profileCreateCmd)
+	profileCmd.AddCommand(profileUseCmd)
+	profileCreateCmd.Flags().BoolVar(&profileNoToken, "no-token", false, "Don't copy the X API token from the active profile")
+}
+
+func runProfileList(cmd *cobra.Command, args []string) error {
+	profiles, err := config.Profiles()
+	if err != nil {
+		return fmt.Errorf("failed to list profiles: %w", err)
+	}
+
+	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("39"))
+	userStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("205"))
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+
+	fmt.Printf("\n%s %s\n\n", titleStyle.Render("Profiles"), dimStyle.Render(config.HomeDir()))
+
+	active := config.Profile()
+	for _, name := range profiles {
+		marker := "  "
+		if name == active {
+			marker = "* "
+		}
+		fmt.Printf("  %s%s %s\n", marker, userStyle.Render(fmt.Sprintf("%-12s", name)), dimStyle.Render(config.ProfileDir(name)))
+	}
+	fmt.Println()
+
+	return nil
+}
+
+func runProfileCreate(cmd *cobra.Command, args []string) error {
+	name := args[0]
+	if err := config.ValidProfileName(name); err != nil {
+		return err
+	}
+	if config.ProfileExists(name) {
+		return fmt.Errorf("profile %q already exists", name)
+	}
+
+	// The token comes from the profile we are creating it from
+	token := ""
+	if active, err := config.Load(); err == nil && !profileNoToken {
+		token = active.X.BearerToken
+	}
+
+	dir := config.ProfileDir(name)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create profile directory: %w", err)
+	}
+
+	config.SetProfile(name)
+	cfg := config.DefaultConfig()
+	cfg.X.BearerToken = token
+	if err := cfg.Save(); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	db, err := database.New(config.DBPath())
+	if err != nil {
+		return fmt.Errorf("failed to create database: %w", err)
+	}
+	db.Close()
+
+	fmt.Printf("Created profile %s in %s\n", name, dir)
+	if token == "" {
+		fmt.Printf("Set its X API token with 'xmon --profile %s init'.\n", name)
+	}
+	fmt.Printf("Run 'xmon profile use %s' to switch to it.\n", name)
+	return nil
+}
+
+func runProfileUse(cmd *cobra.Command, args []string) error {
+	name := args[0]
+	if err := config.UseProfile(name); err != nil {
+		return fmt.Errorf("%w (run 'xmon profile create %s')", err, name)
+	}
+
+	fmt.Printf("Switched to profile %s (%s)\n", name, config.ProfileDir(name))
+	return nil
+}
@@ -0,0 +1,110 @@
+// cmd/prune.go
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jpequegn/xmon/internal/config"
+	"github.com/jpequegn/xmon/internal/database"
+	"github.com/jpequegn/xmon/internal/retention"
+	"github.com/spf13/cobra"
+)
+
+var pruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Delete old tweets according to the retention policy",
+	Long: `Deletes raw tweets older than retention.tweet_days. Before deleting, tweets are
+rolled up into per-account daily aggregates, which are kept forever. Starred
+tweets are kept unless retention.keep_starred is false. The database is
+vacuumed and analyzed afterwards.`,
+	Args: cobra.NoArgs,
+	RunE: runPrune,
+}
+
+var (
+	pruneDryRun bool
+	pruneDays   int
+)
+
+func init() {
+	rootCmd.AddCommand(pruneCmd)
+	pruneCmd.Flags().BoolVar(&pruneDryRun, "dry-run", false, "Show what would be deleted without deleting anything")
+	pruneCmd.Flags().IntVar(&pruneDays, "days", 0, "Keep tweets this many days (default: retention.tweet_days)")
+}
+
+func runPrune(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w (run 'xmon init' first)", err)
+	}
+
+	retentionCfg := cfg.Retention
+	if pruneDays > 0 {
+		retentionCfg.TweetDays = pruneDays
+	}
+	policy, err := retention.PolicyFromConfig(retentionCfg)
+	if err != nil {
+		return fmt.Errorf("invalid retention config in %s: %w", config.ConfigPath(), err)
+	}
+	if !policy.Enabled() {
+		fmt.Printf("Retention is disabled (retention.tweet_days is 0 in %s), nothing to prune.\n", config.ConfigPath())
+		return nil
+	}
+
+	db, err := database.New(config.DBPath())
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	pruner := retention.NewPruner(db, policy)
+
+	if pruneDryRun {
+		plan, err := pruner.Plan(time.Now())
+		if err != nil {
+			return fmt.Errorf("failed to plan prune: %w", err)
+		}
+		fmt.Printf("Would delete %d tweets from before %s (%d account-days rolled up first)\n",
+			plan.Tweets, plan.Cutoff.Format("2006-01-02"), plan.Days)
+		if plan.Starred > 0 {
+			fmt.Printf("Would keep %d starred tweets\n", plan.Starred)
+		}
+		return nil
+	}
+
+	sizeBefore, _ := db.Size()
+
+	result, err := pruner.Prune(time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to prune: %w", err)
+	}
+	if err := pruner.Optimize(); err != nil {
+		return err
+	}
+
+	sizeAfter, _ := db.Size()
+
+	fmt.Printf("Deleted %d tweets from before %s (%d account-days rolled up)\n",
+		result.Tweets, result.Cutoff.Format("2006-01-02"), result.Days)
+	if result.Starred > 0 {
+		fmt.Printf("Kept %d starred tweets\n", result.Starred)
+	}
+	fmt.Printf("Database size: %s -> %s\n", formatBytes(sizeBefore), formatBytes(sizeAfter))
+
+	return nil
+}
+
+// formatBytes renders a byte count with a binary unit
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,137 @@
+// cmd/query.go
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/jpequegn/xmon/internal/config"
+	"github.com/jpequegn/xmon/internal/database"
+	"github.com/jpequegn/xmon/internal/query"
+	"github.com/spf13/cobra"
+)
+
+var queryCmd = &cobra.Command{
+	Use:   "query <sql|saved-name> [args...]",
+	Short: "Run a read-only SQL query",
+	Long: `Runs a SELECT against the database on a read-only connection and prints the
+result as a table, CSV or JSON. Extra arguments are bound to ? placeholders.
+
+Prefer these views, whose column names are stable:
+  v_tweets          one row per stored tweet
+  v_amplification   one row per retweet or quote, with the post it amplifies
+  v_account_daily   daily rollups per account, including pruned days
+
+Queries can be saved by name under "queries:" in the config and run by name:
+  queries:
+    top_posts: SELECT username, text, engagement FROM v_tweets ORDER BY engagement DESC LIMIT ?
+
+  xmon query top_posts 10
+
+Use --list to see saved queries and view columns.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if queryList {
+			return nil
+		}
+		return cobra.MinimumNArgs(1)(cmd, args)
+	},
+	RunE: runQuery,
+}
+
+var (
+	queryFormat string
+	queryWidth  int
+	queryList   bool
+)
+
+func init() {
+	rootCmd.AddCommand(queryCmd)
+	queryCmd.Flags().StringVarP(&queryFormat, "format", "f", "table", "Output format: "+strings.Join(query.Formats, ", "))
+	queryCmd.Flags().IntVar(&queryWidth, "width", 60, "Cut table cells longer than this, 0 to keep them whole")
+	queryCmd.Flags().BoolVar(&queryList, "list", false, "List saved queries and views")
+}
+
+func runQuery(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		cfg = config.DefaultConfig()
+	}
+
+	if queryList {
+		printQueryList(cfg.Queries)
+		return nil
+	}
+
+	sql := args[0]
+	if saved, ok := cfg.Queries[sql]; ok {
+		sql = saved
+	}
+
+	// Bring the schema, and with it the views, up to date before switching
+	// to a connection that cannot write
+	if _, err := os.Stat(config.DBPath()); os.IsNotExist(err) {
+		return fmt.Errorf("no database at %s, run 'xmon init' first", config.DBPath())
+	}
+	db, err := database.New(config.DBPath())
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	db.Close()
+
+	db, err = database.OpenReadOnly(config.DBPath())
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	result, err := query.Run(db, sql, queryArgs(args[1:])...)
+	if err != nil {
+		return fmt.Errorf("query failed: %w", err)
+	}
+	return query.Write(os.Stdout, result, queryFormat, displayLocation(), queryWidth)
+}
+
+// queryArgs passes numeric arguments as numbers, so they work with LIMIT
+// and compare as numbers
+func queryArgs(args []string) []any {
+	values := make([]any, len(args))
+	for i, a := range args {
+		if n, err := strconv.ParseInt(a, 10, 64); err == nil {
+			values[i] = n
+		} else if f, err := strconv.ParseFloat(a, 64); err == nil {
+			values[i] = f
+		} else {
+			values[i] = a
+		}
+	}
+	return values
+}
+
+func printQueryList(saved map[string]string) {
+	sectionStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("39"))
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+
+	fmt.Printf("\n%s\n", sectionStyle.Render("Saved queries"))
+	if len(saved) == 0 {
+		fmt.Printf("  %s\n", dimStyle.Render("None. Add them under 'queries:' in "+config.ConfigPath()))
+	}
+	names := make([]string, 0, len(saved))
+	for name := range saved {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Printf("  %-20s %s\n", name, dimStyle.Render(strings.Join(strings.Fields(saved[name]), " ")))
+	}
+
+	fmt.Printf("\n%s\n", sectionStyle.Render("Views"))
+	for _, v := range query.Views {
+		fmt.Printf("  %-20s %s\n", v.Name, v.Description)
+		fmt.Printf("  %-20s %s\n", "", dimStyle.Render(strings.Join(v.Columns, ", ")))
+	}
+	fmt.Println()
+}
@@ -13,13 +13,18 @@ import (
 var removeCmd = &cobra.Command{
 	Use:   "remove <username>",
 	Short: "Remove an X account from monitoring",
-	Long:  `Removes an X account from your monitoring list.`,
-	Args:  cobra.ExactArgs(1),
-	RunE:  runRemove,
+	Long: `Removes an X account from your monitoring list. The account is archived and
+its tweets are kept; 'xmon add' restores it. Use --purge to delete the account
+and all of its tweets.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runRemove,
 }
 
+var removePurge bool
+
 func init() {
 	rootCmd.AddCommand(removeCmd)
+	removeCmd.Flags().BoolVar(&removePurge, "purge", false, "Delete the account and all of its tweets")
 }
 
 func runRemove(cmd *cobra.Command, args []string) error {
@@ -32,6 +37,20 @@ func runRemove(cmd *cobra.Command, args []string) error {
 	defer db.Close()
 
 	repo := account.NewRepository(db)
+
+	if removePurge {
+		// Archived accounts can be purged too
+		if _, err := repo.Get(username); err != nil {
+			return fmt.Errorf("account @%s not found", username)
+		}
+		deleted, err := repo.Purge(username)
+		if err != nil {
+			return fmt.Errorf("failed to purge account: %w", err)
+		}
+		fmt.Printf("Purged @%s and %d tweets\n", username, deleted)
+		return nil
+	}
+
 	if !repo.Exists(username) {
 		return fmt.Errorf("account @%s is not being monitored", username)
 	}
@@ -40,6 +59,6 @@ func runRemove(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to remove account: %w", err)
 	}
 
-	fmt.Printf("Removed @%s from monitoring\n", username)
+	fmt.Printf("Removed @%s from monitoring (history kept, use --purge to delete it)\n", username)
 	return nil
 }
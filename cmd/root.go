@@ -5,13 +5,48 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/jpequegn/xmon/internal/config"
 	"github.com/spf13/cobra"
 )
 
 var rootCmd = &cobra.Command{
-	Use:   "xmon",
-	Short: "Monitor X/Twitter accounts and get activity digests",
-	Long:  `xmon helps you track influential X accounts, digest their tweets, and surface early signals about emerging topics.`,
+	Use:               "xmon",
+	Short:             "Monitor X/Twitter accounts and get activity digests",
+	Long:              `xmon helps you track influential X accounts, digest their tweets, and surface early signals about emerging topics.`,
+	PersistentPreRunE: selectProfile,
+}
+
+var (
+	rootHome    string
+	rootProfile string
+)
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&rootHome, "home", "", "xmon home directory (default $"+config.HomeEnv+" or ~/.xmon)")
+	rootCmd.PersistentFlags().StringVar(&rootProfile, "profile", "", "Profile to use instead of the current one")
+}
+
+// selectProfile applies --home and --profile before any command touches the
+// config or database
+func selectProfile(cmd *cobra.Command, args []string) error {
+	if rootHome != "" {
+		config.SetHome(rootHome)
+	}
+	if rootProfile != "" {
+		if err := config.ValidProfileName(rootProfile); err != nil {
+			return err
+		}
+		config.SetProfile(rootProfile)
+	}
+
+	// Profile commands manage profiles that may not exist yet
+	if cmd.Parent() != nil && cmd.Parent().Name() == "profile" {
+		return nil
+	}
+	if profile := config.Profile(); !config.ProfileExists(profile) {
+		return fmt.Errorf("profile %q does not exist (run 'xmon profile create %s')", profile, profile)
+	}
+	return nil
 }
 
 func Execute() {
@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/jpequegn/xmon/internal/config"
+	"github.com/jpequegn/xmon/internal/database"
+	"github.com/jpequegn/xmon/internal/tweet"
+	"github.com/spf13/cobra"
+)
+
+var searchCmd = &cobra.Command{
+	Use:   "search <query>",
+	Short: "Search stored tweets",
+	Long: `Full-text search over the tweet archive, ranked by relevance.
+
+Filters can be mixed with search terms:
+  from:naval,pmarca   tweets by these accounts
+  type:quote          original, retweet or quote
+  tag:ai,llm          containing any of these hashtags
+  lang:en             written in this language
+  since:2025-06-01    on or after this date
+  until:2025-06-30    on or before this date
+  min_likes:1000      at least this many likes
+  min_rts:100         at least this many retweets
+
+Use "quotes" for phrases, e.g. xmon search '"open source" from:naval'.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runSearch,
+}
+
+var (
+	searchLimit int
+	searchJSON  bool
+)
+
+func init() {
+	rootCmd.AddCommand(searchCmd)
+	searchCmd.Flags().IntVar(&searchLimit, "limit", 20, "Maximum number of results")
+	searchCmd.Flags().BoolVar(&searchJSON, "json", false, "Output as JSON")
+}
+
+type searchResultJSON struct {
+	TweetID   string    `json:"tweet_id"`
+	Username  string    `json:"username"`
+	Type      string    `json:"type"`
+	Content   string    `json:"content"`
+	Highlight string    `json:"highlight"`
+	Likes     int       `json:"likes"`
+	Retweets  int       `json:"retweets"`
+	CreatedAt time.Time `json:"created_at"`
+	Score     float64   `json:"score"`
+	URL       string    `json:"url"`
+}
+
+func runSearch(cmd *cobra.Command, args []string) error {
+	query, err := tweet.ParseSearchQuery(strings.Join(args, " "))
+	if err != nil {
+		return err
+	}
+	query.Limit = searchLimit
+
+	db, err := database.New(config.DBPath())
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	results, err := tweet.NewRepository(db).Search(query)
+	if err != nil {
+		return fmt.Errorf("search failed: %w", err)
+	}
+
+	if searchJSON {
+		out := []searchResultJSON{}
+		for _, r := range results {
+			out = append(out, searchResultJSON{
+				TweetID:   r.TweetID,
+				Username:  r.Username,
+				Type:      r.TweetType,
+				Content:   r.Content,
+				Highlight: highlight(r.Snippet, "**", "**"),
+				Likes:     r.Likes,
+				Retweets:  r.Retweets,
+				CreatedAt: r.CreatedAt,
+				Score:     r.Score,
+				URL:       fmt.Sprintf("https://x.com/%s/status/%s", r.Username, r.TweetID),
+			})
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(out)
+	}
+
+	if len(results) == 0 {
+		fmt.Println("No matching tweets.")
+		return nil
+	}
+
+	userStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("39"))
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+	matchStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("205"))
+
+	fmt.Println()
+	for _, r := range results {
+		fmt.Printf("%s %s\n",
+			userStyle.Render("@"+r.Username),
+			dimStyle.Render(fmt.Sprintf("· %s · %s", r.CreatedAt.Format("Jan 2, 2006"), r.TweetType)))
+
+		snippet := strings.ReplaceAll(r.Snippet, "\n", " ")
+		var sb strings.Builder
+		for {
+			start := strings.Index(snippet, tweet.HighlightStart)
+			if start < 0 {
+				break
+			}
+			end := strings.Index(snippet[start:], tweet.HighlightEnd)
+			if end < 0 {
+				break
+			}
+			end += start
+			sb.WriteString(snippet[:start])
+			sb.WriteString(matchStyle.Render(snippet[start+len(tweet.HighlightStart) : end]))
+			snippet = snippet[end+len(tweet.HighlightEnd):]
+		}
+		sb.WriteString(snippet)
+
+		fmt.Printf("  %s\n", sb.String())
+		fmt.Printf("  %s\n\n", dimStyle.Render(fmt.Sprintf("↳ %d likes · %d RTs · https://x.com/%s/status/%s",
+			r.Likes, r.Retweets, r.Username, r.TweetID)))
+	}
+	fmt.Printf("%s\n", dimStyle.Render(fmt.Sprintf("%d result(s)", len(results))))
+
+	return nil
+}
+
+// highlight swaps the repository's match markers for the given strings
+func highlight(snippet, start, end string) string {
+	snippet = strings.ReplaceAll(snippet, tweet.HighlightStart, start)
+	return strings.ReplaceAll(snippet, tweet.HighlightEnd, end)
+}
@@ -3,12 +3,15 @@ package cmd
 
 import (
 	"fmt"
-	"time"
+	"strings"
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/jpequegn/xmon/internal/account"
+	"github.com/jpequegn/xmon/internal/analysis"
 	"github.com/jpequegn/xmon/internal/config"
 	"github.com/jpequegn/xmon/internal/database"
+	"github.com/jpequegn/xmon/internal/rollup"
+	"github.com/jpequegn/xmon/internal/timerange"
 	"github.com/jpequegn/xmon/internal/tweet"
 	"github.com/spf13/cobra"
 )
@@ -22,17 +25,22 @@ var showCmd = &cobra.Command{
 }
 
 var (
-	showDays int
+	showRange timerange.Options
 )
 
 func init() {
 	rootCmd.AddCommand(showCmd)
-	showCmd.Flags().IntVar(&showDays, "days", 7, "Number of days to show")
+	addRangeFlags(showCmd, &showRange, "the activity summary")
 }
 
 func runShow(cmd *cobra.Command, args []string) error {
 	username := args[0]
 
+	rng, loc, err := resolveRange(showRange)
+	if err != nil {
+		return err
+	}
+
 	db, err := database.New(config.DBPath())
 	if err != nil {
 		return fmt.Errorf("failed to open database: %w", err)
@@ -41,19 +49,28 @@ func runShow(cmd *cobra.Command, args []string) error {
 
 	accountRepo := account.NewRepository(db)
 	tweetRepo := tweet.NewRepository(db)
+	rollups := rollup.NewRepository(db)
 
 	acc, err := accountRepo.Get(username)
 	if err != nil {
 		return fmt.Errorf("account @%s not found", username)
 	}
 
-	since := time.Now().AddDate(0, 0, -showDays)
-
-	tweets, _ := tweetRepo.GetForAccount(acc.ID, since)
+	window := tweet.Filter{AccountIDs: []int64{acc.ID}, Since: rng.Start, Until: rng.End}
+	counts, err := tweetRepo.CountByType(window)
+	if err != nil {
+		return fmt.Errorf("failed to count tweets: %w", err)
+	}
+	window.Limit = 5
+	recent, err := tweetRepo.Query(window)
+	if err != nil {
+		return fmt.Errorf("failed to get tweets: %w", err)
+	}
 
 	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("205"))
 	sectionStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("39"))
 	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+	warnStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
 
 	fmt.Printf("\n%s\n", titleStyle.Render("@"+acc.Username))
 	if acc.Name != "" {
@@ -64,39 +81,41 @@ func runShow(cmd *cobra.Command, args []string) error {
 	}
 	fmt.Printf("%s\n\n", dimStyle.Render(fmt.Sprintf("%d followers", acc.Followers)))
 
-	// Count by type
-	originals, retweets, quotes := 0, 0, 0
-	for _, t := range tweets {
-		switch t.TweetType {
-		case "original":
-			originals++
-		case "retweet":
-			retweets++
-		case "quote":
-			quotes++
+	fmt.Printf("%s (%s)\n", sectionStyle.Render("Activity"), rng.Label(loc, false))
+	fmt.Printf("  Originals: %d\n", counts.Originals)
+	fmt.Printf("  Retweets:  %d\n", counts.Retweets)
+	fmt.Printf("  Quotes:    %d\n", counts.Quotes)
+	fmt.Println()
+
+	// What this account talks about that the others don't
+	current, previous, err := signatures(rollups, rng.Start, rng.End)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s\n", sectionStyle.Render("Signature Topics"))
+	if topics := current[acc.ID]; len(topics) > 0 {
+		fmt.Printf("  %s\n", strings.Join(termNames(topics), " · "))
+		if shift := analysis.CompareSignatures(previous[acc.ID], topics); shift.Shifted {
+			before := timerange.Range{Start: rng.Start.Add(-rng.End.Sub(rng.Start)), End: rng.Start}
+			fmt.Printf("  %s\n", warnStyle.Render(fmt.Sprintf("↳ Shifted from %s (%s)",
+				strings.Join(shift.Dropped, " · "), before.Label(loc, false))))
 		}
+	} else {
+		fmt.Printf("  %s\n", dimStyle.Render("Nothing that sets this account apart yet"))
 	}
+	fmt.Println()
 
-	fmt.Printf("%s (last %d days)\n", sectionStyle.Render("Activity"), showDays)
-	fmt.Printf("  Originals: %d\n", originals)
-	fmt.Printf("  Retweets:  %d\n", retweets)
-	fmt.Printf("  Quotes:    %d\n", quotes)
+	fmt.Printf("%s\n", sectionStyle.Render("Last 12 Months"))
+	if err := printTrend(rollups, []int64{acc.ID}, "  "); err != nil {
+		return err
+	}
 	fmt.Println()
 
 	// Recent tweets
-	if len(tweets) > 0 {
+	if len(recent.Tweets) > 0 {
 		fmt.Printf("%s\n", sectionStyle.Render("Recent Tweets"))
-		limit := 5
-		if len(tweets) < limit {
-			limit = len(tweets)
-		}
-		for i := 0; i < limit; i++ {
-			t := tweets[i]
-			content := t.Content
-			if len(content) > 70 {
-				content = content[:67] + "..."
-			}
-			fmt.Printf("  %s %s\n", dimStyle.Render(t.CreatedAt.Format("Jan 2")), content)
+		for _, t := range recent.Tweets {
+			fmt.Printf("  %s %s\n", dimStyle.Render(t.CreatedAt.In(loc).Format("Jan 2")), truncate(t.Content, 70))
 		}
 	}
 
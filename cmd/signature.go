@@ -0,0 +1,38 @@
+// cmd/signature.go
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jpequegn/xmon/internal/analysis"
+	"github.com/jpequegn/xmon/internal/rollup"
+)
+
+// signatureLimit is how many signature topics are kept per account
+const signatureLimit = 5
+
+// signatures returns every account's signature topics in [since, until)
+// and in the window of the same length before it, each scored against all
+// accounts that tweeted in that window. Both are read from daily topic
+// rollups, so nothing is tokenized.
+func signatures(rollups *rollup.Repository, since, until time.Time) (map[int64][]analysis.TermScore, map[int64][]analysis.TermScore, error) {
+	current, err := rollups.AccountTerms(since, until)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read topic rollups: %w", err)
+	}
+	previous, err := rollups.AccountTerms(since.Add(-until.Sub(since)), since)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read topic rollups: %w", err)
+	}
+	return analysis.SignatureTopics(current, signatureLimit), analysis.SignatureTopics(previous, signatureLimit), nil
+}
+
+// termNames lists the terms of scores
+func termNames(scores []analysis.TermScore) []string {
+	names := make([]string, len(scores))
+	for i, s := range scores {
+		names[i] = s.Term
+	}
+	return names
+}
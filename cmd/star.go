@@ -0,0 +1,80 @@
+// cmd/star.go
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/jpequegn/xmon/internal/account"
+	"github.com/jpequegn/xmon/internal/config"
+	"github.com/jpequegn/xmon/internal/database"
+	"github.com/jpequegn/xmon/internal/tweet"
+	"github.com/spf13/cobra"
+)
+
+var starCmd = &cobra.Command{
+	Use:   "star [tweet-id]",
+	Short: "Star a tweet so it is never pruned",
+	Long:  `Stars a stored tweet by its X id. Starred tweets are kept by 'xmon prune'. Without an id, lists starred tweets.`,
+	Args:  cobra.MaximumNArgs(1),
+	RunE:  runStar,
+}
+
+var starRemove bool
+
+func init() {
+	rootCmd.AddCommand(starCmd)
+	starCmd.Flags().BoolVar(&starRemove, "remove", false, "Unstar the tweet")
+}
+
+func runStar(cmd *cobra.Command, args []string) error {
+	db, err := database.New(config.DBPath())
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	tweetRepo := tweet.NewRepository(db)
+
+	if len(args) == 1 {
+		if err := tweetRepo.SetStarred(args[0], !starRemove); err != nil {
+			return fmt.Errorf("failed to update star: %w", err)
+		}
+		if starRemove {
+			fmt.Printf("Unstarred tweet %s\n", args[0])
+		} else {
+			fmt.Printf("Starred tweet %s\n", args[0])
+		}
+		return nil
+	}
+
+	res, err := tweetRepo.Query(tweet.Filter{StarredOnly: true})
+	if err != nil {
+		return fmt.Errorf("failed to list starred tweets: %w", err)
+	}
+	tweets := res.Tweets
+	if len(tweets) == 0 {
+		fmt.Println("No starred tweets. Run 'xmon star <tweet-id>' to star one.")
+		return nil
+	}
+
+	accounts, _ := account.NewRepository(db).ListAll()
+	usernames := make(map[int64]string)
+	for _, acc := range accounts {
+		usernames[acc.ID] = acc.Username
+	}
+
+	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("39"))
+	userStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("205"))
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+
+	fmt.Printf("\n%s\n\n", titleStyle.Render("Starred Tweets"))
+	for _, t := range tweets {
+		content := truncate(t.Content, 100)
+		fmt.Printf("  %s %s\n", userStyle.Render("@"+usernames[t.AccountID]), dimStyle.Render(t.CreatedAt.Format("2006-01-02")+" · "+t.TweetID))
+		fmt.Printf("    %s\n", content)
+	}
+	fmt.Println()
+
+	return nil
+}
@@ -0,0 +1,34 @@
+// cmd/themes.go
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jpequegn/xmon/internal/account"
+	"github.com/jpequegn/xmon/internal/analysis"
+)
+
+// themeAccounts names up to n of a theme's accounts, with a count of the rest
+func themeAccounts(theme analysis.Theme, accountMap map[int64]*account.Account, n int) string {
+	var names []string
+	for _, id := range theme.Accounts[:min(n, len(theme.Accounts))] {
+		if acc, ok := accountMap[id]; ok {
+			names = append(names, "@"+acc.Username)
+		}
+	}
+	s := strings.Join(names, ", ")
+	if rest := len(theme.Accounts) - n; rest > 0 {
+		s += fmt.Sprintf(" +%d", rest)
+	}
+	return s
+}
+
+// truncate shortens s to at most n runes, ending in "..." when cut
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n-3]) + "..."
+}
@@ -0,0 +1,45 @@
+// cmd/timerange.go
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/jpequegn/xmon/internal/config"
+	"github.com/jpequegn/xmon/internal/timerange"
+	"github.com/spf13/cobra"
+)
+
+// addRangeFlags registers --days, --week, --month, --since and --until
+func addRangeFlags(cmd *cobra.Command, opts *timerange.Options, what string) {
+	cmd.Flags().IntVar(&opts.Days, "days", 7, "Number of days to include in "+what)
+	cmd.Flags().StringVar(&opts.Week, "week", "", "ISO week to include, e.g. 2025-W40")
+	cmd.Flags().StringVar(&opts.Month, "month", "", "Calendar month to include, e.g. 2025-10")
+	cmd.Flags().StringVar(&opts.Since, "since", "", "First day to include (YYYY-MM-DD)")
+	cmd.Flags().StringVar(&opts.Until, "until", "", "Last day to include (YYYY-MM-DD)")
+}
+
+// resolveRange turns range flags into absolute times, with calendar days in
+// the display timezone
+func resolveRange(opts timerange.Options) (timerange.Range, *time.Location, error) {
+	loc := displayLocation()
+	r, err := timerange.Resolve(opts, time.Now(), loc)
+	return r, loc, err
+}
+
+// displayLocation is the timezone times are shown in: display.timezone from
+// the config, or the system zone
+func displayLocation() *time.Location {
+	cfg, err := config.Load()
+	if err != nil {
+		return time.Local
+	}
+	loc, err := cfg.Display.Location()
+	if err != nil {
+		// On stderr so redirected markdown, CSV or JSON stays clean
+		fmt.Fprintf(os.Stderr, "⚠️  %v, using the system timezone\n", err)
+		return time.Local
+	}
+	return loc
+}
@@ -0,0 +1,154 @@
+// cmd/trend.go
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/jpequegn/xmon/internal/analysis"
+	"github.com/jpequegn/xmon/internal/rollup"
+	"github.com/jpequegn/xmon/internal/tweet"
+)
+
+// trendMonths is how far back the rollup trend views go
+const trendMonths = 12
+
+// baselineWindows is how many windows before the current one topic trends
+// are measured against
+const baselineWindows = 4
+
+// windowCorpus reads and tokenizes the tweets matching window once, for
+// topicTrends and themes alike, also returning their texts
+func windowCorpus(tweetRepo *tweet.Repository, window tweet.Filter) (analysis.Corpus, []string, error) {
+	texts, err := tweetRepo.Texts(window)
+	if err != nil {
+		return analysis.Corpus{}, nil, fmt.Errorf("failed to read tweet texts: %w", err)
+	}
+	docs := make([]analysis.Doc, len(texts))
+	contents := make([]string, len(texts))
+	for i, t := range texts {
+		docs[i] = analysis.Doc{AccountID: t.AccountID, Text: t.Content, Lang: t.Lang, CreatedAt: t.CreatedAt}
+		contents[i] = t.Content
+	}
+	return analysis.NewCorpus(docs), contents, nil
+}
+
+// topicTrends classifies the terms of corpus, the tweets of [since, until),
+// against the baselineWindows windows of the same length before it, keeping
+// limit terms per status. The baseline comes from daily topic rollups, so
+// it still covers pruned days. It also returns whether any earlier window
+// had tweets to compare against.
+func topicTrends(rollups *rollup.Repository, corpus analysis.Corpus, since, until time.Time, limit int) ([]analysis.Trend, bool, error) {
+	length := until.Sub(since)
+	baseline := make([]analysis.TermCounts, baselineWindows)
+	hasBaseline := false
+	for i := range baseline {
+		end := since.Add(-time.Duration(i) * length)
+		var err error
+		if baseline[i], err = rollups.TermCounts(end.Add(-length), end); err != nil {
+			return nil, false, fmt.Errorf("failed to read topic rollups: %w", err)
+		}
+		hasBaseline = hasBaseline || baseline[i].Tweets > 0
+	}
+
+	current := corpus.Counts(rollup.TopicsPerDay)
+	return analysis.DetectTrends(current, baseline, analysis.TrendOptions{Limit: limit}), hasBaseline, nil
+}
+
+// trendArrows groups trends into one line per status, each term marked
+// with its arrow: ↑ emerging with its lift, → sustained, ↓ fading
+func trendArrows(trends []analysis.Trend) []string {
+	arrows := map[analysis.TrendStatus]string{analysis.Emerging: "↑", analysis.Sustained: "→", analysis.Fading: "↓"}
+	var lines []string
+	var terms []string
+	for i, t := range trends {
+		term := arrows[t.Status] + " " + t.Term
+		switch {
+		case t.Status == analysis.Emerging && t.New:
+			term += " (new)"
+		case t.Status == analysis.Emerging:
+			term += fmt.Sprintf(" (×%.1f)", t.Lift)
+		}
+		terms = append(terms, term)
+		if i == len(trends)-1 || trends[i+1].Status != t.Status {
+			lines = append(lines, strings.Join(terms, " · "))
+			terms = nil
+		}
+	}
+	return lines
+}
+
+// rising returns the emerging and sustained terms, for the LLM prompt
+func rising(trends []analysis.Trend) []string {
+	var terms []string
+	for _, t := range trends {
+		if t.Status != analysis.Fading {
+			terms = append(terms, t.Term)
+		}
+	}
+	return terms
+}
+
+// printTrend prints monthly tweet counts as bars, followed by the top
+// topics and amplified users of the period, all read from daily rollups
+func printTrend(rollups *rollup.Repository, accountIDs []int64, indent string) error {
+	since, until := rollup.LastMonths(time.Now(), trendMonths)
+	months, err := rollups.Monthly(accountIDs, since, until)
+	if err != nil {
+		return fmt.Errorf("failed to read monthly rollups: %w", err)
+	}
+
+	barStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("39"))
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+
+	peak := 0
+	for _, m := range months {
+		if n := m.Tweets(); n > peak {
+			peak = n
+		}
+	}
+	if peak == 0 {
+		fmt.Printf("%s%s\n", indent, dimStyle.Render("No activity recorded in the last 12 months"))
+		return nil
+	}
+
+	const width = 30
+	for _, m := range months {
+		label := m.Month
+		if t, err := time.Parse("2006-01", m.Month); err == nil {
+			label = t.Format("Jan 2006")
+		}
+		bar := strings.Repeat("█", m.Tweets()*width/peak)
+		if bar == "" && m.Tweets() > 0 {
+			bar = "▏"
+		}
+		fmt.Printf("%s%-8s  %s %s\n", indent, label, barStyle.Render(fmt.Sprintf("%-*s", width, bar)),
+			dimStyle.Render(fmt.Sprintf("%d tweets · %d likes", m.Tweets(), m.LikesReceived)))
+	}
+
+	topics, err := rollups.TopTopics(accountIDs, since, until, 8)
+	if err != nil {
+		return fmt.Errorf("failed to read topic rollups: %w", err)
+	}
+	if len(topics) > 0 {
+		fmt.Printf("%sTopics:    %s\n", indent, dimStyle.Render(joinCounts(topics, "")))
+	}
+	amplified, err := rollups.TopAmplified(accountIDs, since, until, 5)
+	if err != nil {
+		return fmt.Errorf("failed to read amplification rollups: %w", err)
+	}
+	if len(amplified) > 0 {
+		fmt.Printf("%sAmplified: %s\n", indent, dimStyle.Render(joinCounts(amplified, "@")))
+	}
+	return nil
+}
+
+func joinCounts(counts []rollup.Count, prefix string) string {
+	parts := make([]string, len(counts))
+	for i, c := range counts {
+		parts[i] = fmt.Sprintf("%s%s (%d)", prefix, c.Name, c.Count)
+	}
+	return strings.Join(parts, " · ")
+}
@@ -0,0 +1,261 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/jpequegn/xmon/internal/config"
+	"github.com/jpequegn/xmon/internal/database"
+	"github.com/jpequegn/xmon/internal/usage"
+	"github.com/spf13/cobra"
+)
+
+var usageCmd = &cobra.Command{
+	Use:   "usage",
+	Short: "Show X API usage and quota projection",
+	Long:  `Displays API usage for the current billing cycle, a daily burn chart, past cycles, and when the quota will run out at the current rate.`,
+	Args:  cobra.NoArgs,
+	RunE:  runUsage,
+}
+
+var (
+	usageCycles int
+	usageJSON   bool
+)
+
+func init() {
+	rootCmd.AddCommand(usageCmd)
+	usageCmd.Flags().IntVar(&usageCycles, "cycles", 6, "Number of past billing cycles to show")
+	usageCmd.Flags().BoolVar(&usageJSON, "json", false, "Output as JSON")
+}
+
+type usageReport struct {
+	Tier       string           `json:"tier"`
+	Limit      int              `json:"limit"`
+	Current    usageCycleJSON   `json:"current"`
+	Projection usageProjJSON    `json:"projection"`
+	Daily      []usageDailyJSON `json:"daily"`
+	History    []usageCycleJSON `json:"history"`
+}
+
+type usageCycleJSON struct {
+	Cycle      string         `json:"cycle"`
+	Start      string         `json:"start"`
+	End        string         `json:"end"`
+	TweetsRead int            `json:"tweets_read"`
+	Requests   map[string]int `json:"requests"`
+}
+
+type usageProjJSON struct {
+	DailyRate      float64    `json:"daily_rate"`
+	ProjectedTotal int        `json:"projected_total"`
+	ExhaustedAt    *time.Time `json:"exhausted_at"`
+}
+
+type usageDailyJSON struct {
+	Day        string `json:"day"`
+	TweetsRead int    `json:"tweets_read"`
+	Requests   int    `json:"requests"`
+}
+
+func runUsage(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w (run 'xmon init' first)", err)
+	}
+	loc, err := cfg.Display.Location()
+	if err != nil {
+		return err
+	}
+
+	db, err := database.New(config.DBPath())
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	usageRepo, err := usageRepository(cfg, db)
+	if err != nil {
+		return err
+	}
+	policy := usageRepo.Policy()
+	now := time.Now()
+
+	current, err := usageRepo.GetCurrentCycle()
+	if err != nil {
+		return fmt.Errorf("failed to load usage: %w", err)
+	}
+
+	daily, err := usageRepo.Daily(current.Start, now)
+	if err != nil {
+		return fmt.Errorf("failed to load daily usage: %w", err)
+	}
+
+	history, err := usageRepo.ListCycles(usageCycles + 1)
+	if err != nil {
+		return fmt.Errorf("failed to load usage history: %w", err)
+	}
+	var past []usage.CycleUsage
+	for _, c := range history {
+		if c.Cycle != current.Cycle && len(past) < usageCycles {
+			past = append(past, c)
+		}
+	}
+
+	projection := usage.Project(current, policy.Limits.MonthlyReads, now)
+
+	if usageJSON {
+		return printUsageJSON(policy, current, projection, daily, past)
+	}
+
+	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("205"))
+	sectionStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("39"))
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+	barStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("39"))
+	warnStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
+
+	limit := policy.Limits.MonthlyReads
+
+	fmt.Printf("\n%s (%s tier)\n", titleStyle.Render("X API USAGE"), policy.Tier)
+	fmt.Println(dimStyle.Render("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━"))
+
+	fmt.Printf("\n%s (%s - %s)\n",
+		sectionStyle.Render("Current Cycle"),
+		current.Start.Format("Jan 2"),
+		current.End.Format("Jan 2, 2006"))
+	fmt.Printf("  Tweets read: %d/%d (%.0f%%)\n", current.TweetsRead, limit, percent(current.TweetsRead, limit))
+	if len(current.Requests) > 0 {
+		fmt.Printf("  Requests:    %s\n", formatRequests(current.Requests, policy.Limits.Endpoints))
+	}
+
+	if projection.ExhaustedAt != nil {
+		fmt.Printf("  Projection:  %s\n", warnStyle.Render(fmt.Sprintf(
+			"quota runs out ~%s at %.0f tweets/day (%d projected by %s)",
+			projection.ExhaustedAt.In(loc).Format("Jan 2 15:04"),
+			projection.DailyRate,
+			projection.ProjectedTotal,
+			current.End.Format("Jan 2"))))
+	} else {
+		fmt.Printf("  Projection:  %d/%d by %s at %.0f tweets/day\n",
+			projection.ProjectedTotal, limit, current.End.Format("Jan 2"), projection.DailyRate)
+	}
+
+	if warning := usageRepo.CheckQuota(); warning != "" {
+		for _, line := range strings.Split(warning, "\n") {
+			fmt.Printf("  %s\n", warnStyle.Render(line))
+		}
+	}
+	fmt.Println()
+
+	// Daily burn chart
+	if len(daily) > 0 {
+		fmt.Printf("%s\n", sectionStyle.Render("Daily Burn"))
+		maxRead := 0
+		for _, d := range daily {
+			if d.TweetsRead > maxRead {
+				maxRead = d.TweetsRead
+			}
+		}
+		const barWidth = 30
+		for _, d := range daily {
+			width := 0
+			if maxRead > 0 {
+				width = d.TweetsRead * barWidth / maxRead
+			}
+			if width == 0 && d.TweetsRead > 0 {
+				width = 1
+			}
+			fmt.Printf("  %s %s %d\n",
+				dimStyle.Render(d.Day.Format("Jan 02")),
+				barStyle.Render(strings.Repeat("█", width)),
+				d.TweetsRead)
+		}
+		fmt.Println()
+	}
+
+	// Past cycles
+	if len(past) > 0 {
+		fmt.Printf("%s\n", sectionStyle.Render("Past Cycles"))
+		for _, c := range past {
+			fmt.Printf("  %s  %d/%d (%.0f%%)",
+				dimStyle.Render(c.Start.Format("Jan 2, 2006")), c.TweetsRead, limit, percent(c.TweetsRead, limit))
+			if len(c.Requests) > 0 {
+				fmt.Printf("  %s", dimStyle.Render(formatRequests(c.Requests, nil)))
+			}
+			fmt.Println()
+		}
+		fmt.Println()
+	}
+
+	return nil
+}
+
+func printUsageJSON(policy usage.Policy, current *usage.CycleUsage, projection usage.Projection, daily []usage.DailyUsage, past []usage.CycleUsage) error {
+	report := usageReport{
+		Tier:    policy.Tier,
+		Limit:   policy.Limits.MonthlyReads,
+		Current: cycleJSON(*current),
+		Projection: usageProjJSON{
+			DailyRate:      projection.DailyRate,
+			ProjectedTotal: projection.ProjectedTotal,
+			ExhaustedAt:    projection.ExhaustedAt,
+		},
+		Daily:   []usageDailyJSON{},
+		History: []usageCycleJSON{},
+	}
+	for _, d := range daily {
+		report.Daily = append(report.Daily, usageDailyJSON{
+			Day:        d.Day.Format("2006-01-02"),
+			TweetsRead: d.TweetsRead,
+			Requests:   d.Requests,
+		})
+	}
+	for _, c := range past {
+		report.History = append(report.History, cycleJSON(c))
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
+
+func cycleJSON(c usage.CycleUsage) usageCycleJSON {
+	return usageCycleJSON{
+		Cycle:      c.Cycle,
+		Start:      c.Start.Format("2006-01-02"),
+		End:        c.End.Format("2006-01-02"),
+		TweetsRead: c.TweetsRead,
+		Requests:   c.Requests,
+	}
+}
+
+// formatRequests renders per-endpoint request counts, with caps where known
+func formatRequests(requests map[string]int, caps map[string]int) string {
+	endpoints := make([]string, 0, len(requests))
+	for endpoint := range requests {
+		endpoints = append(endpoints, endpoint)
+	}
+	sort.Strings(endpoints)
+
+	var parts []string
+	for _, endpoint := range endpoints {
+		if limit, ok := caps[endpoint]; ok {
+			parts = append(parts, fmt.Sprintf("%s %d/%d", endpoint, requests[endpoint], limit))
+		} else {
+			parts = append(parts, fmt.Sprintf("%s %d", endpoint, requests[endpoint]))
+		}
+	}
+	return strings.Join(parts, " · ")
+}
+
+func percent(used, limit int) float64 {
+	if limit <= 0 {
+		return 0
+	}
+	return float64(used) / float64(limit) * 100
+}
@@ -2,6 +2,8 @@
 package account
 
 import (
+	"database/sql"
+	"fmt"
 	"time"
 
 	"github.com/jpequegn/xmon/internal/database"
@@ -16,6 +18,14 @@ type Account struct {
 	Followers   int
 	AddedAt     time.Time
 	LastFetched *time.Time
+	SinceID     string
+	Priority    string
+	ArchivedAt  *time.Time
+}
+
+// Archived reports whether the account was removed from monitoring
+func (a *Account) Archived() bool {
+	return a.ArchivedAt != nil
 }
 
 type Repository struct {
@@ -26,21 +36,84 @@ func NewRepository(db *database.DB) *Repository {
 	return &Repository{db: db}
 }
 
+// Add starts monitoring an account. If the user is already known (including
+// archived), its profile is refreshed and it is restored in place, keeping
+// its id so existing tweets stay attached.
 func (r *Repository) Add(userID, username, name, bio string, followers int) error {
-	_, err := r.db.Exec(
-		`INSERT OR REPLACE INTO accounts (user_id, username, name, bio, followers) VALUES (?, ?, ?, ?, ?)`,
-		userID, username, name, bio, followers,
-	)
+	_, err := r.db.Exec(`
+		INSERT INTO accounts (user_id, username, name, bio, followers) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET
+			username = excluded.username,
+			name = excluded.name,
+			bio = excluded.bio,
+			followers = excluded.followers,
+			archived_at = NULL
+	`, userID, username, name, bio, followers)
 	return err
 }
 
+// Remove stops monitoring an account but keeps it, and its tweets, archived
 func (r *Repository) Remove(username string) error {
-	_, err := r.db.Exec(`DELETE FROM accounts WHERE username = ?`, username)
+	_, err := r.db.Exec(`UPDATE accounts SET archived_at = CURRENT_TIMESTAMP WHERE username = ? AND archived_at IS NULL`, username)
+	return err
+}
+
+// Restore resumes monitoring an archived account
+func (r *Repository) Restore(username string) error {
+	_, err := r.db.Exec(`UPDATE accounts SET archived_at = NULL WHERE username = ?`, username)
 	return err
 }
 
+// Purge deletes an account together with all of its tweets and daily
+// rollups, pruned days included, and returns how many tweets were deleted
+func (r *Repository) Purge(username string) (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`DELETE FROM tweets WHERE account_id IN (SELECT id FROM accounts WHERE username = ?)`, username)
+	if err != nil {
+		return 0, err
+	}
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	// Rollups outlive pruned tweets, and their readers do not join accounts
+	for _, table := range []string{"account_daily", "account_daily_topics", "account_daily_amplified"} {
+		if _, err := tx.Exec(`DELETE FROM `+table+` WHERE account_id IN (SELECT id FROM accounts WHERE username = ?)`, username); err != nil {
+			return 0, err
+		}
+	}
+
+	if _, err := tx.Exec(`DELETE FROM accounts WHERE username = ?`, username); err != nil {
+		return 0, err
+	}
+
+	return int(deleted), tx.Commit()
+}
+
+// List returns the actively monitored accounts
 func (r *Repository) List() ([]Account, error) {
-	rows, err := r.db.Query(`SELECT id, user_id, username, name, bio, followers, added_at, last_fetched FROM accounts ORDER BY username`)
+	return r.list(`WHERE archived_at IS NULL`)
+}
+
+// ListArchived returns accounts that were removed but still have history
+func (r *Repository) ListArchived() ([]Account, error) {
+	return r.list(`WHERE archived_at IS NOT NULL`)
+}
+
+// ListAll returns monitored and archived accounts, e.g. to resolve the
+// author of any stored tweet
+func (r *Repository) ListAll() ([]Account, error) {
+	return r.list(``)
+}
+
+func (r *Repository) list(where string) ([]Account, error) {
+	rows, err := r.db.Query(`SELECT id, user_id, username, COALESCE(name, ''), COALESCE(bio, ''), COALESCE(followers, 0), added_at, last_fetched, since_id, priority, archived_at FROM accounts ` + where + ` ORDER BY username`)
 	if err != nil {
 		return nil, err
 	}
@@ -49,7 +122,7 @@ func (r *Repository) List() ([]Account, error) {
 	var accounts []Account
 	for rows.Next() {
 		var a Account
-		if err := rows.Scan(&a.ID, &a.UserID, &a.Username, &a.Name, &a.Bio, &a.Followers, &a.AddedAt, &a.LastFetched); err != nil {
+		if err := rows.Scan(&a.ID, &a.UserID, &a.Username, &a.Name, &a.Bio, &a.Followers, &a.AddedAt, &a.LastFetched, &a.SinceID, &a.Priority, &a.ArchivedAt); err != nil {
 			return nil, err
 		}
 		accounts = append(accounts, a)
@@ -60,9 +133,9 @@ func (r *Repository) List() ([]Account, error) {
 func (r *Repository) Get(username string) (*Account, error) {
 	var a Account
 	err := r.db.QueryRow(
-		`SELECT id, user_id, username, name, bio, followers, added_at, last_fetched FROM accounts WHERE username = ?`,
+		`SELECT id, user_id, username, COALESCE(name, ''), COALESCE(bio, ''), COALESCE(followers, 0), added_at, last_fetched, since_id, priority, archived_at FROM accounts WHERE username = ?`,
 		username,
-	).Scan(&a.ID, &a.UserID, &a.Username, &a.Name, &a.Bio, &a.Followers, &a.AddedAt, &a.LastFetched)
+	).Scan(&a.ID, &a.UserID, &a.Username, &a.Name, &a.Bio, &a.Followers, &a.AddedAt, &a.LastFetched, &a.SinceID, &a.Priority, &a.ArchivedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -72,18 +145,25 @@ func (r *Repository) Get(username string) (*Account, error) {
 func (r *Repository) GetByID(id int64) (*Account, error) {
 	var a Account
 	err := r.db.QueryRow(
-		`SELECT id, user_id, username, name, bio, followers, added_at, last_fetched FROM accounts WHERE id = ?`,
+		`SELECT id, user_id, username, COALESCE(name, ''), COALESCE(bio, ''), COALESCE(followers, 0), added_at, last_fetched, since_id, priority, archived_at FROM accounts WHERE id = ?`,
 		id,
-	).Scan(&a.ID, &a.UserID, &a.Username, &a.Name, &a.Bio, &a.Followers, &a.AddedAt, &a.LastFetched)
+	).Scan(&a.ID, &a.UserID, &a.Username, &a.Name, &a.Bio, &a.Followers, &a.AddedAt, &a.LastFetched, &a.SinceID, &a.Priority, &a.ArchivedAt)
 	if err != nil {
 		return nil, err
 	}
 	return &a, nil
 }
 
+// SetPriority changes how early an account is skipped when quota runs low
+func (r *Repository) SetPriority(username, priority string) error {
+	_, err := r.db.Exec(`UPDATE accounts SET priority = ? WHERE username = ?`, priority, username)
+	return err
+}
+
+// Exists reports whether an account is actively monitored
 func (r *Repository) Exists(username string) bool {
 	var count int
-	r.db.QueryRow(`SELECT COUNT(*) FROM accounts WHERE username = ?`, username).Scan(&count)
+	r.db.QueryRow(`SELECT COUNT(*) FROM accounts WHERE username = ? AND archived_at IS NULL`, username).Scan(&count)
 	return count > 0
 }
 
@@ -91,3 +171,26 @@ func (r *Repository) UpdateLastFetched(id int64) error {
 	_, err := r.db.Exec(`UPDATE accounts SET last_fetched = CURRENT_TIMESTAMP WHERE id = ?`, id)
 	return err
 }
+
+// UpdateFetchedTx marks an account as fetched within tx and advances its
+// since_id cursor. An empty newestID leaves the cursor where it was.
+func (r *Repository) UpdateFetchedTx(tx *sql.Tx, id int64, newestID string) error {
+	res, err := tx.Exec(`
+		UPDATE accounts SET
+			last_fetched = CURRENT_TIMESTAMP,
+			since_id = CASE WHEN ? = '' THEN since_id ELSE ? END
+		WHERE id = ?
+	`, newestID, newestID, id)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("account %d not found", id)
+	}
+	return nil
+}
@@ -4,8 +4,10 @@ package account
 import (
 	"os"
 	"testing"
+	"time"
 
 	"github.com/jpequegn/xmon/internal/database"
+	"github.com/jpequegn/xmon/internal/rollup"
 )
 
 func setupTestDB(t *testing.T) (*database.DB, func()) {
@@ -71,3 +73,177 @@ func TestRemoveAccount(t *testing.T) {
 		t.Error("account should not exist after removal")
 	}
 }
+
+func TestSetPriority(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := NewRepository(db)
+	repo.Add("123", "testuser", "Test", "", 100)
+
+	acc, _ := repo.Get("testuser")
+	if acc.Priority != "normal" {
+		t.Errorf("expected default priority normal, got %s", acc.Priority)
+	}
+
+	if err := repo.SetPriority("testuser", "low"); err != nil {
+		t.Fatalf("failed to set priority: %v", err)
+	}
+	acc, _ = repo.Get("testuser")
+	if acc.Priority != "low" {
+		t.Errorf("expected priority low, got %s", acc.Priority)
+	}
+}
+
+func TestReAddKeepsID(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := NewRepository(db)
+	repo.Add("123", "testuser", "Test", "", 100)
+	before, _ := repo.Get("testuser")
+
+	// Profile refresh, including a handle change
+	if err := repo.Add("123", "renamed", "Test Renamed", "new bio", 150); err != nil {
+		t.Fatalf("re-add failed: %v", err)
+	}
+
+	after, err := repo.Get("renamed")
+	if err != nil {
+		t.Fatalf("expected renamed account: %v", err)
+	}
+	if after.ID != before.ID {
+		t.Errorf("expected id %d to be kept, got %d", before.ID, after.ID)
+	}
+	if after.Followers != 150 || after.Bio != "new bio" {
+		t.Errorf("expected profile to be refreshed, got %+v", after)
+	}
+}
+
+func TestRemoveArchivesAccount(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := NewRepository(db)
+	repo.Add("123", "testuser", "Test", "", 100)
+	acc, _ := repo.Get("testuser")
+	db.Exec(`INSERT INTO tweets (account_id, tweet_id, tweet_type, content) VALUES (?, 't1', 'original', 'hello')`, acc.ID)
+
+	repo.Remove("testuser")
+
+	active, _ := repo.List()
+	if len(active) != 0 {
+		t.Errorf("expected no active accounts, got %d", len(active))
+	}
+	archived, _ := repo.ListArchived()
+	if len(archived) != 1 || !archived[0].Archived() {
+		t.Fatalf("expected 1 archived account, got %+v", archived)
+	}
+	all, _ := repo.ListAll()
+	if len(all) != 1 || all[0].ID != acc.ID {
+		t.Errorf("expected archived account in ListAll, got %+v", all)
+	}
+
+	var count int
+	db.QueryRow(`SELECT COUNT(*) FROM tweets WHERE account_id = ?`, acc.ID).Scan(&count)
+	if count != 1 {
+		t.Errorf("expected tweets to be kept, got %d", count)
+	}
+
+	// Adding the same user again restores the original row
+	repo.Add("123", "testuser", "Test", "", 100)
+	restored, _ := repo.Get("testuser")
+	if restored.ID != acc.ID || restored.Archived() {
+		t.Errorf("expected account %d restored, got %+v", acc.ID, restored)
+	}
+}
+
+func TestPurgeAccount(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := NewRepository(db)
+	repo.Add("123", "testuser", "Test", "", 100)
+	repo.Add("456", "other", "Other", "", 100)
+	acc, _ := repo.Get("testuser")
+	other, _ := repo.Get("other")
+	db.Exec(`INSERT INTO tweets (account_id, tweet_id, tweet_type, content) VALUES (?, 't1', 'original', 'a'), (?, 't2', 'original', 'b'), (?, 't3', 'original', 'c')`,
+		acc.ID, acc.ID, other.ID)
+
+	deleted, err := repo.Purge("testuser")
+	if err != nil {
+		t.Fatalf("purge failed: %v", err)
+	}
+	if deleted != 2 {
+		t.Errorf("expected 2 tweets deleted, got %d", deleted)
+	}
+
+	if _, err := repo.Get("testuser"); err == nil {
+		t.Error("expected account to be gone")
+	}
+
+	var count int
+	db.QueryRow(`SELECT COUNT(*) FROM tweets`).Scan(&count)
+	if count != 1 {
+		t.Errorf("expected other account's tweet to remain, got %d", count)
+	}
+}
+
+func TestPurgeRemovesRollups(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := NewRepository(db)
+	repo.Add("123", "testuser", "Test", "", 100)
+	repo.Add("456", "other", "Other", "", 100)
+	acc, _ := repo.Get("testuser")
+	other, _ := repo.Get("other")
+
+	now := time.Now().UTC()
+	pruned := now.AddDate(0, -2, 0).Format("2006-01-02")
+	_, err := db.Exec(`
+		INSERT INTO tweets (account_id, tweet_id, tweet_type, content, referenced_user, created_at) VALUES
+			(?, 't1', 'original', '#purged topic', '', ?),
+			(?, 't2', 'retweet', 'RT @carol: hi', 'carol', ?),
+			(?, 't3', 'original', '#kept topic', '', ?);
+		INSERT INTO account_daily (day, account_id, originals, pruned_at) VALUES (?, ?, 5, CURRENT_TIMESTAMP);
+		INSERT INTO account_daily_topics (day, account_id, topic, count) VALUES (?, ?, '#history', 5);
+	`, acc.ID, now, acc.ID, now, other.ID, now, pruned, acc.ID, pruned, acc.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rollups := rollup.NewRepository(db)
+	if _, err := rollups.Rebuild(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.Purge("testuser"); err != nil {
+		t.Fatalf("purge failed: %v", err)
+	}
+	// Pruned days survive a rebuild, so purged ones must not be left to it
+	if _, err := rollups.Rebuild(); err != nil {
+		t.Fatal(err)
+	}
+
+	since, until := rollup.LastMonths(now, 12)
+	months, err := rollups.Monthly(nil, since, until)
+	if err != nil {
+		t.Fatal(err)
+	}
+	total := 0
+	for _, m := range months {
+		total += m.Tweets()
+	}
+	if total != 1 {
+		t.Errorf("expected only the other account's tweet in the trend, got %d", total)
+	}
+	topics, _ := rollups.TopTopics(nil, since, until, 10)
+	for _, c := range topics {
+		if c.Name == "#purged" || c.Name == "#history" {
+			t.Errorf("expected purged topics to be gone, got %v", topics)
+		}
+	}
+	if amplified, _ := rollups.TopAmplified(nil, since, until, 10); len(amplified) != 0 {
+		t.Errorf("expected purged amplification to be gone, got %v", amplified)
+	}
+}
@@ -0,0 +1,201 @@
+// internal/analysis/lang.go
+package analysis
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Stopwords per language ResolveLang can pick. English keeps its original
+// list in topics.go.
+var stopWordsByLang = map[string]map[string]bool{
+	"en": stopWords,
+	"es": wordSet(`el la los las un una unos unas y o pero en de del al a con por para sin sobre
+		entre es son fue ser está están estar hay ha han he que qué como cómo cuando donde quien se
+		su sus lo le les me te nos mi mis tu tus yo él ella ellos ellas nosotros este esta estos
+		estas ese esa eso esto muy más mas ya no sí si también porque todo todos toda todas otro
+		otra hoy así rt vía`),
+	"fr": wordSet(`le la les un une des du de et ou mais en dans sur sous pour par avec sans au
+		aux ce cet cette ces est sont était être a ont ai as avez avons qui que quoi dont où il
+		elle ils elles on nous vous je tu me te se lui leur leurs son sa ses mon ma mes ton ta tes
+		notre votre ne pas plus très aussi bien tout tous toute toutes comme si ça cela ceci y
+		fait été rt via`),
+	"de": wordSet(`der die das den dem des ein eine einer eines einem einen und oder aber in im
+		auf an am für von vom mit zu zum zur bei aus nach über unter ist sind war waren sein hat
+		haben wird werden ich du er sie es wir ihr mich dich sich uns nicht auch noch nur schon
+		sehr so wie was wer wenn dass als dann denn doch hier jetzt mehr rt via`),
+	"pt": wordSet(`o a os as um uma uns umas e ou mas em no na nos nas de do da dos das ao aos com
+		por para sem sobre é são foi ser está estão estar há tem têm que como quando onde quem se
+		seu sua seus suas ele ela eles elas eu você nós isso isto este esta esse essa muito mais
+		já não sim também porque todo todos toda todas rt via`),
+	"it": wordSet(`il lo la i gli le un uno una e o ma in nel nella di del della dei delle a al
+		alla da dal con per su tra fra è sono era essere ha hanno ho che come quando dove chi si
+		suo sua suoi sue lui lei loro io tu noi voi questo questa quello quella molto più già non
+		sì anche perché tutto tutti rt via`),
+}
+
+// hanStopChars are common Chinese function characters. Character bigrams
+// containing one are dropped.
+var hanStopChars = wordSet(`的 了 是 在 和 有 我 你 他 她 它 们 这 那 不 也 就 都 而 及 与 着 或 一 个 上 下 被 把 让 给 吗 呢 吧 啊 之 其 为 于 以`)
+
+// Elided articles and pronouns ("l'", "qu'") glued to the next word
+var elisionRe = regexp.MustCompile(`^(?:l|d|j|m|n|s|t|c|qu)['’]`)
+
+func wordSet(words string) map[string]bool {
+	set := make(map[string]bool)
+	for _, w := range strings.Fields(words) {
+		set[w] = true
+	}
+	return set
+}
+
+// DetectLang guesses the language of text: "ja", "zh" or "ko" from its
+// script, otherwise the language whose stopwords it uses most, or "" when
+// there is no clear winner. Stopwords shared between languages, like "on"
+// or "que", are not evidence for either.
+func DetectLang(text string) string {
+	var kana, han, hangul int
+	for _, r := range text {
+		switch {
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+			kana++
+		case unicode.Is(unicode.Han, r):
+			han++
+		case unicode.Is(unicode.Hangul, r):
+			hangul++
+		}
+	}
+	switch {
+	case kana > 0:
+		return "ja"
+	case han > 0:
+		return "zh"
+	case hangul > 0:
+		return "ko"
+	}
+
+	hits := make(map[string]int)
+	for _, word := range words(text) {
+		var langs []string
+		for lang, stop := range stopWordsByLang {
+			if stop[word] {
+				langs = append(langs, lang)
+			}
+		}
+		if len(langs) == 1 {
+			hits[langs[0]]++
+		}
+	}
+	best, bestHits, runnerUp := "", 0, 0
+	for lang, n := range hits {
+		switch {
+		case n > bestHits:
+			best, bestHits, runnerUp = lang, n, bestHits
+		case n > runnerUp:
+			runnerUp = n
+		}
+	}
+	if bestHits < 2 || bestHits == runnerUp {
+		return ""
+	}
+	return best
+}
+
+// ResolveLang returns the language to tokenize text as: lang, as tagged by
+// X, when stopwords or a CJK script are known for it, otherwise DetectLang.
+// X tags such as "und" or "qme" fall back to detection.
+func ResolveLang(text, lang string) string {
+	lang, _, _ = strings.Cut(strings.ToLower(lang), "-")
+	if _, ok := stopWordsByLang[lang]; ok || lang == "ja" || lang == "zh" || lang == "ko" {
+		return lang
+	}
+	return DetectLang(text)
+}
+
+// isStopWord reports whether word is a stopword in lang. Unknown
+// languages use the English list.
+func isStopWord(word, lang string) bool {
+	if stop, ok := stopWordsByLang[lang]; ok {
+		return stop[word]
+	}
+	return stopWords[word]
+}
+
+var wordTokenRe = regexp.MustCompile(`[\p{L}\p{M}\p{N}]+(?:['’][\p{L}\p{M}]+)*`)
+
+// words splits text into lowercase words of any script, with possessive
+// "'s" and elided French articles removed. Runs of Chinese or Japanese
+// characters come back whole; see cjkTokens.
+func words(text string) []string {
+	var result []string
+	for _, w := range wordTokenRe.FindAllString(strings.ToLower(text), -1) {
+		w = elisionRe.ReplaceAllString(w, "")
+		w = strings.TrimSuffix(strings.TrimSuffix(w, "'s"), "’s")
+		if w != "" {
+			result = append(result, w)
+		}
+	}
+	return result
+}
+
+// isCJK reports whether r is written without spaces between words
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana) || r == 'ー'
+}
+
+// splitCJK splits a word into runs that are all CJK or all not
+func splitCJK(word string) []string {
+	var parts []string
+	start := 0
+	var prev bool
+	for i, r := range word {
+		cjk := isCJK(r)
+		if i > 0 && cjk != prev {
+			parts = append(parts, word[start:i])
+			start = i
+		}
+		prev = cjk
+	}
+	return append(parts, word[start:])
+}
+
+// cjkTokens turns a run of Chinese or Japanese characters into tokens, as
+// there are no spaces to split words on. Hiragana, mostly particles and
+// inflections, separates tokens; katakana runs, mostly loanwords, stay
+// whole; Han runs become overlapping character bigrams, skipping those with
+// a Chinese function character.
+func cjkTokens(run string) []string {
+	var tokens []string
+	var chars []rune
+	var katakana bool
+	flush := func() {
+		switch {
+		case katakana && len(chars) >= 2:
+			tokens = append(tokens, string(chars))
+		case !katakana:
+			for i := 0; i+1 < len(chars); i++ {
+				if !hanStopChars[string(chars[i])] && !hanStopChars[string(chars[i+1])] {
+					tokens = append(tokens, string(chars[i:i+2]))
+				}
+			}
+		}
+		chars = nil
+	}
+
+	for _, r := range run {
+		isKatakana := unicode.Is(unicode.Katakana, r) || r == 'ー'
+		switch {
+		case unicode.Is(unicode.Hiragana, r):
+			flush()
+		case len(chars) > 0 && isKatakana != katakana:
+			flush()
+			fallthrough
+		default:
+			katakana = isKatakana
+			chars = append(chars, r)
+		}
+	}
+	flush()
+	return tokens
+}
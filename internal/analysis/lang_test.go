@@ -0,0 +1,75 @@
+package analysis
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestDetectLang(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"Los agentes de IA ya están en todas partes y nadie habla de eso", "es"},
+		{"Les modèles ouverts sont déjà dans nos outils, et ça change tout", "fr"},
+		{"Building AI agents on open source models", ""},
+		{"The agents are getting smarter and they will not stop", "en"},
+		{"新しいAIエージェントが発表された", "ja"},
+		{"人工智能代理正在改变软件开发", "zh"},
+		{"인공지능 에이전트가 늘고 있다", "ko"},
+	}
+	for _, tt := range tests {
+		if got := DetectLang(tt.text); got != tt.want {
+			t.Errorf("DetectLang(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+
+	if got := ResolveLang("Los agentes de IA ya están aquí", "und"); got != "es" {
+		t.Errorf("expected und to fall back to detection, got %q", got)
+	}
+	if got := ResolveLang("ok", "pt-BR"); got != "pt" {
+		t.Errorf("expected the tweet's own tag to win, got %q", got)
+	}
+}
+
+func TestTermsMultilingual(t *testing.T) {
+	tests := []struct {
+		text, lang string
+		want       []string
+	}{
+		{"La #inteligencia artificial está cambiando la economía de España", "es",
+			[]string{"#inteligencia", "artificial", "cambiando", "economía", "españa"}},
+		{"L'économie française et l'énergie nucléaire", "",
+			[]string{"économie", "française", "énergie", "nucléaire"}},
+		{"新しいAIエージェントが発表された", "ja",
+			[]string{"エージェント", "発表"}},
+		{"人工智能代理正在改变软件开发", "zh",
+			[]string{"人工", "工智", "智能", "能代", "代理", "理正", "改变", "变软", "软件", "件开", "开发"}},
+	}
+	for _, tt := range tests {
+		if got := Terms(tt.text, tt.lang); !slices.Equal(got, tt.want) {
+			t.Errorf("Terms(%q, %q) = %v, want %v", tt.text, tt.lang, got, tt.want)
+		}
+	}
+}
+
+func TestExtractTopicsMultilingual(t *testing.T) {
+	tweets := []string{
+		"Los bancos centrales suben los tipos de interés",
+		"Los tipos de interés seguirán altos según los bancos centrales",
+		"人工智能的发展很快",
+		"人工智能改变世界",
+	}
+
+	topics := ExtractTopics(tweets, 10)
+	for _, want := range []string{"bancos centrales", "tipos de interés", "人工", "智能"} {
+		if !slices.Contains(topics, want) {
+			t.Errorf("expected %q among %v", want, topics)
+		}
+	}
+	for _, unwanted := range []string{"los", "los bancos", "的发"} {
+		if slices.Contains(topics, unwanted) {
+			t.Errorf("did not expect %q among %v", unwanted, topics)
+		}
+	}
+}
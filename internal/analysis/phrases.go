@@ -0,0 +1,218 @@
+// internal/analysis/phrases.go
+package analysis
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// minPhraseScore is the log-likelihood ratio a phrase needs, the 99.9%
+// point of chi-squared with one degree of freedom
+const minPhraseScore = 10.83
+
+var (
+	// Anything but letters, digits, apostrophes and spaces ends a run of
+	// words, so phrases never span punctuation or a sentence boundary
+	phraseBreakRe = regexp.MustCompile(`[^\p{L}\p{M}\p{N}'’\s]+`)
+)
+
+// Phrase is a collocation found by ExtractPhrases
+type Phrase struct {
+	Phrase string
+	Count  int     // tweets containing it
+	Score  float64 // log-likelihood ratio against the words occurring independently
+}
+
+// ExtractPhrases finds bigrams and trigrams, like "open source" or "bank of
+// england", that occur together in at least minCount tweets far more often
+// than their words would by chance. Phrases cannot start or end with a
+// stopword of the tweet's language. Chinese and Japanese, written without
+// spaces, are left to keywords. The top 10 are returned by tweet count,
+// then score.
+func ExtractPhrases(tweets []string, minCount int) []Phrase {
+	runs := make([][][]string, len(tweets))
+	langs := make([]string, len(tweets))
+	for i, tweet := range tweets {
+		runs[i], langs[i] = phraseRuns(tweet), ResolveLang(tweet, "")
+	}
+	phrases := collocations(runs, langs, minCount)
+	sort.Slice(phrases, func(i, j int) bool {
+		if phrases[i].Count != phrases[j].Count {
+			return phrases[i].Count > phrases[j].Count
+		}
+		if phrases[i].Score != phrases[j].Score {
+			return phrases[i].Score > phrases[j].Score
+		}
+		return phrases[i].Phrase < phrases[j].Phrase
+	})
+	if len(phrases) > 10 {
+		phrases = phrases[:10]
+	}
+	return phrases
+}
+
+// collocations returns every phrase that passes minCount and
+// minPhraseScore in tweets split by phraseRuns, each in the language of the
+// same index, in no particular order. A bigram only ever seen inside one
+// kept trigram is left out in favour of the trigram.
+func collocations(runs [][][]string, langs []string, minCount int) []Phrase {
+	unigrams := make(map[string]int)
+	ngrams := make(map[string]int)    // occurrences of bigrams and trigrams
+	tweets := make(map[string]int)    // tweets containing each n-gram
+	stopEnds := make(map[string]bool) // n-grams starting or ending with a stopword
+
+	total := 0
+	for t, tweetRuns := range runs {
+		lang := langs[t]
+		seen := make(map[string]bool)
+		for _, run := range tweetRuns {
+			total += len(run)
+			for i, word := range run {
+				unigrams[word]++
+				for n := 2; n <= 3 && i+n <= len(run); n++ {
+					gram := strings.Join(run[i:i+n], " ")
+					ngrams[gram]++
+					if !seen[gram] {
+						tweets[gram]++
+						seen[gram] = true
+					}
+					if isStopWord(run[i], lang) || isStopWord(run[i+n-1], lang) {
+						stopEnds[gram] = true
+					}
+				}
+			}
+		}
+	}
+
+	kept := make(map[string]Phrase)
+	for gram, count := range ngrams {
+		if tweets[gram] < minCount || stopEnds[gram] {
+			continue
+		}
+		words := strings.Fields(gram)
+
+		var score float64
+		if len(words) == 2 {
+			score = llr(count, unigrams[words[0]], unigrams[words[1]], total)
+		} else {
+			// A trigram has to hold together whichever way it is split
+			score = math.Min(
+				llr(count, ngrams[words[0]+" "+words[1]], unigrams[words[2]], total),
+				llr(count, unigrams[words[0]], ngrams[words[1]+" "+words[2]], total))
+		}
+		if score >= minPhraseScore {
+			kept[gram] = Phrase{Phrase: gram, Count: tweets[gram], Score: score}
+		}
+	}
+
+	for gram, p := range kept {
+		words := strings.Fields(gram)
+		if len(words) != 3 {
+			continue
+		}
+		for _, sub := range []string{words[0] + " " + words[1], words[1] + " " + words[2]} {
+			if b, ok := kept[sub]; ok && ngrams[sub] == ngrams[gram] && b.Count == p.Count {
+				delete(kept, sub)
+			}
+		}
+	}
+
+	phrases := make([]Phrase, 0, len(kept))
+	for _, p := range kept {
+		phrases = append(phrases, p)
+	}
+	return phrases
+}
+
+// phraseRuns splits a tweet into lowercase runs of words that a phrase may
+// span. URLs, mentions, hashtags, punctuation, numbers and CJK text break
+// runs.
+func phraseRuns(tweet string) [][]string {
+	tweet = urlRe.ReplaceAllString(tweet, ".")
+	tweet = mentionRe.ReplaceAllString(tweet, ".")
+	tweet = hashtagRe.ReplaceAllString(tweet, ".")
+
+	var runs [][]string
+	for _, segment := range phraseBreakRe.Split(tweet, -1) {
+		var run []string
+		for _, field := range strings.Fields(segment) {
+			w := words(field)
+			if len(w) == 1 && phraseWord(w[0]) {
+				run = append(run, w[0])
+				continue
+			}
+			if len(run) > 1 {
+				runs = append(runs, run)
+			}
+			run = nil
+		}
+		if len(run) > 1 {
+			runs = append(runs, run)
+		}
+	}
+	return runs
+}
+
+// phraseWord reports whether word may be part of a phrase: it starts with a
+// letter and has no CJK characters
+func phraseWord(word string) bool {
+	first, _ := utf8.DecodeRuneInString(word)
+	return unicode.IsLetter(first) && strings.IndexFunc(word, isCJK) < 0
+}
+
+// phrasesIn returns the distinct phrases from set that occur in a tweet's
+// runs, as from phraseRuns
+func phrasesIn(runs [][]string, set map[string]bool) []string {
+	seen := make(map[string]bool)
+	var found []string
+	for _, run := range runs {
+		for i := range run {
+			for n := 2; n <= 3 && i+n <= len(run); n++ {
+				gram := strings.Join(run[i:i+n], " ")
+				if set[gram] && !seen[gram] {
+					found = append(found, gram)
+					seen[gram] = true
+				}
+			}
+		}
+	}
+	return found
+}
+
+// llr is Dunning's log-likelihood ratio for a pair seen together k times,
+// where the first part occurs a times and the second b times among n
+// positions. Pairs seen together less often than chance score 0.
+func llr(k, a, b, n int) float64 {
+	k11 := float64(k)
+	k12 := float64(a - k)
+	k21 := float64(b - k)
+	k22 := float64(n - a - b + k)
+	if k12 < 0 || k21 < 0 || k22 < 0 || k11*float64(n) <= float64(a)*float64(b) {
+		return 0
+	}
+	rows := entropy(k11+k12, k21+k22)
+	cols := entropy(k11+k21, k12+k22)
+	cells := entropy(k11, k12, k21, k22)
+	return math.Max(0, 2*(rows+cols-cells))
+}
+
+// entropy is the unnormalised Shannon entropy of counts
+func entropy(counts ...float64) float64 {
+	var sum, parts float64
+	for _, c := range counts {
+		sum += c
+		parts += xlogx(c)
+	}
+	return xlogx(sum) - parts
+}
+
+func xlogx(x float64) float64 {
+	if x == 0 {
+		return 0
+	}
+	return x * math.Log(x)
+}
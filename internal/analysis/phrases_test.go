@@ -0,0 +1,73 @@
+package analysis
+
+import (
+	"slices"
+	"testing"
+)
+
+var phraseTweets = []string{
+	"Open source wins again. The models are getting cheaper",
+	"Why open source matters for AI agents",
+	"AI agents are everywhere, open source or not",
+	"Building AI agents on open source models",
+	"Interest rates are up and the market is nervous",
+	"Central bank holds interest rates steady",
+	"Bank of England keeps interest rates on hold",
+	"The Bank of England surprised everyone",
+	"Bank of England: no change. @bob thinks otherwise",
+	"source: open https://x.com/open/source",
+}
+
+func phraseNames(phrases []Phrase) []string {
+	names := make([]string, len(phrases))
+	for i, p := range phrases {
+		names[i] = p.Phrase
+	}
+	return names
+}
+
+func TestExtractPhrases(t *testing.T) {
+	phrases := ExtractPhrases(phraseTweets, 2)
+	names := phraseNames(phrases)
+
+	for _, want := range []string{"open source", "ai agents", "interest rates", "bank of england"} {
+		if !slices.Contains(names, want) {
+			t.Errorf("expected %q among %v", want, names)
+		}
+	}
+	for _, unwanted := range []string{"the models", "rates are", "bank of", "of england", "source open"} {
+		if slices.Contains(names, unwanted) {
+			t.Errorf("did not expect %q among %v", unwanted, names)
+		}
+	}
+	if names[0] != "open source" || phrases[0].Count != 4 {
+		t.Errorf("expected open source in 4 tweets first, got %+v", phrases[0])
+	}
+}
+
+func TestPhraseRuns(t *testing.T) {
+	runs := phraseRuns("OpenAI's new model: faster #AI agents via @bob https://x.com/a b")
+
+	// Single words cannot hold a phrase, so "faster" and "b" are dropped
+	want := [][]string{{"openai", "new", "model"}, {"agents", "via"}}
+	if len(runs) != len(want) {
+		t.Fatalf("expected %v, got %v", want, runs)
+	}
+	for i := range want {
+		if !slices.Equal(runs[i], want[i]) {
+			t.Errorf("expected %v, got %v", want, runs)
+		}
+	}
+}
+
+func TestExtractTopicsMergesPhrases(t *testing.T) {
+	topics := ExtractTopics(phraseTweets, 10)
+
+	if !slices.Contains(topics, "open source") || !slices.Contains(topics, "interest rates") {
+		t.Errorf("expected phrases among %v", topics)
+	}
+	// Only ever seen inside the phrase, so it adds nothing on its own
+	if slices.Contains(topics, "rates") {
+		t.Errorf("expected rates to be folded into interest rates, got %v", topics)
+	}
+}
@@ -0,0 +1,108 @@
+// internal/analysis/signature.go
+package analysis
+
+import (
+	"math"
+	"sort"
+)
+
+// signatureMinCount is how many of an account's tweets must mention a term
+// for it to be a signature topic
+const signatureMinCount = 2
+
+// signatureShiftBelow is the similarity under which two signatures count as
+// a shift
+const signatureShiftBelow = 0.5
+
+// TermScore is a term with how many of an account's tweets mention it and
+// its TF-IDF weight
+type TermScore struct {
+	Term  string
+	Count int
+	Score float64
+}
+
+// SignatureTopics finds what each account talks about that the others
+// don't, from counts of how many of each account's tweets mention each
+// term, as read from daily topic rollups. Each account is one document,
+// scored with TF-IDF against the other accounts in counts: terms every
+// account uses score nothing. The limit best are kept per account, by
+// descending score.
+func SignatureTopics(counts map[int64]map[string]int, limit int) map[int64][]TermScore {
+	accountsUsing := make(map[string]int)
+	for _, terms := range counts {
+		for term := range terms {
+			accountsUsing[term]++
+		}
+	}
+
+	n := float64(len(counts))
+	signatures := make(map[int64][]TermScore)
+	for accountID, terms := range counts {
+		var scores []TermScore
+		for term, count := range terms {
+			if count < signatureMinCount {
+				continue
+			}
+			// Smoothed so a term every account uses scores exactly zero
+			idf := math.Log((1 + n) / (1 + float64(accountsUsing[term])))
+			if score := (1 + math.Log(float64(count))) * idf; score > 0 {
+				scores = append(scores, TermScore{Term: term, Count: count, Score: score})
+			}
+		}
+		sort.Slice(scores, func(i, j int) bool {
+			if scores[i].Score != scores[j].Score {
+				return scores[i].Score > scores[j].Score
+			}
+			return scores[i].Term < scores[j].Term
+		})
+		if limit > 0 && len(scores) > limit {
+			scores = scores[:limit]
+		}
+		if len(scores) > 0 {
+			signatures[accountID] = scores
+		}
+	}
+	return signatures
+}
+
+// SignatureShift compares an account's signature topics in two periods
+type SignatureShift struct {
+	Gained     []string // signature terms now that were not before
+	Dropped    []string // signature terms before that are not now
+	Similarity float64  // cosine similarity of the two sets of scores, 0 to 1
+	Shifted    bool     // both periods have a signature and they differ markedly
+}
+
+// CompareSignatures measures how far an account's signature moved from
+// before to after, as returned by SignatureTopics for two periods
+func CompareSignatures(before, after []TermScore) SignatureShift {
+	var s SignatureShift
+	weights := make(map[string]float64)
+	var normBefore, normAfter float64
+	for _, t := range before {
+		weights[t.Term] = t.Score
+		normBefore += t.Score * t.Score
+	}
+	var dot float64
+	for _, t := range after {
+		if w, ok := weights[t.Term]; ok {
+			dot += w * t.Score
+			delete(weights, t.Term)
+		} else {
+			s.Gained = append(s.Gained, t.Term)
+		}
+		normAfter += t.Score * t.Score
+	}
+	for _, t := range before {
+		if _, ok := weights[t.Term]; ok {
+			s.Dropped = append(s.Dropped, t.Term)
+		}
+	}
+
+	if normBefore > 0 && normAfter > 0 {
+		s.Similarity = dot / math.Sqrt(normBefore*normAfter)
+		s.Shifted = s.Similarity < signatureShiftBelow
+	}
+	return s
+}
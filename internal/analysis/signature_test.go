@@ -0,0 +1,57 @@
+package analysis
+
+import (
+	"slices"
+	"testing"
+)
+
+func signatureTerms(scores []TermScore) []string {
+	terms := make([]string, len(scores))
+	for i, s := range scores {
+		terms[i] = s.Term
+	}
+	return terms
+}
+
+func TestSignatureTopics(t *testing.T) {
+	counts := map[int64]map[string]int{
+		1: {"rust": 3, "compilers": 3, "borrow": 1, "markets": 2},
+		2: {"interest": 2, "rates": 2, "markets": 2},
+		3: {"markets": 2, "rally": 1},
+	}
+
+	signatures := SignatureTopics(counts, 5)
+
+	if got := signatureTerms(signatures[1]); !slices.Equal(got, []string{"compilers", "rust"}) {
+		t.Errorf("expected rust and compilers for account 1, got %v", got)
+	}
+	if got := signatureTerms(signatures[2]); !slices.Equal(got, []string{"interest", "rates"}) {
+		t.Errorf("expected interest rates for account 2, got %v", got)
+	}
+	// Everyone talks about markets, so it sets no one apart, and one
+	// mention of rally is not enough
+	if got, ok := signatures[3]; ok {
+		t.Errorf("expected no signature for account 3, got %v", got)
+	}
+	if got := SignatureTopics(counts, 1)[1]; len(got) != 1 || got[0].Count != 3 {
+		t.Errorf("expected the limit to keep one scored term, got %+v", got)
+	}
+}
+
+func TestCompareSignatures(t *testing.T) {
+	before := []TermScore{{Term: "crypto", Score: 2}, {Term: "defi", Score: 1}, {Term: "agents", Score: 0.5}}
+
+	steady := CompareSignatures(before, []TermScore{{Term: "crypto", Score: 1.8}, {Term: "defi", Score: 1.2}})
+	if steady.Shifted || len(steady.Gained) != 0 || !slices.Equal(steady.Dropped, []string{"agents"}) {
+		t.Errorf("expected a steady signature, got %+v", steady)
+	}
+
+	moved := CompareSignatures(before, []TermScore{{Term: "agents", Score: 2}, {Term: "open source", Score: 1.5}})
+	if !moved.Shifted || !slices.Equal(moved.Gained, []string{"open source"}) || !slices.Equal(moved.Dropped, []string{"crypto", "defi"}) {
+		t.Errorf("expected a shift, got %+v", moved)
+	}
+
+	if first := CompareSignatures(nil, before); first.Shifted || len(first.Gained) != 3 {
+		t.Errorf("expected no shift without an earlier signature, got %+v", first)
+	}
+}
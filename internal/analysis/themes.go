@@ -0,0 +1,351 @@
+// internal/analysis/themes.go
+package analysis
+
+import (
+	"slices"
+	"sort"
+	"strings"
+)
+
+const (
+	themeMaxTerms    = 300 // most frequent terms kept as graph nodes
+	themeMinCooccur  = 2   // tweets two terms must share to be linked
+	themeMaxTermRate = 0.5 // terms in more of the tweets than this link everything, so are left out
+)
+
+// Theme is a cluster of terms that tend to be tweeted together
+type Theme struct {
+	Name     string // its two leading terms
+	Terms    []string
+	Accounts []int64 // accounts with tweets in the theme, most tweets first
+	Tweets   []Doc   // up to 3 representative tweets, those using most of its terms first
+	Count    int     // tweets in the theme
+}
+
+// DetectThemes clusters the terms of a corpus and its phrases into themes
+// without any model: terms are linked by how many tweets use both, and the
+// graph is split into communities with the Louvain method. Each tweet joins
+// the theme it shares most terms with. Themes need tweets from at least two
+// accounts; the limit largest are returned. The same docs always give the
+// same themes.
+func DetectThemes(c Corpus, limit int) []Theme {
+	docs := c.Docs
+	phrases := make(map[string]bool)
+	for _, p := range collocations(c.runs, c.langs, themeMinCooccur) {
+		phrases[p.Phrase] = true
+	}
+
+	docTerms := make([][]string, len(docs))
+	df := make(map[string]int)
+	for i := range docs {
+		// Clipped so the phrases don't overwrite the corpus's own terms
+		docTerms[i] = append(slices.Clip(c.Terms[i]), phrasesIn(c.runs[i], phrases)...)
+		for _, term := range docTerms[i] {
+			df[term]++
+		}
+	}
+
+	// Nodes are the most frequent terms, in a fixed order so clustering is
+	// deterministic
+	var terms []string
+	for term, n := range df {
+		if n >= themeMinCooccur && float64(n) <= themeMaxTermRate*float64(len(docs)) {
+			terms = append(terms, term)
+		}
+	}
+	sort.Slice(terms, func(i, j int) bool {
+		if df[terms[i]] != df[terms[j]] {
+			return df[terms[i]] > df[terms[j]]
+		}
+		return terms[i] < terms[j]
+	})
+	if len(terms) > themeMaxTerms {
+		terms = terms[:themeMaxTerms]
+	}
+	node := make(map[string]int, len(terms))
+	for i, term := range terms {
+		node[term] = i
+	}
+
+	cooccur := make(map[[2]int]float64)
+	for _, dt := range docTerms {
+		var ids []int
+		for _, term := range dt {
+			if id, ok := node[term]; ok {
+				ids = append(ids, id)
+			}
+		}
+		for a := range ids {
+			for b := a + 1; b < len(ids); b++ {
+				cooccur[[2]int{min(ids[a], ids[b]), max(ids[a], ids[b])}]++
+			}
+		}
+	}
+	g := newGraph(len(terms))
+	for pair, w := range cooccur {
+		if w >= themeMinCooccur {
+			g.link(pair[0], pair[1], w)
+		}
+	}
+
+	// Group the terms of each community, leading with the best connected
+	members := make(map[int][]int)
+	for id, c := range louvain(g) {
+		if g.degree(id) > 0 {
+			members[c] = append(members[c], id)
+		}
+	}
+	var themes []Theme
+	themeOf := make(map[int]int) // node to index in themes
+	communities := make([]int, 0, len(members))
+	for c := range members {
+		communities = append(communities, c)
+	}
+	sort.Ints(communities)
+	for _, c := range communities {
+		ids := members[c]
+		if len(ids) < 2 {
+			continue
+		}
+		sort.Slice(ids, func(i, j int) bool {
+			if g.degree(ids[i]) != g.degree(ids[j]) {
+				return g.degree(ids[i]) > g.degree(ids[j])
+			}
+			return ids[i] < ids[j]
+		})
+		// Words mostly used inside one of the theme's phrases still place
+		// tweets in it, but are not listed
+		theme := Theme{}
+		for _, id := range ids {
+			themeOf[id] = len(themes)
+			if !foldedInto(terms[id], ids, terms, df) {
+				theme.Terms = append(theme.Terms, terms[id])
+			}
+		}
+		theme.Name = strings.Join(theme.Terms[:min(2, len(theme.Terms))], " / ")
+		themes = append(themes, theme)
+	}
+
+	// Each tweet joins the theme it shares most terms with
+	type match struct {
+		doc   int
+		terms int
+	}
+	matches := make([][]match, len(themes))
+	accountTweets := make([]map[int64]int, len(themes))
+	for i, dt := range docTerms {
+		hits := make(map[int]int)
+		for _, term := range dt {
+			if id, ok := node[term]; ok {
+				if t, ok := themeOf[id]; ok {
+					hits[t]++
+				}
+			}
+		}
+		best := -1
+		for t, n := range hits {
+			if best < 0 || n > hits[best] || (n == hits[best] && t < best) {
+				best = t
+			}
+		}
+		if best < 0 {
+			continue
+		}
+		matches[best] = append(matches[best], match{i, hits[best]})
+		if accountTweets[best] == nil {
+			accountTweets[best] = make(map[int64]int)
+		}
+		accountTweets[best][docs[i].AccountID]++
+	}
+
+	var result []Theme
+	for t, theme := range themes {
+		if len(accountTweets[t]) < 2 {
+			continue
+		}
+		theme.Count = len(matches[t])
+		for id := range accountTweets[t] {
+			theme.Accounts = append(theme.Accounts, id)
+		}
+		counts := accountTweets[t]
+		sort.Slice(theme.Accounts, func(i, j int) bool {
+			a, b := theme.Accounts[i], theme.Accounts[j]
+			if counts[a] != counts[b] {
+				return counts[a] > counts[b]
+			}
+			return a < b
+		})
+		// Representative tweets use most of the theme's terms, one per
+		// account before any account gets a second
+		sort.SliceStable(matches[t], func(i, j int) bool { return matches[t][i].terms > matches[t][j].terms })
+		picked := make(map[int]bool)
+		shown := make(map[int64]bool)
+		for _, repeat := range []bool{false, true} {
+			for _, m := range matches[t] {
+				if len(theme.Tweets) == 3 {
+					break
+				}
+				if !picked[m.doc] && (repeat || !shown[docs[m.doc].AccountID]) {
+					theme.Tweets = append(theme.Tweets, docs[m.doc])
+					picked[m.doc] = true
+					shown[docs[m.doc].AccountID] = true
+				}
+			}
+		}
+		result = append(result, theme)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Count > result[j].Count })
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result
+}
+
+// foldedInto reports whether term mostly occurs inside a longer phrase
+// among the nodes ids
+func foldedInto(term string, ids []int, terms []string, df map[string]int) bool {
+	for _, id := range ids {
+		p := terms[id]
+		if len(p) > len(term) && strings.Contains(" "+p+" ", " "+term+" ") && df[p]*2 >= df[term] {
+			return true
+		}
+	}
+	return false
+}
+
+// graph is a weighted undirected graph. A node's self loop holds twice the
+// weight of the edges inside it, once communities are merged into nodes.
+type graph struct {
+	adj []map[int]float64
+}
+
+func newGraph(n int) *graph {
+	g := &graph{adj: make([]map[int]float64, n)}
+	for i := range g.adj {
+		g.adj[i] = make(map[int]float64)
+	}
+	return g
+}
+
+func (g *graph) link(a, b int, w float64) {
+	g.adj[a][b] += w
+	g.adj[b][a] += w
+}
+
+func (g *graph) degree(n int) float64 {
+	var d float64
+	for _, w := range g.adj[n] {
+		d += w
+	}
+	return d
+}
+
+// neighbours returns n's neighbours in ascending order, so every pass
+// visits them the same way
+func (g *graph) neighbours(n int) []int {
+	ns := make([]int, 0, len(g.adj[n]))
+	for m := range g.adj[n] {
+		if m != n {
+			ns = append(ns, m)
+		}
+	}
+	sort.Ints(ns)
+	return ns
+}
+
+// louvain partitions g to maximise modularity, returning each node's
+// community. Nodes are moved to the neighbouring community that gains most,
+// communities are merged into single nodes, and this repeats until nothing
+// merges. Nodes and candidate communities are always visited in order, with
+// ties kept where they are or going to the lowest community, so the result
+// is deterministic.
+func louvain(g *graph) []int {
+	membership := make([]int, len(g.adj))
+	for i := range membership {
+		membership[i] = i
+	}
+
+	for {
+		community, moved := louvainLevel(g)
+		if !moved {
+			return membership
+		}
+
+		// Renumber communities by first appearance and merge them
+		renumber := make(map[int]int)
+		for _, c := range community {
+			if _, ok := renumber[c]; !ok {
+				renumber[c] = len(renumber)
+			}
+		}
+		if len(renumber) == len(g.adj) {
+			return membership
+		}
+		merged := newGraph(len(renumber))
+		for n, edges := range g.adj {
+			for m, w := range edges {
+				merged.adj[renumber[community[n]]][renumber[community[m]]] += w
+			}
+		}
+		for i, c := range membership {
+			membership[i] = renumber[community[c]]
+		}
+		g = merged
+	}
+}
+
+// louvainLevel moves each node of g to the neighbouring community with the
+// largest modularity gain until no move helps, reporting whether any did
+func louvainLevel(g *graph) ([]int, bool) {
+	n := len(g.adj)
+	community := make([]int, n)
+	degree := make([]float64, n)
+	total := make([]float64, n) // sum of degrees per community
+	var m2 float64
+	for i := range community {
+		community[i] = i
+		degree[i] = g.degree(i)
+		total[i] = degree[i]
+		m2 += degree[i]
+	}
+	if m2 == 0 {
+		return community, false
+	}
+
+	moved := false
+	for pass := 0; pass < 100; pass++ {
+		changed := false
+		for i := 0; i < n; i++ {
+			current := community[i]
+			total[current] -= degree[i]
+
+			links := make(map[int]float64)
+			for _, j := range g.neighbours(i) {
+				links[community[j]] += g.adj[i][j]
+			}
+			candidates := make([]int, 0, len(links))
+			for c := range links {
+				candidates = append(candidates, c)
+			}
+			sort.Ints(candidates)
+
+			best, bestGain := current, links[current]-total[current]*degree[i]/m2
+			for _, c := range candidates {
+				if gain := links[c] - total[c]*degree[i]/m2; gain > bestGain+1e-12 {
+					best, bestGain = c, gain
+				}
+			}
+
+			community[i] = best
+			total[best] += degree[i]
+			if best != current {
+				changed, moved = true, true
+			}
+		}
+		if !changed {
+			break
+		}
+	}
+	return community, moved
+}
@@ -0,0 +1,81 @@
+package analysis
+
+import (
+	"slices"
+	"testing"
+)
+
+var themeDocs = []Doc{
+	{AccountID: 1, Text: "Open source models make agents cheaper"},
+	{AccountID: 2, Text: "Agents built on open source models"},
+	{AccountID: 3, Text: "Which open source models run agents best?"},
+	{AccountID: 1, Text: "Agents need evals, open source or not"},
+	{AccountID: 2, Text: "Interest rates hit mortgages again"},
+	{AccountID: 3, Text: "Mortgages follow interest rates"},
+	{AccountID: 4, Text: "Interest rates and mortgages, the bank decides"},
+	{AccountID: 4, Text: "Mortgages are pricier with these interest rates"},
+	{AccountID: 1, Text: "Lunch was great"},
+	{AccountID: 5, Text: "Gardening weekend, tomatoes everywhere"},
+	{AccountID: 5, Text: "Tomatoes and more gardening"},
+}
+
+func themeNamed(themes []Theme, term string) *Theme {
+	for i := range themes {
+		if slices.Contains(themes[i].Terms, term) {
+			return &themes[i]
+		}
+	}
+	return nil
+}
+
+func TestDetectThemes(t *testing.T) {
+	themes := DetectThemes(NewCorpus(themeDocs), 5)
+
+	ai := themeNamed(themes, "agents")
+	rates := themeNamed(themes, "interest rates")
+	if ai == nil || rates == nil || ai == rates {
+		t.Fatalf("expected separate AI and rates themes, got %+v", themes)
+	}
+	if !slices.Contains(ai.Terms, "open source models") || !slices.Contains(rates.Terms, "mortgages") {
+		t.Errorf("expected related terms to cluster, got %v and %v", ai.Terms, rates.Terms)
+	}
+	if rates.Count != 4 || !slices.Equal(rates.Accounts, []int64{4, 2, 3}) {
+		t.Errorf("expected 4 rates tweets from accounts 4, 2, 3, got %d from %v", rates.Count, rates.Accounts)
+	}
+	if len(ai.Tweets) == 0 || len(ai.Tweets) > 3 {
+		t.Errorf("expected up to 3 representative tweets, got %v", ai.Tweets)
+	}
+	// A single account's hobby is not a theme
+	if g := themeNamed(themes, "gardening"); g != nil {
+		t.Errorf("expected no theme from one account, got %+v", g)
+	}
+}
+
+func TestDetectThemesIsDeterministic(t *testing.T) {
+	first := DetectThemes(NewCorpus(themeDocs), 0)
+	for i := 0; i < 20; i++ {
+		again := DetectThemes(NewCorpus(themeDocs), 0)
+		if len(again) != len(first) {
+			t.Fatalf("expected %d themes, got %d", len(first), len(again))
+		}
+		for j := range first {
+			if again[j].Name != first[j].Name || !slices.Equal(again[j].Terms, first[j].Terms) {
+				t.Fatalf("expected the same themes every run, got %+v then %+v", first, again)
+			}
+		}
+	}
+}
+
+func TestLouvainSplitsCliques(t *testing.T) {
+	// Two triangles joined by one light edge
+	g := newGraph(6)
+	for _, e := range [][2]int{{0, 1}, {1, 2}, {0, 2}, {3, 4}, {4, 5}, {3, 5}} {
+		g.link(e[0], e[1], 3)
+	}
+	g.link(2, 3, 1)
+
+	c := louvain(g)
+	if c[0] != c[1] || c[1] != c[2] || c[3] != c[4] || c[4] != c[5] || c[0] == c[3] {
+		t.Errorf("expected two communities, got %v", c)
+	}
+}
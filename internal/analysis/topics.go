@@ -5,6 +5,8 @@ import (
 	"regexp"
 	"sort"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
 // Common words to filter out
@@ -34,8 +36,7 @@ var stopWords = map[string]bool{
 
 // Package-level compiled regexes to avoid recompilation per call
 var (
-	hashtagRe = regexp.MustCompile(`#(\w+)`)
-	wordRe    = regexp.MustCompile(`\b[a-zA-Z]{3,}\b`)
+	hashtagRe = regexp.MustCompile(`#([\p{L}\p{M}\p{N}_]+)`)
 	urlRe     = regexp.MustCompile(`https?://\S+`)
 	mentionRe = regexp.MustCompile(`@\w+`)
 )
@@ -62,24 +63,15 @@ func ExtractHashtags(tweets []string) []TopicCount {
 	return sortTopics(counts)
 }
 
-// ExtractKeywords extracts significant keywords from tweets
+// ExtractKeywords extracts significant keywords from tweets, detecting
+// each tweet's language for its stopwords
 func ExtractKeywords(tweets []string, minLength int, minCount int) []TopicCount {
 	counts := make(map[string]int)
 
 	for _, tweet := range tweets {
-		// Remove URLs
-		tweet = urlRe.ReplaceAllString(tweet, "")
-		// Remove mentions
-		tweet = mentionRe.ReplaceAllString(tweet, "")
-
-		words := wordRe.FindAllString(strings.ToLower(tweet), -1)
-		seen := make(map[string]bool) // Count each word once per tweet
-
-		for _, word := range words {
-			if len(word) >= minLength && !stopWords[word] && !seen[word] {
-				counts[word]++
-				seen[word] = true
-			}
+		// Count each word once per tweet
+		for _, word := range keywords(tweet, "", minLength) {
+			counts[word]++
 		}
 	}
 
@@ -94,13 +86,16 @@ func ExtractKeywords(tweets []string, minLength int, minCount int) []TopicCount
 	return sortTopics(filtered)
 }
 
-// ExtractTopics combines hashtags and keywords
+// ExtractTopics combines hashtags, phrases and keywords. A keyword is left
+// out when most of its mentions come from a phrase already listed.
 func ExtractTopics(tweets []string, limit int) []string {
 	hashtags := ExtractHashtags(tweets)
+	phrases := ExtractPhrases(tweets, 2)
 	keywords := ExtractKeywords(tweets, 4, 2)
 
 	// Combine and dedupe
 	seen := make(map[string]bool)
+	inPhrase := make(map[string]int) // most tweets a listed phrase covers per word
 	var result []string
 
 	// Prioritize hashtags
@@ -111,9 +106,20 @@ func ExtractTopics(tweets []string, limit int) []string {
 		}
 	}
 
+	// Then phrases, which say more than their words alone
+	for _, p := range phrases {
+		if !seen[p.Phrase] && len(result) < limit {
+			result = append(result, p.Phrase)
+			seen[p.Phrase] = true
+			for _, word := range strings.Fields(p.Phrase) {
+				inPhrase[word] = max(inPhrase[word], p.Count)
+			}
+		}
+	}
+
 	// Add keywords
 	for _, k := range keywords {
-		if !seen[k.Topic] && len(result) < limit {
+		if !seen[k.Topic] && inPhrase[k.Topic]*2 < k.Count && len(result) < limit {
 			result = append(result, k.Topic)
 			seen[k.Topic] = true
 		}
@@ -122,6 +128,68 @@ func ExtractTopics(tweets []string, limit int) []string {
 	return result
 }
 
+// Terms returns the distinct topics of a single tweet: its hashtags, with
+// the #, followed by the keywords ExtractTopics would consider. A word used
+// as a hashtag is not repeated as a keyword. lang is the language X tagged
+// the tweet with, or "" to detect it.
+func Terms(tweet, lang string) []string {
+	seen := make(map[string]bool)
+	var terms []string
+
+	for _, match := range hashtagRe.FindAllStringSubmatch(tweet, -1) {
+		tag := strings.ToLower(match[1])
+		if !seen[tag] {
+			terms = append(terms, "#"+tag)
+			seen[tag] = true
+		}
+	}
+
+	for _, word := range keywords(tweet, lang, 4) {
+		if !seen[word] {
+			terms = append(terms, word)
+			seen[word] = true
+		}
+	}
+
+	return terms
+}
+
+// keywords returns the distinct words of tweet, minus URLs, mentions and
+// the stopwords of its language, in the order they appear. Words need
+// minLength letters, or two in Korean; Chinese and Japanese text is split
+// by cjkTokens instead.
+func keywords(tweet, lang string, minLength int) []string {
+	tweet = urlRe.ReplaceAllString(tweet, "")
+	tweet = mentionRe.ReplaceAllString(tweet, "")
+	lang = ResolveLang(tweet, lang)
+	if lang == "ko" {
+		minLength = 2
+	}
+
+	seen := make(map[string]bool)
+	var result []string
+	add := func(word string) {
+		if !seen[word] {
+			result = append(result, word)
+			seen[word] = true
+		}
+	}
+	for _, word := range words(tweet) {
+		for _, part := range splitCJK(word) {
+			first, _ := utf8.DecodeRuneInString(part)
+			switch {
+			case isCJK(first):
+				for _, token := range cjkTokens(part) {
+					add(token)
+				}
+			case utf8.RuneCountInString(part) >= minLength && !isStopWord(part, lang) && strings.IndexFunc(part, unicode.IsLetter) >= 0:
+				add(part)
+			}
+		}
+	}
+	return result
+}
+
 func sortTopics(counts map[string]int) []TopicCount {
 	topics := make([]TopicCount, 0, len(counts))
 	for topic, count := range counts {
@@ -68,3 +68,18 @@ func TestExtractTopics(t *testing.T) {
 		t.Errorf("expected '#ai' as first topic, got %s", topics[0])
 	}
 }
+
+func TestTerms(t *testing.T) {
+	terms := Terms("Shipping #Agents with @bob: agents everywhere, shipping again https://x.com/agents", "")
+
+	want := []string{"#agents", "shipping", "everywhere"}
+	if len(terms) != len(want) {
+		t.Fatalf("expected %v, got %v", want, terms)
+	}
+	for i := range want {
+		if terms[i] != want[i] {
+			t.Errorf("expected %v, got %v", want, terms)
+			break
+		}
+	}
+}
@@ -0,0 +1,276 @@
+package analysis
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// Doc is one tweet's text and the account that posted it
+type Doc struct {
+	AccountID int64
+	Text      string
+	Lang      string    // as tagged by X, "" to detect it
+	CreatedAt time.Time // only needed to count terms per account-day
+}
+
+// Corpus is a window of docs with the terms of each, as from Terms, so
+// they are tokenized once however many analyses read them
+type Corpus struct {
+	Docs  []Doc
+	Terms [][]string
+	runs  [][][]string // as from phraseRuns
+	langs []string     // as from ResolveLang
+}
+
+// NewCorpus tokenizes docs
+func NewCorpus(docs []Doc) Corpus {
+	c := Corpus{
+		Docs:  docs,
+		Terms: make([][]string, len(docs)),
+		runs:  make([][][]string, len(docs)),
+		langs: make([]string, len(docs)),
+	}
+	for i, d := range docs {
+		c.langs[i] = ResolveLang(d.Text, d.Lang)
+		c.Terms[i] = Terms(d.Text, c.langs[i])
+		c.runs[i] = phraseRuns(d.Text)
+	}
+	return c
+}
+
+// TermCounts is how many tweets in a window mention each term, and from
+// how many distinct accounts
+type TermCounts struct {
+	Tweets   int
+	Counts   map[string]int
+	Accounts map[string]int
+}
+
+// Counts counts the corpus's terms. With perDay above zero only the perDay
+// terms each account mentions most on a UTC day are counted, ties
+// alphabetically, as daily topic rollups keep them, so the result compares
+// like for like with counts read back from those.
+func (c Corpus) Counts(perDay int) TermCounts {
+	type accountDay struct {
+		accountID int64
+		day       string
+	}
+	days := make(map[accountDay]map[string]int)
+	for i, d := range c.Docs {
+		key := accountDay{d.AccountID, d.CreatedAt.UTC().Format("2006-01-02")}
+		if days[key] == nil {
+			days[key] = make(map[string]int)
+		}
+		for _, term := range c.Terms[i] {
+			days[key][term]++
+		}
+	}
+
+	tc := TermCounts{Tweets: len(c.Docs), Counts: make(map[string]int), Accounts: make(map[string]int)}
+	accounts := make(map[string]map[int64]bool)
+	for key, counts := range days {
+		terms := make([]string, 0, len(counts))
+		for term := range counts {
+			terms = append(terms, term)
+		}
+		if perDay > 0 && len(terms) > perDay {
+			sort.Slice(terms, func(i, j int) bool {
+				if counts[terms[i]] != counts[terms[j]] {
+					return counts[terms[i]] > counts[terms[j]]
+				}
+				return terms[i] < terms[j]
+			})
+			terms = terms[:perDay]
+		}
+		for _, term := range terms {
+			tc.Counts[term] += counts[term]
+			if accounts[term] == nil {
+				accounts[term] = make(map[int64]bool)
+			}
+			accounts[term][key.accountID] = true
+		}
+	}
+	for term, ids := range accounts {
+		tc.Accounts[term] = len(ids)
+	}
+	return tc
+}
+
+// TrendStatus classifies a term against its baseline
+type TrendStatus string
+
+const (
+	Emerging  TrendStatus = "emerging"  // well above its usual frequency
+	Sustained TrendStatus = "sustained" // frequent now and in most earlier windows
+	Fading    TrendStatus = "fading"    // well below its usual frequency
+)
+
+// Trend is a term's frequency in the current window compared with the
+// baseline windows before it. Rates are the share of tweets mentioning the
+// term.
+type Trend struct {
+	Term         string
+	Status       TrendStatus
+	Count        int     // tweets mentioning it in the window
+	Accounts     int     // distinct accounts mentioning it in the window
+	Rate         float64 // Count over all tweets in the window
+	BaselineRate float64 // mean rate over the baseline windows
+	Lift         float64 // Rate over BaselineRate; large for terms never seen before
+	Z            float64 // standard score of Rate against the baseline
+	New          bool    // not mentioned in any baseline window
+}
+
+// TrendOptions tune DetectTrends. Zero values use the defaults.
+type TrendOptions struct {
+	MinCount    int     // tweets that must mention a term, default 3
+	MinAccounts int     // distinct accounts that must mention it, default 2
+	MinZ        float64 // standard score needed to emerge or fade, default 2
+	MinLift     float64 // ratio needed to emerge, or its inverse to fade, default 1.5
+	Limit       int     // terms kept per status, 0 keeps all
+}
+
+func (o TrendOptions) withDefaults() TrendOptions {
+	if o.MinCount == 0 {
+		o.MinCount = 3
+	}
+	if o.MinAccounts == 0 {
+		o.MinAccounts = 2
+	}
+	if o.MinZ == 0 {
+		o.MinZ = 2
+	}
+	if o.MinLift == 0 {
+		o.MinLift = 1.5
+	}
+	return o
+}
+
+// DetectTrends compares the frequencies of terms in current against
+// baseline, the windows of the same length before it, typically read from
+// daily topic rollups so the baseline survives retention. Windows without
+// tweets, e.g. from before monitoring started, are left out of the
+// baseline; with no baseline at all nothing can be classified and nil is
+// returned.
+//
+// Results are emerging terms by descending Z, then sustained ones by
+// descending Count, then fading ones by ascending Z.
+func DetectTrends(current TermCounts, baseline []TermCounts, opts TrendOptions) []Trend {
+	opts = opts.withDefaults()
+	if current.Tweets == 0 {
+		return nil
+	}
+
+	var windows []TermCounts
+	baselineTweets := 0
+	for _, w := range baseline {
+		if w.Tweets > 0 {
+			windows = append(windows, w)
+			baselineTweets += w.Tweets
+		}
+	}
+	if len(windows) == 0 {
+		return nil
+	}
+
+	candidates := make(map[string]bool)
+	for term := range current.Counts {
+		candidates[term] = true
+	}
+	for _, w := range windows {
+		for term := range w.Counts {
+			candidates[term] = true
+		}
+	}
+
+	// Half a mention across the whole baseline stands in for terms never
+	// seen, so their lift stays finite
+	floor := 0.5 / float64(baselineTweets)
+
+	var emerging, sustained, fading []Trend
+	for term := range candidates {
+		rates := make([]float64, len(windows))
+		present, peakCount, peakAccounts := 0, 0, 0
+		for i, w := range windows {
+			rates[i] = float64(w.Counts[term]) / float64(w.Tweets)
+			if w.Counts[term] > 0 {
+				present++
+			}
+			peakCount = max(peakCount, w.Counts[term])
+			peakAccounts = max(peakAccounts, w.Accounts[term])
+		}
+		mean, sd := meanStdDev(rates)
+
+		t := Trend{
+			Term:         term,
+			Count:        current.Counts[term],
+			Accounts:     current.Accounts[term],
+			Rate:         float64(current.Counts[term]) / float64(current.Tweets),
+			BaselineRate: mean,
+			New:          present == 0,
+		}
+		expected := math.Max(mean, floor)
+		t.Lift = t.Rate / expected
+
+		// Sampling noise of the window's own rate keeps small windows and
+		// rare terms from scoring extreme z values
+		noise := expected * (1 - expected) / float64(current.Tweets)
+		if spread := math.Sqrt(sd*sd + noise); spread > 0 {
+			t.Z = (t.Rate - mean) / spread
+		}
+
+		frequentNow := t.Count >= opts.MinCount && t.Accounts >= opts.MinAccounts
+		frequentBefore := peakCount >= opts.MinCount && peakAccounts >= opts.MinAccounts
+		switch {
+		case frequentNow && t.Z >= opts.MinZ && t.Lift >= opts.MinLift:
+			t.Status = Emerging
+			emerging = append(emerging, t)
+		case frequentBefore && t.Z <= -opts.MinZ && t.Lift <= 1/opts.MinLift:
+			t.Status = Fading
+			fading = append(fading, t)
+		case frequentNow && present*2 >= len(windows):
+			t.Status = Sustained
+			sustained = append(sustained, t)
+		}
+	}
+
+	sortTrends(emerging, func(a, b Trend) bool { return a.Z > b.Z })
+	sortTrends(sustained, func(a, b Trend) bool { return a.Count > b.Count })
+	sortTrends(fading, func(a, b Trend) bool { return a.Z < b.Z })
+
+	var trends []Trend
+	for _, group := range [][]Trend{emerging, sustained, fading} {
+		if opts.Limit > 0 && len(group) > opts.Limit {
+			group = group[:opts.Limit]
+		}
+		trends = append(trends, group...)
+	}
+	return trends
+}
+
+// sortTrends orders by less, then alphabetically so output is stable
+func sortTrends(trends []Trend, less func(a, b Trend) bool) {
+	sort.Slice(trends, func(i, j int) bool {
+		if less(trends[i], trends[j]) {
+			return true
+		}
+		if less(trends[j], trends[i]) {
+			return false
+		}
+		return trends[i].Term < trends[j].Term
+	})
+}
+
+func meanStdDev(values []float64) (float64, float64) {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+
+	var sq float64
+	for _, v := range values {
+		sq += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(sq / float64(len(values)))
+}
@@ -0,0 +1,116 @@
+package analysis
+
+import (
+	"maps"
+	"slices"
+	"testing"
+	"time"
+)
+
+// window counts n tweets spread round-robin over accounts, the first
+// mentions[term] of them mentioning term
+func window(n int, accounts int64, mentions map[string]int) TermCounts {
+	return NewCorpus(windowDocs(n, accounts, mentions)).Counts(0)
+}
+
+func windowDocs(n int, accounts int64, mentions map[string]int) []Doc {
+	docs := make([]Doc, n)
+	for i := range docs {
+		docs[i] = Doc{AccountID: int64(i) % accounts, Text: "so"}
+	}
+	for term, count := range mentions {
+		for i := 0; i < count; i++ {
+			docs[i].Text += ". " + term
+		}
+	}
+	return docs
+}
+
+func TestDetectTrends(t *testing.T) {
+	baseline := []TermCounts{
+		window(100, 5, map[string]int{"startup": 20, "crypto": 15}),
+		window(100, 5, map[string]int{"startup": 22, "crypto": 18}),
+		window(100, 5, map[string]int{"startup": 19, "crypto": 16, "agents": 1}),
+		window(100, 5, map[string]int{"startup": 21, "crypto": 14}),
+	}
+	current := window(100, 5, map[string]int{"startup": 20, "agents": 25, "crypto": 1, "llama": 12})
+
+	trends := DetectTrends(current, baseline, TrendOptions{})
+	status := make(map[string]Trend)
+	for _, tr := range trends {
+		status[tr.Term] = tr
+	}
+
+	if tr := status["agents"]; tr.Status != Emerging || tr.Lift < 10 || tr.New {
+		t.Errorf("expected agents to be emerging, got %+v", tr)
+	}
+	if tr := status["llama"]; tr.Status != Emerging || !tr.New {
+		t.Errorf("expected the new term llama to be emerging, got %+v", tr)
+	}
+	if tr := status["startup"]; tr.Status != Sustained {
+		t.Errorf("expected startup to be sustained, got %+v", tr)
+	}
+	if tr := status["crypto"]; tr.Status != Fading {
+		t.Errorf("expected crypto to be fading, got %+v", tr)
+	}
+	if trends[0].Term != "agents" || trends[len(trends)-1].Term != "crypto" {
+		t.Errorf("expected emerging first and fading last, got %+v", trends)
+	}
+}
+
+func TestDetectTrendsThresholds(t *testing.T) {
+	baseline := []TermCounts{window(100, 5, nil), window(100, 5, nil)}
+
+	// Many mentions from a single account are not a trend
+	docs := windowDocs(100, 5, nil)
+	for i := 0; i < 10; i++ {
+		docs[i*5].Text += " hobbyhorse"
+	}
+	// A brand new term from several accounts is
+	for i := 0; i < 6; i++ {
+		docs[i].Text += " launch"
+	}
+	current := NewCorpus(docs).Counts(0)
+
+	trends := DetectTrends(current, baseline, TrendOptions{})
+	if len(trends) != 1 || trends[0].Term != "launch" || !trends[0].New || trends[0].Accounts != 5 {
+		t.Errorf("expected only launch to emerge, got %+v", trends)
+	}
+
+	trends = DetectTrends(current, baseline, TrendOptions{MinAccounts: 1, Limit: 1})
+	if len(trends) != 1 || trends[0].Term != "hobbyhorse" {
+		t.Errorf("expected lowering the threshold to admit hobbyhorse first, got %+v", trends)
+	}
+}
+
+func TestDetectTrendsWithoutBaseline(t *testing.T) {
+	current := window(10, 2, map[string]int{"agents": 5})
+	if trends := DetectTrends(current, []TermCounts{{}, window(0, 1, nil)}, TrendOptions{}); trends != nil {
+		t.Errorf("expected no classification without history, got %+v", trends)
+	}
+}
+
+func TestCorpusCountsPerDay(t *testing.T) {
+	day := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	docs := []Doc{
+		{AccountID: 1, Text: "agents agents", CreatedAt: day},
+		{AccountID: 1, Text: "agents and zebras", CreatedAt: day},
+		{AccountID: 1, Text: "bots", CreatedAt: day},
+		{AccountID: 1, Text: "zebras", CreatedAt: day.AddDate(0, 0, 1)},
+		{AccountID: 2, Text: "zebras", CreatedAt: day},
+	}
+
+	all := NewCorpus(docs).Counts(0)
+	if all.Tweets != 5 || all.Counts["agents"] != 2 || all.Counts["zebras"] != 3 || all.Accounts["zebras"] != 2 {
+		t.Errorf("expected every term counted, got %+v", all)
+	}
+
+	// Like the rollups, each account-day keeps its top terms, ties alphabetically
+	capped := NewCorpus(docs).Counts(2)
+	if capped.Counts["agents"] != 2 || capped.Counts["bots"] != 1 || capped.Counts["zebras"] != 2 || capped.Accounts["zebras"] != 2 {
+		t.Errorf("expected zebras dropped from account 1's first day only, got %+v", capped)
+	}
+	if terms := slices.Sorted(maps.Keys(capped.Counts)); !slices.Equal(terms, []string{"agents", "bots", "zebras"}) {
+		t.Errorf("expected only kept terms, got %v", terms)
+	}
+}
@@ -0,0 +1,237 @@
+package backup
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jpequegn/xmon/internal/database"
+)
+
+const (
+	filePrefix = "xmon-"
+	timeFormat = "20060102-150405"
+	gzipSuffix = ".gz"
+)
+
+// Name returns the file name for a backup taken at t
+func Name(t time.Time, compress bool) string {
+	name := filePrefix + t.UTC().Format(timeFormat) + ".db"
+	if compress {
+		name += gzipSuffix
+	}
+	return name
+}
+
+// Write backs db up to path, gzipping it if path ends in .gz. The backup is
+// written to a temporary file first, so path never holds a partial copy.
+func Write(db *database.DB, path string) error {
+	tmp := path + ".tmp"
+	defer os.Remove(tmp)
+
+	if strings.HasSuffix(path, gzipSuffix) {
+		raw := tmp + ".db"
+		defer os.Remove(raw)
+		if err := db.BackupTo(raw); err != nil {
+			return err
+		}
+		if err := compressFile(raw, tmp); err != nil {
+			return fmt.Errorf("failed to compress backup: %w", err)
+		}
+	} else if err := db.BackupTo(tmp); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
+
+// List returns the backups in dir, oldest first
+func List(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var paths []string
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, filePrefix) {
+			continue
+		}
+		if strings.HasSuffix(name, ".db") || strings.HasSuffix(name, ".db"+gzipSuffix) {
+			paths = append(paths, filepath.Join(dir, name))
+		}
+	}
+	// Names embed the UTC timestamp, so lexical order is chronological
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// Rotate deletes the oldest backups in dir so that at most keep remain, and
+// returns the deleted paths
+func Rotate(dir string, keep int) ([]string, error) {
+	if keep <= 0 {
+		return nil, nil
+	}
+
+	paths, err := List(dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) <= keep {
+		return nil, nil
+	}
+
+	var removed []string
+	for _, path := range paths[:len(paths)-keep] {
+		if err := os.Remove(path); err != nil {
+			return removed, err
+		}
+		removed = append(removed, path)
+	}
+	return removed, nil
+}
+
+// Restore replaces the database at dbPath with the backup at src after
+// checking its integrity and schema version. The replaced database is kept
+// next to it with a .pre-restore suffix. Returns the backup's schema
+// version; older schemas are migrated the next time the database is opened.
+func Restore(src, dbPath string) (int, error) {
+	staged := dbPath + ".restore"
+	defer os.Remove(staged)
+
+	var err error
+	if strings.HasSuffix(src, gzipSuffix) {
+		err = decompressFile(src, staged)
+	} else {
+		err = copyFile(src, staged)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read backup: %w", err)
+	}
+
+	version, err := Verify(staged)
+	if err != nil {
+		return 0, err
+	}
+
+	// Move the current database and its sidecar files out of the way
+	if _, err := os.Stat(dbPath); err == nil {
+		for _, suffix := range []string{"", "-wal", "-shm"} {
+			if err := os.Rename(dbPath+suffix, dbPath+".pre-restore"+suffix); err != nil && !os.IsNotExist(err) {
+				return 0, fmt.Errorf("failed to move current database aside: %w", err)
+			}
+		}
+	}
+
+	if err := os.Rename(staged, dbPath); err != nil {
+		return 0, fmt.Errorf("failed to swap in restored database: %w", err)
+	}
+	return version, nil
+}
+
+// Verify checks that path is an intact xmon database this version can open
+// and returns its schema version
+func Verify(path string) (int, error) {
+	db, err := database.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+
+	problems, err := db.IntegrityCheck()
+	if err != nil {
+		return 0, fmt.Errorf("not a valid database: %w", err)
+	}
+	if len(problems) > 0 {
+		return 0, fmt.Errorf("integrity check failed: %s", strings.Join(problems, "; "))
+	}
+
+	var tables int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name IN ('accounts', 'tweets')`).Scan(&tables); err != nil {
+		return 0, err
+	}
+	if tables != 2 {
+		return 0, fmt.Errorf("not an xmon database")
+	}
+
+	version, err := db.SchemaVersion()
+	if err != nil {
+		return 0, err
+	}
+	if version > database.LatestVersion() {
+		return version, fmt.Errorf("%w (backup is at version %d, this xmon supports %d)",
+			database.ErrSchemaTooNew, version, database.LatestVersion())
+	}
+	return version, nil
+}
+
+func compressFile(src, dest string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	zw := gzip.NewWriter(out)
+	if _, err := io.Copy(zw, in); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	return out.Close()
+}
+
+func decompressFile(src, dest string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	zr, err := gzip.NewReader(in)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+
+	return writeFile(dest, zr)
+}
+
+func copyFile(src, dest string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	return writeFile(dest, in)
+}
+
+func writeFile(dest string, r io.Reader) error {
+	out, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	if _, err := io.Copy(out, r); err != nil {
+		return err
+	}
+	return out.Close()
+}
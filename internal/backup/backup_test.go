@@ -0,0 +1,130 @@
+package backup
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jpequegn/xmon/internal/database"
+)
+
+func setupTestDB(t *testing.T, dir string) *database.DB {
+	t.Helper()
+	db, err := database.New(filepath.Join(dir, "xmon.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func countAccounts(t *testing.T, path string) int {
+	t.Helper()
+	db, err := database.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	var count int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM accounts`).Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	return count
+}
+
+func TestWriteAndRestore(t *testing.T) {
+	for _, name := range []string{"backup.db", "backup.db.gz"} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			db := setupTestDB(t, dir)
+			db.Exec(`INSERT INTO accounts (user_id, username) VALUES ('1', 'alice')`)
+
+			path := filepath.Join(dir, name)
+			if err := Write(db, path); err != nil {
+				t.Fatalf("backup failed: %v", err)
+			}
+			if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+				t.Error("expected temporary file to be cleaned up")
+			}
+
+			// Restore into a different database
+			target := filepath.Join(dir, "restored.db")
+			os.WriteFile(target, []byte("old"), 0644)
+
+			version, err := Restore(path, target)
+			if err != nil {
+				t.Fatalf("restore failed: %v", err)
+			}
+			if version != database.LatestVersion() {
+				t.Errorf("expected version %d, got %d", database.LatestVersion(), version)
+			}
+			if n := countAccounts(t, target); n != 1 {
+				t.Errorf("expected 1 account in restored db, got %d", n)
+			}
+			if data, _ := os.ReadFile(target + ".pre-restore"); string(data) != "old" {
+				t.Error("expected previous database to be kept")
+			}
+		})
+	}
+}
+
+func TestRestoreRejectsBadBackups(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "xmon.db")
+	os.WriteFile(target, []byte("current"), 0644)
+
+	garbage := filepath.Join(dir, "garbage.db")
+	os.WriteFile(garbage, []byte("this is not a database, just some text padding it out"), 0644)
+	if _, err := Restore(garbage, target); err == nil {
+		t.Error("expected garbage backup to be rejected")
+	}
+
+	newer := filepath.Join(dir, "newer.db")
+	db, err := database.New(newer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`INSERT INTO schema_version (version, name, applied_at) VALUES (?, 'from_the_future', CURRENT_TIMESTAMP)`, database.LatestVersion()+1); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	if _, err := Restore(newer, target); !errors.Is(err, database.ErrSchemaTooNew) {
+		t.Errorf("expected ErrSchemaTooNew, got %v", err)
+	}
+
+	if data, _ := os.ReadFile(target); string(data) != "current" {
+		t.Error("a rejected restore must leave the current database untouched")
+	}
+}
+
+func TestRotate(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		os.WriteFile(filepath.Join(dir, Name(start.AddDate(0, 0, i), i%2 == 0)), nil, 0644)
+	}
+	os.WriteFile(filepath.Join(dir, "notes.txt"), nil, 0644)
+
+	removed, err := Rotate(dir, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(removed) != 2 {
+		t.Errorf("expected 2 removed, got %d", len(removed))
+	}
+
+	remaining, _ := List(dir)
+	if len(remaining) != 3 {
+		t.Fatalf("expected 3 backups left, got %d", len(remaining))
+	}
+	if filepath.Base(remaining[0]) != Name(start.AddDate(0, 0, 2), true) {
+		t.Errorf("expected oldest backups removed, first left is %s", remaining[0])
+	}
+	if _, err := os.Stat(filepath.Join(dir, "notes.txt")); err != nil {
+		t.Error("rotation must not touch unrelated files")
+	}
+}
@@ -2,17 +2,25 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
 
 type Config struct {
-	X      XConfig      `yaml:"x"`
-	APIs   APIsConfig   `yaml:"apis"`
-	Fetch  FetchConfig  `yaml:"fetch"`
-	Digest DigestConfig `yaml:"digest"`
+	X         XConfig           `yaml:"x"`
+	APIs      APIsConfig        `yaml:"apis"`
+	Fetch     FetchConfig       `yaml:"fetch"`
+	Digest    DigestConfig      `yaml:"digest"`
+	Usage     UsageConfig       `yaml:"usage"`
+	Retention RetentionConfig   `yaml:"retention"`
+	Backup    BackupConfig      `yaml:"backup"`
+	Display   DisplayConfig     `yaml:"display"`
+	Queries   map[string]string `yaml:"queries,omitempty"` // saved SQL for 'xmon query', by name
 }
 
 type XConfig struct {
@@ -22,6 +30,7 @@ type XConfig struct {
 type APIsConfig struct {
 	LLMProvider string `yaml:"llm_provider"`
 	LLMModel    string `yaml:"llm_model"`
+	LLMBaseURL  string `yaml:"llm_base_url"`
 }
 
 type FetchConfig struct {
@@ -32,11 +41,57 @@ type DigestConfig struct {
 	DefaultDays int `yaml:"default_days"`
 }
 
+// UsageConfig describes the X API plan we are billed on. MonthlyReads and
+// EndpointLimits override the tier's defaults when set.
+type UsageConfig struct {
+	Tier            string         `yaml:"tier"`
+	MonthlyReads    int            `yaml:"monthly_reads,omitempty"`
+	EndpointLimits  map[string]int `yaml:"endpoint_limits,omitempty"`
+	CycleStartDay   int            `yaml:"cycle_start_day"`
+	WarnPercent     float64        `yaml:"warn_percent"`
+	CriticalPercent float64        `yaml:"critical_percent"`
+	Reserve         int            `yaml:"reserve"`
+}
+
+// RetentionConfig controls pruning of raw tweets. Daily aggregates are kept
+// forever, so TweetDays only limits how far back full tweet text is kept.
+type RetentionConfig struct {
+	TweetDays       int  `yaml:"tweet_days"` // 0 keeps tweets forever
+	KeepStarred     bool `yaml:"keep_starred"`
+	PruneEveryHours int  `yaml:"prune_every_hours"` // daemon schedule, 0 disables
+}
+
+// BackupConfig controls where 'xmon backup' writes and how many it keeps
+type BackupConfig struct {
+	Dir  string `yaml:"dir,omitempty"` // defaults to BackupDir()
+	Keep int    `yaml:"keep"`          // 0 keeps every backup
+	Gzip bool   `yaml:"gzip"`
+}
+
+// DisplayConfig controls how times are shown. Timestamps are always stored
+// and compared in UTC.
+type DisplayConfig struct {
+	Timezone string `yaml:"timezone,omitempty"` // IANA name, e.g. Europe/Paris; empty uses the system zone
+}
+
+// Location returns the configured display timezone
+func (d DisplayConfig) Location() (*time.Location, error) {
+	if d.Timezone == "" || d.Timezone == "Local" {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(d.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid display.timezone %q: %w", d.Timezone, err)
+	}
+	return loc, nil
+}
+
 func DefaultConfig() *Config {
 	return &Config{
 		APIs: APIsConfig{
 			LLMProvider: "ollama",
 			LLMModel:    "llama3.2",
+			LLMBaseURL:  "http://localhost:11434",
 		},
 		Fetch: FetchConfig{
 			DefaultInterval: 1440,
@@ -44,14 +99,151 @@ func DefaultConfig() *Config {
 		Digest: DigestConfig{
 			DefaultDays: 7,
 		},
+		Usage: UsageConfig{
+			Tier:            "free",
+			CycleStartDay:   1,
+			WarnPercent:     75,
+			CriticalPercent: 90,
+		},
+		Retention: RetentionConfig{
+			KeepStarred:     true,
+			PruneEveryHours: 24,
+		},
+		Backup: BackupConfig{
+			Keep: 7,
+			Gzip: true,
+		},
 	}
 }
 
-func ConfigDir() string {
+// DefaultProfile is the profile that lives directly in HomeDir(), as all
+// data did before profiles existed
+const DefaultProfile = "default"
+
+// HomeEnv overrides the xmon home directory, like the --home flag
+const HomeEnv = "XMON_HOME"
+
+var (
+	homeOverride    string
+	profileOverride string
+)
+
+// SetHome overrides the xmon home directory for this process
+func SetHome(dir string) {
+	homeOverride = dir
+}
+
+// SetProfile selects a profile for this process instead of the current one
+func SetProfile(name string) {
+	profileOverride = name
+}
+
+// HomeDir is where xmon keeps its profiles: the --home flag, then
+// $XMON_HOME, then ~/.xmon
+func HomeDir() string {
+	if homeOverride != "" {
+		return homeOverride
+	}
+	if dir := os.Getenv(HomeEnv); dir != "" {
+		return dir
+	}
 	home, _ := os.UserHomeDir()
 	return filepath.Join(home, ".xmon")
 }
 
+// Profile returns the active profile: the --profile flag, then the one
+// chosen with 'xmon profile use', then the default
+func Profile() string {
+	if profileOverride != "" {
+		return profileOverride
+	}
+	data, err := os.ReadFile(currentProfilePath())
+	if err == nil {
+		if name := strings.TrimSpace(string(data)); name != "" {
+			return name
+		}
+	}
+	return DefaultProfile
+}
+
+// ConfigDir holds the active profile's config, database and backups
+func ConfigDir() string {
+	return ProfileDir(Profile())
+}
+
+// ProfileDir returns the directory of a named profile
+func ProfileDir(name string) string {
+	if name == DefaultProfile {
+		return HomeDir()
+	}
+	return filepath.Join(HomeDir(), "profiles", name)
+}
+
+// Profiles lists the profiles that exist, the default first
+func Profiles() ([]string, error) {
+	profiles := []string{DefaultProfile}
+
+	entries, err := os.ReadDir(filepath.Join(HomeDir(), "profiles"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return profiles, nil
+		}
+		return nil, err
+	}
+	for _, e := range entries {
+		if e.IsDir() && ValidProfileName(e.Name()) == nil {
+			profiles = append(profiles, e.Name())
+		}
+	}
+	return profiles, nil
+}
+
+// ProfileExists reports whether a profile's directory exists. The default
+// profile always exists.
+func ProfileExists(name string) bool {
+	if name == DefaultProfile {
+		return true
+	}
+	info, err := os.Stat(ProfileDir(name))
+	return err == nil && info.IsDir()
+}
+
+// ValidProfileName rejects names that would escape the profiles directory
+// or be awkward on the command line
+func ValidProfileName(name string) error {
+	if name == "" {
+		return fmt.Errorf("profile name must not be empty")
+	}
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return fmt.Errorf("invalid profile name %q (use letters, digits, - and _)", name)
+		}
+	}
+	return nil
+}
+
+// UseProfile makes name the current profile for future commands
+func UseProfile(name string) error {
+	if !ProfileExists(name) {
+		return fmt.Errorf("profile %q does not exist", name)
+	}
+	if err := os.MkdirAll(HomeDir(), 0755); err != nil {
+		return err
+	}
+	if name == DefaultProfile {
+		err := os.Remove(currentProfilePath())
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	return os.WriteFile(currentProfilePath(), []byte(name+"\n"), 0644)
+}
+
+func currentProfilePath() string {
+	return filepath.Join(HomeDir(), "current_profile")
+}
+
 func ConfigPath() string {
 	return filepath.Join(ConfigDir(), "config.yaml")
 }
@@ -60,6 +252,16 @@ func DBPath() string {
 	return filepath.Join(ConfigDir(), "xmon.db")
 }
 
+// FetchLockPath is the lock file held while a fetch runs, so the daemon and
+// manual fetches never run at the same time
+func FetchLockPath() string {
+	return filepath.Join(ConfigDir(), "fetch.lock")
+}
+
+func BackupDir() string {
+	return filepath.Join(ConfigDir(), "backups")
+}
+
 func Load() (*Config, error) {
 	data, err := os.ReadFile(ConfigPath())
 	if err != nil {
@@ -2,7 +2,10 @@
 package config
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 )
 
 func TestDefaultConfig(t *testing.T) {
@@ -21,3 +24,86 @@ func TestConfigDir(t *testing.T) {
 		t.Error("config dir should not be empty")
 	}
 }
+
+func TestProfiles(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv(HomeEnv, home)
+	defer SetProfile("")
+
+	if ConfigDir() != home {
+		t.Errorf("expected default profile in %s, got %s", home, ConfigDir())
+	}
+
+	if err := UseProfile("ai"); err == nil {
+		t.Error("expected switching to a missing profile to fail")
+	}
+
+	if err := os.MkdirAll(ProfileDir("ai"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := UseProfile("ai"); err != nil {
+		t.Fatalf("use failed: %v", err)
+	}
+	if Profile() != "ai" || ConfigDir() != filepath.Join(home, "profiles", "ai") {
+		t.Errorf("expected ai profile to be current, got %s in %s", Profile(), ConfigDir())
+	}
+
+	// An explicit --profile wins over the current one
+	SetProfile(DefaultProfile)
+	if ConfigDir() != home {
+		t.Errorf("expected override to select the default profile, got %s", ConfigDir())
+	}
+	SetProfile("")
+
+	profiles, err := Profiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(profiles) != 2 || profiles[0] != DefaultProfile || profiles[1] != "ai" {
+		t.Errorf("unexpected profiles: %v", profiles)
+	}
+
+	if err := UseProfile(DefaultProfile); err != nil {
+		t.Fatal(err)
+	}
+	if Profile() != DefaultProfile {
+		t.Errorf("expected default profile, got %s", Profile())
+	}
+}
+
+func TestHomeOverride(t *testing.T) {
+	t.Setenv(HomeEnv, "/from/env")
+	if HomeDir() != "/from/env" {
+		t.Errorf("expected $%s to set the home, got %s", HomeEnv, HomeDir())
+	}
+
+	SetHome("/from/flag")
+	defer SetHome("")
+	if HomeDir() != "/from/flag" {
+		t.Errorf("expected --home to win over the env, got %s", HomeDir())
+	}
+}
+
+func TestValidProfileName(t *testing.T) {
+	for _, name := range []string{"ai", "crypto-2025", "policy_watch"} {
+		if err := ValidProfileName(name); err != nil {
+			t.Errorf("expected %q to be valid: %v", name, err)
+		}
+	}
+	for _, name := range []string{"", "../etc", "a b", "x/y"} {
+		if err := ValidProfileName(name); err == nil {
+			t.Errorf("expected %q to be rejected", name)
+		}
+	}
+}
+
+func TestDisplayLocation(t *testing.T) {
+	loc, err := DisplayConfig{}.Location()
+	if err != nil || loc != time.Local {
+		t.Errorf("expected empty timezone to mean local time, got %v, %v", loc, err)
+	}
+
+	if _, err := (DisplayConfig{Timezone: "Mars/Olympus"}).Location(); err == nil {
+		t.Error("expected unknown timezone to be rejected")
+	}
+}
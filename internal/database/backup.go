@@ -0,0 +1,102 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// backupStepPages is how many pages are copied per backup step. Copying in
+// steps lets writers in other connections get the lock in between.
+const backupStepPages = 256
+
+// backupBusyTimeout bounds how long a backup waits on a busy database
+const backupBusyTimeout = 30 * time.Second
+
+// BackupTo copies the live database to dest with SQLite's online backup API,
+// so it is safe while another process is writing. dest is overwritten.
+func (db *DB) BackupTo(dest string) error {
+	destDB, err := Open(dest)
+	if err != nil {
+		return fmt.Errorf("failed to open backup destination: %w", err)
+	}
+	defer destDB.Close()
+
+	ctx := context.Background()
+	srcConn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer srcConn.Close()
+
+	destConn, err := destDB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer destConn.Close()
+
+	return destConn.Raw(func(destRaw any) error {
+		return srcConn.Raw(func(srcRaw any) error {
+			destSQLite, ok := destRaw.(*sqlite3.SQLiteConn)
+			if !ok {
+				return fmt.Errorf("unexpected driver connection %T", destRaw)
+			}
+			srcSQLite, ok := srcRaw.(*sqlite3.SQLiteConn)
+			if !ok {
+				return fmt.Errorf("unexpected driver connection %T", srcRaw)
+			}
+
+			backup, err := destSQLite.Backup("main", srcSQLite, "main")
+			if err != nil {
+				return err
+			}
+
+			deadline := time.Now().Add(backupBusyTimeout)
+			for {
+				remaining := backup.Remaining()
+				done, err := backup.Step(backupStepPages)
+				if err != nil {
+					backup.Close()
+					return err
+				}
+				if done {
+					break
+				}
+				// Step reports busy and locked as progress without copying
+				// anything; back off until the writer is done
+				if backup.Remaining() == remaining && remaining > 0 {
+					if time.Now().After(deadline) {
+						backup.Close()
+						return fmt.Errorf("database stayed busy for %s", backupBusyTimeout)
+					}
+					time.Sleep(50 * time.Millisecond)
+				}
+			}
+			return backup.Finish()
+		})
+	})
+}
+
+// IntegrityCheck runs SQLite's integrity check and returns its problems, or
+// nil if the database is intact
+func (db *DB) IntegrityCheck() ([]string, error) {
+	rows, err := db.Query(`PRAGMA integrity_check`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var problems []string
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			return nil, err
+		}
+		if line != "ok" {
+			problems = append(problems, line)
+		}
+	}
+	return problems, rows.Err()
+}
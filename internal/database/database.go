@@ -3,6 +3,9 @@ package database
 
 import (
 	"database/sql"
+	"fmt"
+	"net/url"
+	"time"
 
 	_ "github.com/mattn/go-sqlite3"
 )
@@ -11,64 +14,118 @@ type DB struct {
 	*sql.DB
 }
 
+// Execer is implemented by both *sql.DB and *sql.Tx, so write helpers can
+// run either standalone or as part of a transaction
+type Execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+// BusyTimeout is how long a connection waits for another process's write
+// lock before failing with "database is locked"
+const BusyTimeout = 5 * time.Second
+
+// New opens the database at path and brings its schema up to date. It
+// refuses databases created by a newer version of xmon.
+//
+// The database is switched to WAL mode so the daemon can write while other
+// commands read.
 func New(path string) (*DB, error) {
-	db, err := sql.Open("sqlite3", path)
+	db, err := Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := db.Exec(`PRAGMA journal_mode = WAL`); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to enable WAL: %w", err)
+	}
+
+	if _, err := db.Migrate(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// Open opens the database at path without touching its schema. Every
+// connection waits up to BusyTimeout for locks and enforces foreign keys.
+func Open(path string) (*DB, error) {
+	dsn := fmt.Sprintf("file:%s?_busy_timeout=%d&_foreign_keys=on", url.PathEscape(path), BusyTimeout.Milliseconds())
+	db, err := sql.Open("sqlite3", dsn)
 	if err != nil {
 		return nil, err
 	}
 
 	if err := db.Ping(); err != nil {
+		db.Close()
 		return nil, err
 	}
 
-	wrapper := &DB{db}
-	if err := wrapper.initSchema(); err != nil {
+	return &DB{db}, nil
+}
+
+// OpenReadOnly opens the database at path for reading only. Both the file
+// and the connection refuse writes, so arbitrary user queries cannot change
+// anything.
+func OpenReadOnly(path string) (*DB, error) {
+	dsn := fmt.Sprintf("file:%s?mode=ro&_query_only=1&_busy_timeout=%d", url.PathEscape(path), BusyTimeout.Milliseconds())
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.Ping(); err != nil {
+		db.Close()
 		return nil, err
 	}
 
-	return wrapper, nil
+	return &DB{db}, nil
 }
 
-func (db *DB) initSchema() error {
-	schema := `
-	CREATE TABLE IF NOT EXISTS accounts (
-		id INTEGER PRIMARY KEY,
-		user_id TEXT UNIQUE NOT NULL,
-		username TEXT NOT NULL,
-		name TEXT,
-		bio TEXT,
-		followers INTEGER,
-		added_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		last_fetched DATETIME
-	);
-
-	CREATE TABLE IF NOT EXISTS tweets (
-		id INTEGER PRIMARY KEY,
-		account_id INTEGER NOT NULL,
-		tweet_id TEXT UNIQUE NOT NULL,
-		tweet_type TEXT NOT NULL,
-		content TEXT,
-		referenced_user TEXT,
-		referenced_tweet_id TEXT,
-		likes INTEGER DEFAULT 0,
-		retweets INTEGER DEFAULT 0,
-		created_at DATETIME,
-		fetched_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		FOREIGN KEY (account_id) REFERENCES accounts(id)
-	);
-
-	CREATE TABLE IF NOT EXISTS api_usage (
-		id INTEGER PRIMARY KEY,
-		month TEXT UNIQUE NOT NULL,
-		tweets_read INTEGER DEFAULT 0,
-		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
-	);
-
-	CREATE INDEX IF NOT EXISTS idx_tweets_account ON tweets(account_id);
-	CREATE INDEX IF NOT EXISTS idx_tweets_created ON tweets(created_at);
-	CREATE INDEX IF NOT EXISTS idx_tweets_type ON tweets(tweet_type);
-	`
-
-	_, err := db.Exec(schema)
+// Size returns the size of the database file in bytes
+func (db *DB) Size() (int64, error) {
+	var pages, pageSize int64
+	if err := db.QueryRow(`PRAGMA page_count`).Scan(&pages); err != nil {
+		return 0, err
+	}
+	if err := db.QueryRow(`PRAGMA page_size`).Scan(&pageSize); err != nil {
+		return 0, err
+	}
+	return pages * pageSize, nil
+}
+
+// addColumnIfMissing adds a column to an existing table. CREATE TABLE IF NOT
+// EXISTS leaves tables from older databases untouched, so new columns have to
+// be added explicitly.
+func (db *DB) addColumnIfMissing(table, column, decl string) error {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid       int
+			name      string
+			colType   string
+			notNull   int
+			dfltValue sql.NullString
+			pk        int
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dfltValue, &pk); err != nil {
+			return err
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+
+	_, err = db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, decl))
 	return err
 }
@@ -2,6 +2,7 @@
 package database
 
 import (
+	"database/sql"
 	"os"
 	"testing"
 )
@@ -23,3 +24,156 @@ func TestNewDB(t *testing.T) {
 		t.Error("expected non-nil db")
 	}
 }
+
+func TestNewDBAdoptsUnversionedSchema(t *testing.T) {
+	tmpfile, err := os.CreateTemp("", "xmon-test-*.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(tmpfile.Name())
+
+	// Simulate a database created before since_id existed
+	old, err := sql.Open("sqlite3", tmpfile.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = old.Exec(`CREATE TABLE accounts (
+		id INTEGER PRIMARY KEY,
+		user_id TEXT UNIQUE NOT NULL,
+		username TEXT NOT NULL,
+		name TEXT,
+		bio TEXT,
+		followers INTEGER,
+		added_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		last_fetched DATETIME
+	)`)
+	old.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := New(tmpfile.Name())
+	if err != nil {
+		t.Fatalf("failed to open old db: %v", err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec(`SELECT since_id, priority FROM accounts`); err != nil {
+		t.Errorf("expected new account columns to be added: %v", err)
+	}
+
+	version, _ := db.SchemaVersion()
+	if version != LatestVersion() {
+		t.Errorf("expected old db to be migrated to %d, got %d", LatestVersion(), version)
+	}
+}
+
+func TestNewDBSettings(t *testing.T) {
+	db, err := New(tempDBPath(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	var mode string
+	db.QueryRow(`PRAGMA journal_mode`).Scan(&mode)
+	if mode != "wal" {
+		t.Errorf("expected WAL journal mode, got %s", mode)
+	}
+
+	var fk, timeout int
+	db.QueryRow(`PRAGMA foreign_keys`).Scan(&fk)
+	if fk != 1 {
+		t.Error("expected foreign keys to be enforced")
+	}
+	db.QueryRow(`PRAGMA busy_timeout`).Scan(&timeout)
+	if timeout != int(BusyTimeout.Milliseconds()) {
+		t.Errorf("expected busy timeout %d, got %d", BusyTimeout.Milliseconds(), timeout)
+	}
+
+	_, err = db.Exec(`INSERT INTO tweets (account_id, tweet_id, tweet_type) VALUES (999, '1', 'original')`)
+	if err == nil {
+		t.Error("expected tweet without an account to be rejected")
+	}
+}
+
+func TestMigrateAdoptsOrphanedTweets(t *testing.T) {
+	path := tempDBPath(t)
+
+	// Older versions deleted accounts without touching their tweets
+	old, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = old.Exec(`
+		CREATE TABLE accounts (id INTEGER PRIMARY KEY, user_id TEXT UNIQUE NOT NULL, username TEXT NOT NULL, name TEXT, bio TEXT, followers INTEGER, added_at DATETIME DEFAULT CURRENT_TIMESTAMP, last_fetched DATETIME);
+		CREATE TABLE tweets (id INTEGER PRIMARY KEY, account_id INTEGER NOT NULL, tweet_id TEXT UNIQUE NOT NULL, tweet_type TEXT NOT NULL, content TEXT, referenced_user TEXT, referenced_tweet_id TEXT, likes INTEGER DEFAULT 0, retweets INTEGER DEFAULT 0, created_at DATETIME, fetched_at DATETIME DEFAULT CURRENT_TIMESTAMP, FOREIGN KEY (account_id) REFERENCES accounts(id));
+		INSERT INTO tweets (account_id, tweet_id, tweet_type, content) VALUES (42, '1', 'original', 'orphan');
+	`)
+	old.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := New(path)
+	if err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	defer db.Close()
+
+	var username string
+	var archived sql.NullTime
+	if err := db.QueryRow(`SELECT username, archived_at FROM accounts WHERE id = 42`).Scan(&username, &archived); err != nil {
+		t.Fatalf("expected placeholder account: %v", err)
+	}
+	if !archived.Valid {
+		t.Error("expected placeholder account to be archived")
+	}
+
+	rows, _ := db.Query(`PRAGMA foreign_key_check`)
+	defer rows.Close()
+	if rows.Next() {
+		t.Error("expected no foreign key violations")
+	}
+}
+
+func TestMigrateBackfillsReferencedTweets(t *testing.T) {
+	path := tempDBPath(t)
+
+	old, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = old.Exec(`
+		CREATE TABLE accounts (id INTEGER PRIMARY KEY, user_id TEXT UNIQUE NOT NULL, username TEXT NOT NULL, name TEXT, bio TEXT, followers INTEGER, added_at DATETIME DEFAULT CURRENT_TIMESTAMP, last_fetched DATETIME);
+		CREATE TABLE tweets (id INTEGER PRIMARY KEY, account_id INTEGER NOT NULL, tweet_id TEXT UNIQUE NOT NULL, tweet_type TEXT NOT NULL, content TEXT, referenced_user TEXT, referenced_tweet_id TEXT, likes INTEGER DEFAULT 0, retweets INTEGER DEFAULT 0, created_at DATETIME, fetched_at DATETIME DEFAULT CURRENT_TIMESTAMP, FOREIGN KEY (account_id) REFERENCES accounts(id));
+		INSERT INTO accounts (id, user_id, username) VALUES (1, '1', 'alice'), (2, '2', 'bob');
+		INSERT INTO tweets (account_id, tweet_id, tweet_type, content, referenced_user, referenced_tweet_id, retweets) VALUES
+			(1, '10', 'retweet', 'RT @dan: hello world', 'dan', '100', 7),
+			(2, '11', 'retweet', 'RT @dan: hello world', 'dan', '100', 8),
+			(2, '12', 'quote', 'my take', 'dan', '100', 0);
+	`)
+	old.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := New(path)
+	if err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	defer db.Close()
+
+	var content string
+	var retweets, links int
+	if err := db.QueryRow(`SELECT content, retweets FROM referenced_tweets WHERE tweet_id = '100'`).Scan(&content, &retweets); err != nil {
+		t.Fatalf("expected referenced tweet to be backfilled: %v", err)
+	}
+	if content != "hello world" || retweets != 8 {
+		t.Errorf("unexpected backfill %q with %d retweets", content, retweets)
+	}
+	db.QueryRow(`SELECT COUNT(*) FROM tweet_references WHERE referenced_tweet_id = '100'`).Scan(&links)
+	if links != 3 {
+		t.Errorf("expected 3 links, got %d", links)
+	}
+}
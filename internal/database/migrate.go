@@ -0,0 +1,223 @@
+package database
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// ErrSchemaTooNew is returned when a database was migrated by a newer xmon
+var ErrSchemaTooNew = errors.New("database schema is newer than this version of xmon supports")
+
+// Migration is one ordered schema change, embedded from migrations/NNNN_name.sql
+type Migration struct {
+	Version int
+	Name    string
+	SQL     string
+}
+
+// AppliedMigration is a row of the schema_version table
+type AppliedMigration struct {
+	Version   int
+	Name      string
+	AppliedAt time.Time
+}
+
+// Migrations returns all embedded migrations in version order
+func Migrations() ([]Migration, error) {
+	entries, err := fs.ReadDir(migrationFiles, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	var migrations []Migration
+	for _, e := range entries {
+		base := strings.TrimSuffix(e.Name(), ".sql")
+		num, name, ok := strings.Cut(base, "_")
+		if !ok {
+			return nil, fmt.Errorf("migration %s: expected NNNN_name.sql", e.Name())
+		}
+		version, err := strconv.Atoi(num)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: invalid version: %w", e.Name(), err)
+		}
+
+		data, err := migrationFiles.ReadFile("migrations/" + e.Name())
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, Migration{Version: version, Name: name, SQL: string(data)})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			return nil, fmt.Errorf("migration versions must be consecutive from 1, found %d at position %d", m.Version, i+1)
+		}
+	}
+
+	return migrations, nil
+}
+
+// LatestVersion is the schema version this build of xmon migrates to
+func LatestVersion() int {
+	migrations, _ := Migrations()
+	return len(migrations)
+}
+
+// SchemaVersion returns the version the database is at, 0 if it was never migrated
+func (db *DB) SchemaVersion() (int, error) {
+	exists, err := db.tableExists("schema_version")
+	if err != nil || !exists {
+		return 0, err
+	}
+
+	var version int
+	err = db.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_version`).Scan(&version)
+	return version, err
+}
+
+// AppliedMigrations lists the migrations recorded in schema_version
+func (db *DB) AppliedMigrations() ([]AppliedMigration, error) {
+	exists, err := db.tableExists("schema_version")
+	if err != nil || !exists {
+		return nil, err
+	}
+
+	rows, err := db.Query(`SELECT version, name, applied_at FROM schema_version ORDER BY version`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var applied []AppliedMigration
+	for rows.Next() {
+		var m AppliedMigration
+		if err := rows.Scan(&m.Version, &m.Name, &m.AppliedAt); err != nil {
+			return nil, err
+		}
+		applied = append(applied, m)
+	}
+	return applied, rows.Err()
+}
+
+// PendingMigrations returns the migrations not yet applied to the database
+func (db *DB) PendingMigrations() ([]Migration, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+
+	version, err := db.SchemaVersion()
+	if err != nil {
+		return nil, err
+	}
+	if version > len(migrations) {
+		return nil, fmt.Errorf("%w (database is at version %d, latest known is %d)", ErrSchemaTooNew, version, len(migrations))
+	}
+
+	return migrations[version:], nil
+}
+
+// Migrate applies pending migrations in order, each in its own transaction,
+// and returns the ones it applied
+func (db *DB) Migrate() ([]Migration, error) {
+	pending, err := db.PendingMigrations()
+	if err != nil {
+		return nil, err
+	}
+	if len(pending) == 0 {
+		return nil, nil
+	}
+
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS schema_version (
+			version INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			applied_at DATETIME NOT NULL
+		)
+	`); err != nil {
+		return nil, err
+	}
+
+	if pending[0].Version == 1 {
+		if err := db.adoptLegacySchema(); err != nil {
+			return nil, fmt.Errorf("upgrade unversioned database: %w", err)
+		}
+	}
+
+	var applied []Migration
+	for _, m := range pending {
+		if err := db.apply(m); err != nil {
+			if strings.Contains(err.Error(), "no such module: fts5") {
+				err = fmt.Errorf("%w (xmon must be built with -tags sqlite_fts5)", err)
+			}
+			return applied, fmt.Errorf("migration %04d_%s: %w", m.Version, m.Name, err)
+		}
+		applied = append(applied, m)
+	}
+	return applied, nil
+}
+
+func (db *DB) apply(m Migration) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(m.SQL); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(
+		`INSERT INTO schema_version (version, name, applied_at) VALUES (?, ?, ?)`,
+		m.Version, m.Name, time.Now().UTC(),
+	); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// adoptLegacySchema prepares databases created before schema_version
+// existed. Their tables were made with CREATE TABLE IF NOT EXISTS at
+// whatever shape xmon had at the time, so the baseline migration would skip
+// them; columns added since are filled in here first.
+func (db *DB) adoptLegacySchema() error {
+	columns := []struct{ table, column, decl string }{
+		{"accounts", "since_id", "TEXT DEFAULT ''"},
+		{"accounts", "priority", "TEXT DEFAULT 'normal'"},
+		{"fetch_runs", "error", "TEXT DEFAULT ''"},
+		{"fetch_runs", "skipped", "INTEGER DEFAULT 0"},
+		{"fetch_run_accounts", "skipped", "TEXT DEFAULT ''"},
+	}
+	for _, c := range columns {
+		exists, err := db.tableExists(c.table)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			continue
+		}
+		if err := db.addColumnIfMissing(c.table, c.column, c.decl); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (db *DB) tableExists(name string) (bool, error) {
+	var count int
+	err := db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`, name).Scan(&count)
+	return count > 0, err
+}
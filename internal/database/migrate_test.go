@@ -0,0 +1,88 @@
+package database
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+func tempDBPath(t *testing.T) string {
+	tmpfile, err := os.CreateTemp("", "xmon-migrate-test-*.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpfile.Close()
+	t.Cleanup(func() { os.Remove(tmpfile.Name()) })
+	return tmpfile.Name()
+}
+
+func TestMigrations(t *testing.T) {
+	migrations, err := Migrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) == 0 {
+		t.Fatal("expected embedded migrations")
+	}
+	if migrations[0].Version != 1 || migrations[0].SQL == "" {
+		t.Errorf("unexpected first migration: %+v", migrations[0])
+	}
+}
+
+func TestMigrateFreshDatabase(t *testing.T) {
+	path := tempDBPath(t)
+
+	db, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	version, _ := db.SchemaVersion()
+	if version != 0 {
+		t.Errorf("expected version 0 before migrating, got %d", version)
+	}
+
+	applied, err := db.Migrate()
+	if err != nil {
+		t.Fatalf("migrate failed: %v", err)
+	}
+	if len(applied) != LatestVersion() {
+		t.Errorf("expected %d migrations applied, got %d", LatestVersion(), len(applied))
+	}
+
+	version, _ = db.SchemaVersion()
+	if version != LatestVersion() {
+		t.Errorf("expected version %d, got %d", LatestVersion(), version)
+	}
+
+	// Running again is a no-op
+	applied, err = db.Migrate()
+	if err != nil || len(applied) != 0 {
+		t.Errorf("expected no-op, got %d applied, err %v", len(applied), err)
+	}
+
+	pending, _ := db.PendingMigrations()
+	if len(pending) != 0 {
+		t.Errorf("expected nothing pending, got %d", len(pending))
+	}
+}
+
+func TestRefuseNewerSchema(t *testing.T) {
+	path := tempDBPath(t)
+
+	db, err := New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec(`INSERT INTO schema_version (version, name, applied_at) VALUES (?, 'from_the_future', CURRENT_TIMESTAMP)`, LatestVersion()+1)
+	db.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = New(path)
+	if !errors.Is(err, ErrSchemaTooNew) {
+		t.Errorf("expected ErrSchemaTooNew, got %v", err)
+	}
+}
@@ -0,0 +1,85 @@
+-- Baseline schema. Databases created before versioned migrations existed
+-- already have some of these tables; IF NOT EXISTS lets them adopt it.
+
+CREATE TABLE IF NOT EXISTS accounts (
+	id INTEGER PRIMARY KEY,
+	user_id TEXT UNIQUE NOT NULL,
+	username TEXT NOT NULL,
+	name TEXT,
+	bio TEXT,
+	followers INTEGER,
+	added_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	last_fetched DATETIME,
+	since_id TEXT DEFAULT '',
+	priority TEXT DEFAULT 'normal'
+);
+
+CREATE TABLE IF NOT EXISTS tweets (
+	id INTEGER PRIMARY KEY,
+	account_id INTEGER NOT NULL,
+	tweet_id TEXT UNIQUE NOT NULL,
+	tweet_type TEXT NOT NULL,
+	content TEXT,
+	referenced_user TEXT,
+	referenced_tweet_id TEXT,
+	likes INTEGER DEFAULT 0,
+	retweets INTEGER DEFAULT 0,
+	created_at DATETIME,
+	fetched_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (account_id) REFERENCES accounts(id)
+);
+
+CREATE TABLE IF NOT EXISTS api_usage (
+	id INTEGER PRIMARY KEY,
+	month TEXT UNIQUE NOT NULL,
+	tweets_read INTEGER DEFAULT 0,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS api_usage_daily (
+	day TEXT PRIMARY KEY,
+	tweets_read INTEGER DEFAULT 0,
+	requests INTEGER DEFAULT 0,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS api_requests (
+	cycle TEXT NOT NULL,
+	endpoint TEXT NOT NULL,
+	requests INTEGER DEFAULT 0,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (cycle, endpoint)
+);
+
+CREATE TABLE IF NOT EXISTS fetch_runs (
+	id INTEGER PRIMARY KEY,
+	started_at DATETIME NOT NULL,
+	finished_at DATETIME,
+	status TEXT NOT NULL DEFAULT 'running',
+	new_tweets INTEGER DEFAULT 0,
+	tweets_read INTEGER DEFAULT 0,
+	errors INTEGER DEFAULT 0,
+	rate_limit_remaining INTEGER,
+	rate_limit_reset DATETIME,
+	error TEXT DEFAULT '',
+	skipped INTEGER DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS fetch_run_accounts (
+	id INTEGER PRIMARY KEY,
+	run_id INTEGER NOT NULL,
+	account_id INTEGER NOT NULL,
+	username TEXT NOT NULL,
+	new_tweets INTEGER DEFAULT 0,
+	tweets_read INTEGER DEFAULT 0,
+	http_status INTEGER DEFAULT 0,
+	error TEXT DEFAULT '',
+	skipped TEXT DEFAULT '',
+	FOREIGN KEY (run_id) REFERENCES fetch_runs(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_tweets_account ON tweets(account_id);
+CREATE INDEX IF NOT EXISTS idx_tweets_created ON tweets(created_at);
+CREATE INDEX IF NOT EXISTS idx_tweets_type ON tweets(tweet_type);
+CREATE INDEX IF NOT EXISTS idx_fetch_runs_started ON fetch_runs(started_at);
+CREATE INDEX IF NOT EXISTS idx_fetch_run_accounts_run ON fetch_run_accounts(run_id);
@@ -0,0 +1,24 @@
+-- Full-text index over tweet content. FTS4 rather than FTS5 because
+-- go-sqlite3 only compiles FTS5 in with the sqlite_fts5 build tag.
+-- External content: the index stores no copy of the text, and the triggers
+-- keep it in sync with the tweets table.
+
+CREATE VIRTUAL TABLE tweets_fts USING fts4(content="tweets", content, tokenize=unicode61 "remove_diacritics=1");
+
+CREATE TRIGGER tweets_fts_before_update BEFORE UPDATE OF content ON tweets BEGIN
+	DELETE FROM tweets_fts WHERE docid = old.id;
+END;
+
+CREATE TRIGGER tweets_fts_before_delete BEFORE DELETE ON tweets BEGIN
+	DELETE FROM tweets_fts WHERE docid = old.id;
+END;
+
+CREATE TRIGGER tweets_fts_after_update AFTER UPDATE OF content ON tweets BEGIN
+	INSERT INTO tweets_fts (docid, content) VALUES (new.id, new.content);
+END;
+
+CREATE TRIGGER tweets_fts_after_insert AFTER INSERT ON tweets BEGIN
+	INSERT INTO tweets_fts (docid, content) VALUES (new.id, new.content);
+END;
+
+INSERT INTO tweets_fts (tweets_fts) VALUES ('rebuild');
@@ -0,0 +1,4 @@
+-- Removed accounts are archived rather than deleted so their tweets keep
+-- an owner. NULL means the account is actively monitored.
+
+ALTER TABLE accounts ADD COLUMN archived_at DATETIME;
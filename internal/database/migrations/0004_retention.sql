@@ -0,0 +1,18 @@
+-- Retention: starred tweets are never pruned, and per-account daily
+-- aggregates are kept forever so long-range charts survive pruning.
+
+ALTER TABLE tweets ADD COLUMN starred_at DATETIME;
+
+CREATE TABLE account_daily (
+	day TEXT NOT NULL,
+	account_id INTEGER NOT NULL,
+	originals INTEGER DEFAULT 0,
+	retweets INTEGER DEFAULT 0,
+	quotes INTEGER DEFAULT 0,
+	likes_received INTEGER DEFAULT 0,
+	retweets_received INTEGER DEFAULT 0,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (day, account_id)
+);
+
+CREATE INDEX idx_tweets_starred ON tweets(starred_at) WHERE starred_at IS NOT NULL;
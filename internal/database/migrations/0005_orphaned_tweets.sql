@@ -0,0 +1,9 @@
+-- Foreign keys are enforced from this version on. Older versions deleted
+-- accounts (and re-added them under a new id) without touching their
+-- tweets, so give any orphaned tweets an archived placeholder owner rather
+-- than leave rows that violate tweets.account_id.
+
+INSERT INTO accounts (id, user_id, username, name, archived_at)
+SELECT DISTINCT t.account_id, 'orphan:' || t.account_id, 'unknown_' || t.account_id, 'Unknown account', CURRENT_TIMESTAMP
+FROM tweets t
+WHERE NOT EXISTS (SELECT 1 FROM accounts a WHERE a.id = t.account_id);
@@ -0,0 +1,9 @@
+-- Tweet timestamps are compared as text, which only orders correctly when
+-- every value is in the same zone. Rewrite any stored with a local offset
+-- as UTC; new tweets are always written in UTC.
+
+UPDATE tweets
+SET created_at = strftime('%Y-%m-%d %H:%M:%f', created_at) || '+00:00'
+WHERE created_at IS NOT NULL
+	AND created_at NOT LIKE '%+00:00'
+	AND strftime('%Y-%m-%d %H:%M:%f', created_at) IS NOT NULL;
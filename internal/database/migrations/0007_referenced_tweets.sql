@@ -0,0 +1,45 @@
+-- Posts that tracked accounts retweet or quote are stored once, with their
+-- author, full text and metrics, and linked to every amplifying tweet.
+
+CREATE TABLE referenced_tweets (
+	tweet_id TEXT PRIMARY KEY,
+	author_id TEXT NOT NULL DEFAULT '',
+	author_username TEXT NOT NULL DEFAULT '',
+	content TEXT NOT NULL DEFAULT '',
+	likes INTEGER DEFAULT 0,
+	retweets INTEGER DEFAULT 0,
+	created_at DATETIME,
+	first_seen DATETIME DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE tweet_references (
+	tweet_id TEXT NOT NULL,
+	referenced_tweet_id TEXT NOT NULL,
+	kind TEXT NOT NULL,
+	PRIMARY KEY (tweet_id, referenced_tweet_id),
+	FOREIGN KEY (tweet_id) REFERENCES tweets(tweet_id) ON DELETE CASCADE,
+	FOREIGN KEY (referenced_tweet_id) REFERENCES referenced_tweets(tweet_id)
+);
+
+CREATE INDEX idx_tweet_references_referenced ON tweet_references(referenced_tweet_id);
+
+-- Backfill from existing rows. Only the truncated "RT @user: " text of
+-- retweets is known so far; the next fetch that sees the post fills in the
+-- rest.
+INSERT INTO referenced_tweets (tweet_id, author_username, content, likes, retweets)
+SELECT
+	referenced_tweet_id,
+	COALESCE(MAX(referenced_user), ''),
+	COALESCE(MAX(CASE WHEN tweet_type = 'retweet' AND content LIKE 'RT @%: %'
+		THEN substr(content, instr(content, ': ') + 2) END), ''),
+	MAX(CASE WHEN tweet_type = 'retweet' THEN COALESCE(likes, 0) ELSE 0 END),
+	MAX(CASE WHEN tweet_type = 'retweet' THEN COALESCE(retweets, 0) ELSE 0 END)
+FROM tweets
+WHERE tweet_type IN ('retweet', 'quote') AND COALESCE(referenced_tweet_id, '') != ''
+GROUP BY referenced_tweet_id;
+
+INSERT OR IGNORE INTO tweet_references (tweet_id, referenced_tweet_id, kind)
+SELECT tweet_id, referenced_tweet_id, tweet_type
+FROM tweets
+WHERE tweet_type IN ('retweet', 'quote') AND COALESCE(referenced_tweet_id, '') != '';
@@ -0,0 +1,6 @@
+-- Language X detected for each tweet (BCP 47, e.g. "en"). Tweets fetched
+-- before this have none.
+
+ALTER TABLE tweets ADD COLUMN lang TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX idx_tweets_lang ON tweets(lang) WHERE lang != '';
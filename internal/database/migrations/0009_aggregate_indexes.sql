@@ -0,0 +1,11 @@
+-- Covering indexes for the digest aggregates. Window counts by account and
+-- type read (created_at, account_id, tweet_type) without touching rows, and
+-- amplification ranking seeks straight to retweets and quotes in the
+-- window. They supersede the single-column created_at and type indexes.
+
+CREATE INDEX idx_tweets_created_account_type ON tweets(created_at, account_id, tweet_type);
+
+CREATE INDEX idx_tweets_type_created_ref ON tweets(tweet_type, created_at, referenced_user, account_id);
+
+DROP INDEX IF EXISTS idx_tweets_created;
+DROP INDEX IF EXISTS idx_tweets_type;
@@ -0,0 +1,54 @@
+-- Daily rollups: account_daily is kept current on ingest rather than only
+-- written by retention, and gains the topics and amplified users of each
+-- account-day. pruned_at marks days whose raw tweets retention deleted;
+-- their rollups are only ever merged upwards.
+--
+-- Topics need the Go tokenizer, so 'xmon db rebuild-rollups' fills them in
+-- for tweets stored before this version.
+
+ALTER TABLE account_daily ADD COLUMN pruned_at DATETIME;
+
+CREATE TABLE account_daily_topics (
+	day TEXT NOT NULL,
+	account_id INTEGER NOT NULL,
+	topic TEXT NOT NULL,
+	count INTEGER NOT NULL DEFAULT 0,
+	PRIMARY KEY (day, account_id, topic)
+);
+
+CREATE TABLE account_daily_amplified (
+	day TEXT NOT NULL,
+	account_id INTEGER NOT NULL,
+	username TEXT NOT NULL,
+	count INTEGER NOT NULL DEFAULT 0,
+	PRIMARY KEY (day, account_id, username)
+);
+
+-- Until now only retention wrote daily rows
+UPDATE account_daily SET pruned_at = updated_at;
+
+INSERT INTO account_daily (day, account_id, originals, retweets, quotes, likes_received, retweets_received, updated_at)
+SELECT
+	date(created_at),
+	account_id,
+	SUM(CASE WHEN tweet_type = 'original' THEN 1 ELSE 0 END),
+	SUM(CASE WHEN tweet_type = 'retweet' THEN 1 ELSE 0 END),
+	SUM(CASE WHEN tweet_type = 'quote' THEN 1 ELSE 0 END),
+	SUM(likes),
+	SUM(retweets),
+	CURRENT_TIMESTAMP
+FROM tweets
+WHERE date(created_at) IS NOT NULL
+GROUP BY date(created_at), account_id
+ON CONFLICT(day, account_id) DO UPDATE SET
+	originals = MAX(originals, excluded.originals),
+	retweets = MAX(retweets, excluded.retweets),
+	quotes = MAX(quotes, excluded.quotes),
+	likes_received = MAX(likes_received, excluded.likes_received),
+	retweets_received = MAX(retweets_received, excluded.retweets_received);
+
+INSERT INTO account_daily_amplified (day, account_id, username, count)
+SELECT date(created_at), account_id, referenced_user, COUNT(*)
+FROM tweets
+WHERE tweet_type IN ('retweet', 'quote') AND referenced_user != '' AND date(created_at) IS NOT NULL
+GROUP BY date(created_at), account_id, referenced_user;
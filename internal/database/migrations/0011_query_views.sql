@@ -0,0 +1,55 @@
+-- Views for 'xmon query'. Their column names are a stable interface for
+-- saved queries and scripts: columns may be added, but existing ones are not
+-- renamed or removed. Times are UTC.
+
+-- One row per stored tweet
+CREATE VIEW v_tweets AS
+SELECT
+	t.tweet_id,
+	a.username,
+	t.tweet_type AS type,
+	COALESCE(t.content, '') AS text,
+	COALESCE(t.likes, 0) AS likes,
+	COALESCE(t.retweets, 0) AS retweets,
+	COALESCE(t.likes, 0) + COALESCE(t.retweets, 0) AS engagement,
+	t.lang,
+	COALESCE(t.referenced_user, '') AS referenced_user,
+	COALESCE(t.referenced_tweet_id, '') AS referenced_tweet_id,
+	t.starred_at IS NOT NULL AS starred,
+	t.created_at,
+	'https://x.com/' || a.username || '/status/' || t.tweet_id AS url
+FROM tweets t
+JOIN accounts a ON a.id = t.account_id;
+
+-- One row per retweet or quote of a post, with the post itself
+CREATE VIEW v_amplification AS
+SELECT
+	tr.tweet_id,
+	a.username AS amplified_by,
+	tr.kind,
+	t.created_at AS amplified_at,
+	rt.tweet_id AS post_id,
+	rt.author_username AS post_author,
+	rt.content AS post_text,
+	COALESCE(rt.likes, 0) AS post_likes,
+	COALESCE(rt.retweets, 0) AS post_retweets,
+	rt.created_at AS post_created_at
+FROM tweet_references tr
+JOIN tweets t ON t.tweet_id = tr.tweet_id
+JOIN accounts a ON a.id = t.account_id
+JOIN referenced_tweets rt ON rt.tweet_id = tr.referenced_tweet_id;
+
+-- Daily rollups per account, including days whose tweets were pruned
+CREATE VIEW v_account_daily AS
+SELECT
+	d.day,
+	a.username,
+	COALESCE(d.originals, 0) AS originals,
+	COALESCE(d.retweets, 0) AS retweets,
+	COALESCE(d.quotes, 0) AS quotes,
+	COALESCE(d.originals, 0) + COALESCE(d.retweets, 0) + COALESCE(d.quotes, 0) AS tweets,
+	COALESCE(d.likes_received, 0) AS likes_received,
+	COALESCE(d.retweets_received, 0) AS retweets_received,
+	d.pruned_at IS NOT NULL AS pruned
+FROM account_daily d
+JOIN accounts a ON a.id = d.account_id;
@@ -0,0 +1,27 @@
+-- Full-text index moves from FTS4 to FTS5 for its built-in bm25() ranking
+-- and snippet() highlighting, so searches rank and limit inside SQLite.
+-- go-sqlite3 only compiles FTS5 in with the sqlite_fts5 build tag; see the
+-- README. Still external content, kept in sync by triggers.
+
+DROP TRIGGER tweets_fts_before_update;
+DROP TRIGGER tweets_fts_before_delete;
+DROP TRIGGER tweets_fts_after_update;
+DROP TRIGGER tweets_fts_after_insert;
+DROP TABLE tweets_fts;
+
+CREATE VIRTUAL TABLE tweets_fts USING fts5(content, content='tweets', content_rowid='id', tokenize='unicode61 remove_diacritics 1');
+
+CREATE TRIGGER tweets_fts_after_insert AFTER INSERT ON tweets BEGIN
+	INSERT INTO tweets_fts (rowid, content) VALUES (new.id, new.content);
+END;
+
+CREATE TRIGGER tweets_fts_after_delete AFTER DELETE ON tweets BEGIN
+	INSERT INTO tweets_fts (tweets_fts, rowid, content) VALUES ('delete', old.id, old.content);
+END;
+
+CREATE TRIGGER tweets_fts_after_update AFTER UPDATE OF content ON tweets BEGIN
+	INSERT INTO tweets_fts (tweets_fts, rowid, content) VALUES ('delete', old.id, old.content);
+	INSERT INTO tweets_fts (rowid, content) VALUES (new.id, new.content);
+END;
+
+INSERT INTO tweets_fts (tweets_fts) VALUES ('rebuild');
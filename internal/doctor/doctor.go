@@ -0,0 +1,414 @@
+// Package doctor runs health checks over the config, database and the APIs
+// xmon depends on, each with a suggested fix
+package doctor
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jpequegn/xmon/internal/account"
+	"github.com/jpequegn/xmon/internal/config"
+	"github.com/jpequegn/xmon/internal/database"
+	"github.com/jpequegn/xmon/internal/llm"
+	"github.com/jpequegn/xmon/internal/retention"
+	"github.com/jpequegn/xmon/internal/usage"
+	"github.com/jpequegn/xmon/internal/x"
+)
+
+type Status int
+
+const (
+	StatusOK Status = iota
+	StatusWarn
+	StatusFail
+	StatusSkipped
+)
+
+func (s Status) String() string {
+	switch s {
+	case StatusOK:
+		return "ok"
+	case StatusWarn:
+		return "warn"
+	case StatusFail:
+		return "fail"
+	default:
+		return "skipped"
+	}
+}
+
+// Result is the outcome of one check
+type Result struct {
+	Name    string
+	Status  Status
+	Message string
+	Fix     string // what to do about a warning or failure
+}
+
+// probeUsername is looked up to test the bearer token. A user lookup costs
+// one request and no tweet reads.
+const probeUsername = "X"
+
+// Env is everything the checks look at. Nil clients skip the checks that
+// need them, e.g. when running offline.
+type Env struct {
+	Config     *config.Config
+	ConfigErr  error
+	ConfigPath string
+	DB         *database.DB
+	DBErr      error
+	X          *x.Client
+	LLM        *llm.Client
+	Now        time.Time
+}
+
+// Run runs every check in order
+func Run(env Env) []Result {
+	if env.Now.IsZero() {
+		env.Now = time.Now()
+	}
+
+	var results []Result
+	results = append(results, checkConfig(env))
+	results = append(results, checkToken(env))
+	results = append(results, checkIntegrity(env))
+	results = append(results, checkSchema(env))
+	results = append(results, checkOrphans(env))
+	results = append(results, checkNeverFetched(env))
+	results = append(results, checkStale(env))
+	results = append(results, checkQuota(env))
+	results = append(results, checkLLM(env))
+	return results
+}
+
+// Failed reports whether any result failed, or also warned when strict
+func Failed(results []Result, strict bool) bool {
+	for _, r := range results {
+		if r.Status == StatusFail || (strict && r.Status == StatusWarn) {
+			return true
+		}
+	}
+	return false
+}
+
+func checkConfig(env Env) Result {
+	r := Result{Name: "config"}
+	if env.ConfigErr != nil {
+		r.Status = StatusFail
+		r.Message = fmt.Sprintf("cannot load %s: %v", env.ConfigPath, env.ConfigErr)
+		r.Fix = "Run 'xmon init' or fix the YAML syntax"
+		return r
+	}
+
+	var problems []string
+	if _, err := usage.PolicyFromConfig(env.Config.Usage); err != nil {
+		problems = append(problems, "usage: "+err.Error())
+	}
+	if _, err := retention.PolicyFromConfig(env.Config.Retention); err != nil {
+		problems = append(problems, "retention: "+err.Error())
+	}
+	if _, err := env.Config.Display.Location(); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if env.Config.Fetch.DefaultInterval <= 0 {
+		problems = append(problems, fmt.Sprintf("fetch.default_interval must be positive, got %d", env.Config.Fetch.DefaultInterval))
+	}
+	if len(problems) > 0 {
+		r.Status = StatusFail
+		r.Message = strings.Join(problems, "; ")
+		r.Fix = "Edit " + env.ConfigPath
+		return r
+	}
+
+	r.Message = env.ConfigPath
+	return r
+}
+
+func checkToken(env Env) Result {
+	r := Result{Name: "x token"}
+	if env.Config == nil {
+		r.Status = StatusSkipped
+		r.Message = "no config"
+		return r
+	}
+	if env.Config.X.BearerToken == "" {
+		r.Status = StatusFail
+		r.Message = "no bearer token set"
+		r.Fix = "Run 'xmon init' or set x.bearer_token in " + env.ConfigPath
+		return r
+	}
+	if env.X == nil {
+		r.Status = StatusSkipped
+		r.Message = "token set, not verified (offline)"
+		return r
+	}
+
+	_, err := env.X.GetUser(probeUsername)
+	switch status := x.StatusCode(err); {
+	case err == nil:
+		r.Message = "token accepted by the X API"
+	case status == http.StatusUnauthorized:
+		r.Status = StatusFail
+		r.Message = "token rejected (401)"
+		r.Fix = "Regenerate the bearer token in the X developer portal and update x.bearer_token"
+	case status == http.StatusForbidden:
+		r.Status = StatusFail
+		r.Message = "token lacks access to user lookup (403)"
+		r.Fix = "Attach the app to a project with v2 API access in the X developer portal"
+	case status == http.StatusTooManyRequests:
+		r.Status = StatusWarn
+		r.Message = "token valid but rate limited (429)"
+		r.Fix = "Wait for the rate limit window to reset"
+	case status != 0:
+		r.Status = StatusWarn
+		r.Message = err.Error()
+		r.Fix = "Check the X API status page"
+	default:
+		r.Status = StatusWarn
+		r.Message = fmt.Sprintf("cannot reach the X API: %v", err)
+		r.Fix = "Check your network connection"
+	}
+	return r
+}
+
+func checkIntegrity(env Env) Result {
+	r := Result{Name: "database integrity"}
+	if env.DB == nil {
+		r.Status = StatusFail
+		r.Message = fmt.Sprintf("cannot open database: %v", env.DBErr)
+		r.Fix = "Run 'xmon init', or 'xmon restore <backup>' if the file is damaged"
+		return r
+	}
+
+	problems, err := env.DB.IntegrityCheck()
+	if err != nil {
+		r.Status = StatusFail
+		r.Message = err.Error()
+		r.Fix = "Restore from a backup with 'xmon restore <file>'"
+		return r
+	}
+	if len(problems) > 0 {
+		r.Status = StatusFail
+		r.Message = fmt.Sprintf("%d problem(s): %s", len(problems), problems[0])
+		r.Fix = "Restore from a backup with 'xmon restore <file>'"
+		return r
+	}
+
+	r.Message = "ok"
+	if size, err := env.DB.Size(); err == nil {
+		r.Message = fmt.Sprintf("ok (%.1f MiB)", float64(size)/(1<<20))
+	}
+	return r
+}
+
+func checkSchema(env Env) Result {
+	r := Result{Name: "schema version"}
+	if env.DB == nil {
+		r.Status = StatusSkipped
+		return r
+	}
+
+	version, err := env.DB.SchemaVersion()
+	if err != nil {
+		r.Status = StatusFail
+		r.Message = err.Error()
+		return r
+	}
+
+	pending, err := env.DB.PendingMigrations()
+	if err != nil {
+		r.Status = StatusFail
+		r.Message = err.Error()
+		r.Fix = "Upgrade xmon"
+		return r
+	}
+	if len(pending) > 0 {
+		r.Status = StatusWarn
+		r.Message = fmt.Sprintf("version %d, %d migration(s) pending", version, len(pending))
+		r.Fix = "Run 'xmon db migrate'"
+		return r
+	}
+
+	r.Message = fmt.Sprintf("version %d (latest)", version)
+	return r
+}
+
+func checkOrphans(env Env) Result {
+	r := Result{Name: "orphaned tweets"}
+	if env.DB == nil {
+		r.Status = StatusSkipped
+		return r
+	}
+
+	var orphans int
+	err := env.DB.QueryRow(`
+		SELECT COUNT(*) FROM tweets t
+		WHERE NOT EXISTS (SELECT 1 FROM accounts a WHERE a.id = t.account_id)
+	`).Scan(&orphans)
+	if err != nil {
+		r.Status = StatusFail
+		r.Message = err.Error()
+		return r
+	}
+	if orphans > 0 {
+		r.Status = StatusWarn
+		r.Message = fmt.Sprintf("%d tweet(s) belong to no account", orphans)
+		r.Fix = "Run 'xmon prune' to age them out with the retention policy"
+		return r
+	}
+
+	r.Message = "none"
+	return r
+}
+
+func checkNeverFetched(env Env) Result {
+	r := Result{Name: "never fetched"}
+	if env.DB == nil {
+		r.Status = StatusSkipped
+		return r
+	}
+
+	accounts, err := account.NewRepository(env.DB).List()
+	if err != nil {
+		r.Status = StatusFail
+		r.Message = err.Error()
+		return r
+	}
+
+	var never []string
+	for _, a := range accounts {
+		if a.LastFetched == nil {
+			never = append(never, "@"+a.Username)
+		}
+	}
+	if len(never) > 0 {
+		r.Status = StatusWarn
+		r.Message = fmt.Sprintf("%d account(s): %s", len(never), strings.Join(never, ", "))
+		r.Fix = "Run 'xmon fetch'"
+		return r
+	}
+
+	r.Message = fmt.Sprintf("all %d account(s) fetched at least once", len(accounts))
+	return r
+}
+
+func checkStale(env Env) Result {
+	r := Result{Name: "stale accounts"}
+	if env.DB == nil || env.Config == nil {
+		r.Status = StatusSkipped
+		return r
+	}
+
+	// Same threshold as the digest's freshness warning
+	staleAfter := 2 * time.Duration(env.Config.Fetch.DefaultInterval) * time.Minute
+
+	accounts, err := account.NewRepository(env.DB).List()
+	if err != nil {
+		r.Status = StatusFail
+		r.Message = err.Error()
+		return r
+	}
+
+	var stale []string
+	for _, a := range accounts {
+		if a.LastFetched != nil && env.Now.Sub(*a.LastFetched) > staleAfter {
+			stale = append(stale, fmt.Sprintf("@%s (%s ago)", a.Username, env.Now.Sub(*a.LastFetched).Round(time.Hour)))
+		}
+	}
+	if len(stale) > 0 {
+		r.Status = StatusWarn
+		r.Message = fmt.Sprintf("%d account(s) not fetched within %s: %s", len(stale), staleAfter, strings.Join(stale, ", "))
+		r.Fix = "Check 'xmon fetch history --failed', and that the daemon is running"
+		return r
+	}
+
+	r.Message = "none"
+	return r
+}
+
+func checkQuota(env Env) Result {
+	r := Result{Name: "api quota"}
+	if env.DB == nil || env.Config == nil {
+		r.Status = StatusSkipped
+		return r
+	}
+
+	policy, err := usage.PolicyFromConfig(env.Config.Usage)
+	if err != nil {
+		r.Status = StatusSkipped
+		r.Message = "invalid usage config"
+		return r
+	}
+	usageRepo := usage.NewRepositoryWithPolicy(env.DB, policy)
+
+	current, err := usageRepo.GetCurrentCycle()
+	if err != nil {
+		r.Status = StatusFail
+		r.Message = err.Error()
+		return r
+	}
+
+	limit := policy.Limits.MonthlyReads
+	r.Message = fmt.Sprintf("%d/%d tweets read this cycle (%s tier)", current.TweetsRead, limit, policy.Tier)
+
+	if usage.NewGuard(policy, current.TweetsRead, false).Budget() == 0 {
+		r.Status = StatusFail
+		r.Message += ", reserve reached"
+		r.Fix = fmt.Sprintf("Fetching is paused until %s; remove accounts or raise usage.reserve/tier", current.End.Format("Jan 2"))
+		return r
+	}
+	if warning := usageRepo.CheckQuota(); warning != "" {
+		r.Status = StatusWarn
+		for _, w := range strings.Split(warning, "\n") {
+			r.Message += "; " + strings.TrimSpace(strings.TrimPrefix(w, "⚠️"))
+		}
+		r.Fix = "Lower the fetch frequency or mark less important accounts with 'xmon priority <user> low'"
+		return r
+	}
+	if p := usage.Project(current, limit, env.Now); p.ExhaustedAt != nil {
+		r.Status = StatusWarn
+		r.Message += fmt.Sprintf(", projected to run out %s", p.ExhaustedAt.Format("Jan 2"))
+		r.Fix = "See 'xmon usage'; lower the fetch frequency or the number of accounts"
+		return r
+	}
+	return r
+}
+
+func checkLLM(env Env) Result {
+	r := Result{Name: "llm"}
+	if env.Config == nil {
+		r.Status = StatusSkipped
+		return r
+	}
+	if env.LLM == nil {
+		r.Status = StatusSkipped
+		r.Message = "not checked (offline)"
+		return r
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	models, err := env.LLM.Models(ctx)
+	if err != nil {
+		r.Status = StatusWarn
+		r.Message = fmt.Sprintf("%s unreachable: %v", env.Config.APIs.LLMBaseURL, err)
+		r.Fix = "Start Ollama with 'ollama serve' or set apis.llm_base_url; digests work without it"
+		return r
+	}
+
+	model := env.Config.APIs.LLMModel
+	for _, m := range models {
+		if m == model || strings.TrimSuffix(m, ":latest") == model {
+			r.Message = fmt.Sprintf("%s reachable, model %s available", env.Config.APIs.LLMBaseURL, model)
+			return r
+		}
+	}
+	r.Status = StatusWarn
+	r.Message = fmt.Sprintf("model %s not found on %s", model, env.Config.APIs.LLMBaseURL)
+	r.Fix = fmt.Sprintf("Run 'ollama pull %s'", model)
+	return r
+}
@@ -0,0 +1,173 @@
+package doctor
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jpequegn/xmon/internal/config"
+	"github.com/jpequegn/xmon/internal/database"
+	"github.com/jpequegn/xmon/internal/llm"
+	"github.com/jpequegn/xmon/internal/usage"
+	"github.com/jpequegn/xmon/internal/x"
+)
+
+func setupTestDB(t *testing.T) *database.DB {
+	tmpfile, err := os.CreateTemp("", "xmon-doctor-test-*.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpfile.Close()
+	t.Cleanup(func() { os.Remove(tmpfile.Name()) })
+
+	db, err := database.New(tmpfile.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// fakeX answers user lookups with the given status
+func fakeX(t *testing.T, status int) *x.Client {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer test-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(status)
+		w.Write([]byte(`{"data":{"id":"1","username":"X"}}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	client := x.NewClient("test-token")
+	client.SetBaseURL(srv.URL)
+	return client
+}
+
+func fakeOllama(t *testing.T, models string) *llm.Client {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"models":[` + models + `]}`))
+	}))
+	t.Cleanup(srv.Close)
+	return llm.NewClient(srv.URL, "llama3.2")
+}
+
+func find(t *testing.T, results []Result, name string) Result {
+	t.Helper()
+	for _, r := range results {
+		if r.Name == name {
+			return r
+		}
+	}
+	t.Fatalf("no %q check in results", name)
+	return Result{}
+}
+
+func healthyEnv(t *testing.T) Env {
+	cfg := config.DefaultConfig()
+	cfg.X.BearerToken = "test-token"
+	return Env{
+		Config: cfg,
+		DB:     setupTestDB(t),
+		X:      fakeX(t, http.StatusOK),
+		LLM:    fakeOllama(t, `{"name":"llama3.2:latest"}`),
+	}
+}
+
+func TestRunHealthy(t *testing.T) {
+	results := Run(healthyEnv(t))
+	for _, r := range results {
+		if r.Status != StatusOK {
+			t.Errorf("expected %s ok, got %s: %s", r.Name, r.Status, r.Message)
+		}
+	}
+	if Failed(results, true) {
+		t.Error("healthy env must not fail")
+	}
+}
+
+func TestTokenRejected(t *testing.T) {
+	env := healthyEnv(t)
+	env.X = fakeX(t, http.StatusForbidden)
+
+	results := Run(env)
+	if r := find(t, results, "x token"); r.Status != StatusFail || r.Fix == "" {
+		t.Errorf("expected token failure with a fix, got %+v", r)
+	}
+	if !Failed(results, false) {
+		t.Error("expected run to fail")
+	}
+
+	env.Config.X.BearerToken = ""
+	if r := find(t, Run(env), "x token"); r.Status != StatusFail {
+		t.Errorf("expected missing token to fail, got %+v", r)
+	}
+}
+
+func TestInvalidConfig(t *testing.T) {
+	env := healthyEnv(t)
+	env.Config.Usage.CycleStartDay = 31
+
+	if r := find(t, Run(env), "config"); r.Status != StatusFail {
+		t.Errorf("expected invalid cycle_start_day to fail, got %+v", r)
+	}
+}
+
+func TestAccountChecks(t *testing.T) {
+	env := healthyEnv(t)
+	env.Now = time.Now()
+	env.DB.Exec(`INSERT INTO accounts (user_id, username) VALUES ('1', 'never')`)
+	env.DB.Exec(`INSERT INTO accounts (user_id, username, last_fetched) VALUES ('2', 'stale', ?)`, env.Now.AddDate(0, 0, -10).UTC())
+
+	results := Run(env)
+	if r := find(t, results, "never fetched"); r.Status != StatusWarn {
+		t.Errorf("expected never-fetched warning, got %+v", r)
+	}
+	if r := find(t, results, "stale accounts"); r.Status != StatusWarn {
+		t.Errorf("expected stale warning, got %+v", r)
+	}
+	if Failed(results, false) {
+		t.Error("warnings alone must not fail without strict")
+	}
+	if !Failed(results, true) {
+		t.Error("warnings must fail with strict")
+	}
+}
+
+func TestQuotaWarningNamesTheLimit(t *testing.T) {
+	env := healthyEnv(t)
+	env.Config.Usage.MonthlyReads = 100
+	usage.NewRepository(env.DB).AddTweetsRead(80)
+
+	r := find(t, Run(env), "api quota")
+	if r.Status != StatusWarn || !strings.Contains(r.Message, "API quota warning: 80/100 tweets read") {
+		t.Errorf("expected the quota warning in the message, got %+v", r)
+	}
+}
+
+func TestLLMChecks(t *testing.T) {
+	env := healthyEnv(t)
+	env.LLM = fakeOllama(t, `{"name":"mistral:latest"}`)
+	if r := find(t, Run(env), "llm"); r.Status != StatusWarn {
+		t.Errorf("expected missing model warning, got %+v", r)
+	}
+
+	env.LLM = llm.NewClient("http://127.0.0.1:1", "llama3.2")
+	if r := find(t, Run(env), "llm"); r.Status != StatusWarn {
+		t.Errorf("expected unreachable warning, got %+v", r)
+	}
+}
+
+func TestMissingDatabase(t *testing.T) {
+	env := healthyEnv(t)
+	env.DB = nil
+	env.DBErr = os.ErrNotExist
+
+	if r := find(t, Run(env), "database integrity"); r.Status != StatusFail {
+		t.Errorf("expected missing database to fail, got %+v", r)
+	}
+}
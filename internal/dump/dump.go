@@ -0,0 +1,495 @@
+// Package dump writes and loads a portable JSONL copy of the dataset.
+//
+// A dump is one JSON object per line. The first line is a header carrying
+// the format version; every following line has a "type" and refers to
+// accounts by their X user_id, never by local row ids, so a dump can be
+// merged into any database.
+package dump
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/jpequegn/xmon/internal/database"
+)
+
+// Format identifies xmon dumps in the header
+const Format = "xmon-dump"
+
+// FormatVersion is bumped when a record changes incompatibly
+const FormatVersion = 1
+
+// Record types
+const (
+	TypeHeader        = "header"
+	TypeAccount       = "account"
+	TypeTweet         = "tweet"
+	TypeReferenced    = "referenced_tweet"
+	TypeAccountDaily  = "account_daily"
+	TypeUsageCycle    = "usage_cycle"
+	TypeUsageDay      = "usage_day"
+	TypeUsageEndpoint = "usage_endpoint"
+	TypeFetchRun      = "fetch_run"
+)
+
+// ErrFormatTooNew is returned when a dump was written by a newer xmon
+var ErrFormatTooNew = errors.New("dump format is newer than this version of xmon")
+
+type Header struct {
+	Type      string    `json:"type"`
+	Format    string    `json:"format"`
+	Version   int       `json:"version"`
+	Schema    int       `json:"schema"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Account struct {
+	Type        string     `json:"type"`
+	UserID      string     `json:"user_id"`
+	Username    string     `json:"username"`
+	Name        string     `json:"name,omitempty"`
+	Bio         string     `json:"bio,omitempty"`
+	Followers   int        `json:"followers,omitempty"`
+	Priority    string     `json:"priority,omitempty"`
+	SinceID     string     `json:"since_id,omitempty"`
+	AddedAt     *time.Time `json:"added_at,omitempty"`
+	LastFetched *time.Time `json:"last_fetched,omitempty"`
+	ArchivedAt  *time.Time `json:"archived_at,omitempty"`
+}
+
+type Tweet struct {
+	Type              string     `json:"type"`
+	TweetID           string     `json:"tweet_id"`
+	UserID            string     `json:"user_id"`
+	TweetType         string     `json:"tweet_type"`
+	Content           string     `json:"content,omitempty"`
+	ReferencedUser    string     `json:"referenced_user,omitempty"`
+	ReferencedTweetID string     `json:"referenced_tweet_id,omitempty"`
+	Likes             int        `json:"likes"`
+	Retweets          int        `json:"retweets"`
+	CreatedAt         *time.Time `json:"created_at,omitempty"`
+	FetchedAt         *time.Time `json:"fetched_at,omitempty"`
+	StarredAt         *time.Time `json:"starred_at,omitempty"`
+	Lang              string     `json:"lang,omitempty"`
+}
+
+// Referenced is a post that tracked accounts retweeted or quoted. Tweet
+// records point to it through referenced_tweet_id.
+type Referenced struct {
+	Type           string     `json:"type"`
+	TweetID        string     `json:"tweet_id"`
+	AuthorID       string     `json:"author_id,omitempty"`
+	AuthorUsername string     `json:"author_username,omitempty"`
+	Content        string     `json:"content,omitempty"`
+	Likes          int        `json:"likes"`
+	Retweets       int        `json:"retweets"`
+	CreatedAt      *time.Time `json:"created_at,omitempty"`
+}
+
+type AccountDaily struct {
+	Type             string         `json:"type"`
+	Day              string         `json:"day"`
+	UserID           string         `json:"user_id"`
+	Originals        int            `json:"originals"`
+	Retweets         int            `json:"retweets"`
+	Quotes           int            `json:"quotes"`
+	LikesReceived    int            `json:"likes_received"`
+	RetweetsReceived int            `json:"retweets_received"`
+	Topics           map[string]int `json:"topics,omitempty"`
+	Amplified        map[string]int `json:"amplified,omitempty"`
+	PrunedAt         *time.Time     `json:"pruned_at,omitempty"`
+}
+
+type UsageCycle struct {
+	Type       string `json:"type"`
+	Cycle      string `json:"cycle"`
+	TweetsRead int    `json:"tweets_read"`
+}
+
+type UsageDay struct {
+	Type       string `json:"type"`
+	Day        string `json:"day"`
+	TweetsRead int    `json:"tweets_read"`
+	Requests   int    `json:"requests"`
+}
+
+type UsageEndpoint struct {
+	Type     string `json:"type"`
+	Cycle    string `json:"cycle"`
+	Endpoint string `json:"endpoint"`
+	Requests int    `json:"requests"`
+}
+
+type FetchRun struct {
+	Type               string            `json:"type"`
+	StartedAt          time.Time         `json:"started_at"`
+	FinishedAt         *time.Time        `json:"finished_at,omitempty"`
+	Status             string            `json:"status"`
+	NewTweets          int               `json:"new_tweets"`
+	TweetsRead         int               `json:"tweets_read"`
+	Errors             int               `json:"errors"`
+	Skipped            int               `json:"skipped"`
+	RateLimitRemaining *int              `json:"rate_limit_remaining,omitempty"`
+	RateLimitReset     *time.Time        `json:"rate_limit_reset,omitempty"`
+	Error              string            `json:"error,omitempty"`
+	Accounts           []FetchRunAccount `json:"accounts,omitempty"`
+}
+
+type FetchRunAccount struct {
+	UserID     string `json:"user_id"`
+	Username   string `json:"username"`
+	NewTweets  int    `json:"new_tweets"`
+	TweetsRead int    `json:"tweets_read"`
+	HTTPStatus int    `json:"http_status,omitempty"`
+	Error      string `json:"error,omitempty"`
+	Skipped    string `json:"skipped,omitempty"`
+}
+
+// Options select what goes into a dump
+type Options struct {
+	Usernames []string // only these accounts and their tweets; empty means all
+	NoUsage   bool     // leave out API usage counters
+	NoHistory bool     // leave out fetch history
+}
+
+// Counts is the number of records written or loaded per type
+type Counts map[string]int
+
+// Write streams the dataset to w as JSONL. Everything is read inside one
+// transaction, so the dump is a consistent snapshot even while the daemon
+// is fetching.
+func Write(w io.Writer, db *database.DB, opts Options) (Counts, error) {
+	schema, err := db.SchemaVersion()
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	d := &dumper{tx: tx, enc: json.NewEncoder(w), counts: Counts{}}
+	d.enc.SetEscapeHTML(false)
+
+	if err := d.enc.Encode(Header{
+		Type:      TypeHeader,
+		Format:    Format,
+		Version:   FormatVersion,
+		Schema:    schema,
+		CreatedAt: time.Now().UTC(),
+	}); err != nil {
+		return nil, err
+	}
+
+	filter, args := accountFilter(opts.Usernames)
+	steps := []func(string, []any) error{d.accounts, d.referenced, d.tweets, d.daily}
+	if !opts.NoUsage {
+		steps = append(steps, d.usage)
+	}
+	if !opts.NoHistory {
+		steps = append(steps, d.history)
+	}
+	for _, step := range steps {
+		if err := step(filter, args); err != nil {
+			return d.counts, err
+		}
+	}
+	return d.counts, nil
+}
+
+// accountFilter returns a condition on the accounts table aliased a
+func accountFilter(usernames []string) (string, []any) {
+	if len(usernames) == 0 {
+		return "1", nil
+	}
+	args := make([]any, len(usernames))
+	for i, u := range usernames {
+		args[i] = strings.ToLower(strings.TrimPrefix(u, "@"))
+	}
+	return "lower(a.username) IN (?" + strings.Repeat(", ?", len(usernames)-1) + ")", args
+}
+
+type dumper struct {
+	tx     *sql.Tx
+	enc    *json.Encoder
+	counts Counts
+}
+
+func (d *dumper) emit(kind string, v any) error {
+	if err := d.enc.Encode(v); err != nil {
+		return err
+	}
+	d.counts[kind]++
+	return nil
+}
+
+func (d *dumper) accounts(filter string, args []any) error {
+	rows, err := d.tx.Query(`
+		SELECT a.user_id, a.username, COALESCE(a.name, ''), COALESCE(a.bio, ''), COALESCE(a.followers, 0),
+			COALESCE(a.priority, ''), COALESCE(a.since_id, ''), a.added_at, a.last_fetched, a.archived_at
+		FROM accounts a
+		WHERE `+filter+`
+		ORDER BY a.id
+	`, args...)
+	if err != nil {
+		return fmt.Errorf("failed to read accounts: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		rec := Account{Type: TypeAccount}
+		var added, fetched, archived sql.NullTime
+		if err := rows.Scan(&rec.UserID, &rec.Username, &rec.Name, &rec.Bio, &rec.Followers,
+			&rec.Priority, &rec.SinceID, &added, &fetched, &archived); err != nil {
+			return err
+		}
+		rec.AddedAt, rec.LastFetched, rec.ArchivedAt = timePtr(added), timePtr(fetched), timePtr(archived)
+		if err := d.emit(TypeAccount, rec); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (d *dumper) tweets(filter string, args []any) error {
+	rows, err := d.tx.Query(`
+		SELECT t.tweet_id, a.user_id, t.tweet_type, COALESCE(t.content, ''), COALESCE(t.referenced_user, ''),
+			COALESCE(t.referenced_tweet_id, ''), COALESCE(t.likes, 0), COALESCE(t.retweets, 0),
+			t.created_at, t.fetched_at, t.starred_at, t.lang
+		FROM tweets t
+		JOIN accounts a ON a.id = t.account_id
+		WHERE `+filter+`
+		ORDER BY t.id
+	`, args...)
+	if err != nil {
+		return fmt.Errorf("failed to read tweets: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		rec := Tweet{Type: TypeTweet}
+		var created, fetched, starred sql.NullTime
+		if err := rows.Scan(&rec.TweetID, &rec.UserID, &rec.TweetType, &rec.Content, &rec.ReferencedUser,
+			&rec.ReferencedTweetID, &rec.Likes, &rec.Retweets, &created, &fetched, &starred, &rec.Lang); err != nil {
+			return err
+		}
+		rec.CreatedAt, rec.FetchedAt, rec.StarredAt = timePtr(created), timePtr(fetched), timePtr(starred)
+		if err := d.emit(TypeTweet, rec); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// referenced writes the posts linked from the dumped tweets, before the
+// tweets so loading can attach them
+func (d *dumper) referenced(filter string, args []any) error {
+	rows, err := d.tx.Query(`
+		SELECT rt.tweet_id, rt.author_id, rt.author_username, rt.content, COALESCE(rt.likes, 0), COALESCE(rt.retweets, 0), rt.created_at
+		FROM referenced_tweets rt
+		WHERE EXISTS (
+			SELECT 1 FROM tweet_references tr
+			JOIN tweets t ON t.tweet_id = tr.tweet_id
+			JOIN accounts a ON a.id = t.account_id
+			WHERE tr.referenced_tweet_id = rt.tweet_id AND `+filter+`
+		)
+		ORDER BY rt.tweet_id
+	`, args...)
+	if err != nil {
+		return fmt.Errorf("failed to read referenced tweets: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		rec := Referenced{Type: TypeReferenced}
+		var created sql.NullTime
+		if err := rows.Scan(&rec.TweetID, &rec.AuthorID, &rec.AuthorUsername, &rec.Content, &rec.Likes, &rec.Retweets, &created); err != nil {
+			return err
+		}
+		rec.CreatedAt = timePtr(created)
+		if err := d.emit(TypeReferenced, rec); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (d *dumper) daily(filter string, args []any) error {
+	rows, err := d.tx.Query(`
+		SELECT ad.day, a.user_id, ad.originals, ad.retweets, ad.quotes, ad.likes_received, ad.retweets_received, ad.pruned_at,
+			(SELECT json_group_object(r.topic, r.count) FROM account_daily_topics r WHERE r.day = ad.day AND r.account_id = ad.account_id),
+			(SELECT json_group_object(r.username, r.count) FROM account_daily_amplified r WHERE r.day = ad.day AND r.account_id = ad.account_id)
+		FROM account_daily ad
+		JOIN accounts a ON a.id = ad.account_id
+		WHERE `+filter+`
+		ORDER BY ad.day, a.user_id
+	`, args...)
+	if err != nil {
+		return fmt.Errorf("failed to read daily aggregates: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		rec := AccountDaily{Type: TypeAccountDaily}
+		var pruned sql.NullTime
+		var topics, amplified string
+		if err := rows.Scan(&rec.Day, &rec.UserID, &rec.Originals, &rec.Retweets, &rec.Quotes,
+			&rec.LikesReceived, &rec.RetweetsReceived, &pruned, &topics, &amplified); err != nil {
+			return err
+		}
+		rec.PrunedAt = timePtr(pruned)
+		if err := json.Unmarshal([]byte(topics), &rec.Topics); err != nil {
+			return fmt.Errorf("failed to read topics for %s: %w", rec.Day, err)
+		}
+		if err := json.Unmarshal([]byte(amplified), &rec.Amplified); err != nil {
+			return fmt.Errorf("failed to read amplified users for %s: %w", rec.Day, err)
+		}
+		if err := d.emit(TypeAccountDaily, rec); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (d *dumper) usage(string, []any) error {
+	rows, err := d.tx.Query(`SELECT month, COALESCE(tweets_read, 0) FROM api_usage ORDER BY month`)
+	if err != nil {
+		return fmt.Errorf("failed to read usage: %w", err)
+	}
+	for rows.Next() {
+		rec := UsageCycle{Type: TypeUsageCycle}
+		if err := rows.Scan(&rec.Cycle, &rec.TweetsRead); err != nil {
+			rows.Close()
+			return err
+		}
+		if err := d.emit(TypeUsageCycle, rec); err != nil {
+			rows.Close()
+			return err
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	rows, err = d.tx.Query(`SELECT day, COALESCE(tweets_read, 0), COALESCE(requests, 0) FROM api_usage_daily ORDER BY day`)
+	if err != nil {
+		return fmt.Errorf("failed to read daily usage: %w", err)
+	}
+	for rows.Next() {
+		rec := UsageDay{Type: TypeUsageDay}
+		if err := rows.Scan(&rec.Day, &rec.TweetsRead, &rec.Requests); err != nil {
+			rows.Close()
+			return err
+		}
+		if err := d.emit(TypeUsageDay, rec); err != nil {
+			rows.Close()
+			return err
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	rows, err = d.tx.Query(`SELECT cycle, endpoint, COALESCE(requests, 0) FROM api_requests ORDER BY cycle, endpoint`)
+	if err != nil {
+		return fmt.Errorf("failed to read endpoint usage: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		rec := UsageEndpoint{Type: TypeUsageEndpoint}
+		if err := rows.Scan(&rec.Cycle, &rec.Endpoint, &rec.Requests); err != nil {
+			return err
+		}
+		if err := d.emit(TypeUsageEndpoint, rec); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// history writes finished fetch runs with their per-account results nested.
+// Runs still in progress are left out.
+func (d *dumper) history(filter string, args []any) error {
+	rows, err := d.tx.Query(`
+		SELECT r.id, r.started_at, r.finished_at, r.status, COALESCE(r.new_tweets, 0), COALESCE(r.tweets_read, 0),
+			COALESCE(r.errors, 0), COALESCE(r.skipped, 0), r.rate_limit_remaining, r.rate_limit_reset, COALESCE(r.error, ''),
+			a.user_id, ra.username, ra.new_tweets, ra.tweets_read, ra.http_status, ra.error, ra.skipped
+		FROM fetch_runs r
+		LEFT JOIN fetch_run_accounts ra ON ra.run_id = r.id
+		LEFT JOIN accounts a ON a.id = ra.account_id AND `+filter+`
+		WHERE r.finished_at IS NOT NULL
+		ORDER BY r.id, ra.id
+	`, args...)
+	if err != nil {
+		return fmt.Errorf("failed to read fetch history: %w", err)
+	}
+	defer rows.Close()
+
+	var run *FetchRun
+	var runID int64
+	for rows.Next() {
+		var id int64
+		rec := FetchRun{Type: TypeFetchRun}
+		var finished, reset sql.NullTime
+		var remaining sql.NullInt64
+		var userID, username, errMsg, skipped sql.NullString
+		var newTweets, read, status sql.NullInt64
+		if err := rows.Scan(&id, &rec.StartedAt, &finished, &rec.Status, &rec.NewTweets, &rec.TweetsRead,
+			&rec.Errors, &rec.Skipped, &remaining, &reset, &rec.Error,
+			&userID, &username, &newTweets, &read, &status, &errMsg, &skipped); err != nil {
+			return err
+		}
+
+		if run == nil || id != runID {
+			if run != nil {
+				if err := d.emit(TypeFetchRun, run); err != nil {
+					return err
+				}
+			}
+			rec.StartedAt = rec.StartedAt.UTC()
+			rec.FinishedAt, rec.RateLimitReset = timePtr(finished), timePtr(reset)
+			if remaining.Valid {
+				n := int(remaining.Int64)
+				rec.RateLimitRemaining = &n
+			}
+			run, runID = &rec, id
+		}
+
+		// Accounts outside the filter, or since purged, have no user_id
+		if userID.Valid {
+			run.Accounts = append(run.Accounts, FetchRunAccount{
+				UserID:     userID.String,
+				Username:   username.String,
+				NewTweets:  int(newTweets.Int64),
+				TweetsRead: int(read.Int64),
+				HTTPStatus: int(status.Int64),
+				Error:      errMsg.String,
+				Skipped:    skipped.String,
+			})
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if run != nil {
+		return d.emit(TypeFetchRun, run)
+	}
+	return nil
+}
+
+func timePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	u := t.Time.UTC()
+	return &u
+}
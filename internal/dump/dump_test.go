@@ -0,0 +1,251 @@
+package dump
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jpequegn/xmon/internal/database"
+)
+
+func setupTestDB(t *testing.T) *database.DB {
+	t.Helper()
+	tmpfile, err := os.CreateTemp("", "xmon-dump-test-*.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpfile.Close()
+
+	db, err := database.New(tmpfile.Name())
+	if err != nil {
+		os.Remove(tmpfile.Name())
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+		os.Remove(tmpfile.Name())
+	})
+	return db
+}
+
+func seed(t *testing.T, db *database.DB) {
+	t.Helper()
+	created := time.Date(2025, 10, 1, 12, 0, 0, 0, time.UTC)
+	started := time.Date(2025, 10, 1, 13, 0, 0, 0, time.UTC)
+	_, err := db.Exec(`
+		INSERT INTO accounts (id, user_id, username, name, followers, last_fetched) VALUES (1, '111', 'alice', 'Alice', 10, ?);
+		INSERT INTO accounts (id, user_id, username) VALUES (2, '222', 'bob');
+		INSERT INTO tweets (account_id, tweet_id, tweet_type, content, likes, retweets, created_at, starred_at) VALUES (1, '1001', 'original', 'hello', 5, 1, ?, ?);
+		INSERT INTO tweets (account_id, tweet_id, tweet_type, content, referenced_user, referenced_tweet_id, created_at) VALUES (2, '1002', 'retweet', 'RT', 'carol', '900', ?);
+		INSERT INTO referenced_tweets (tweet_id, author_username, content) VALUES ('900', 'carol', 'the original post');
+		INSERT INTO tweet_references (tweet_id, referenced_tweet_id, kind) VALUES ('1002', '900', 'retweet');
+		INSERT INTO account_daily (day, account_id, originals, pruned_at) VALUES ('2025-01-01', 1, 3, '2025-02-01 00:00:00');
+		INSERT INTO account_daily_topics (day, account_id, topic, count) VALUES ('2025-01-01', 1, '#history', 2);
+		INSERT INTO api_usage (month, tweets_read) VALUES ('2025-10', 40);
+		INSERT INTO api_usage_daily (day, tweets_read, requests) VALUES ('2025-10-01', 40, 2);
+		INSERT INTO api_requests (cycle, endpoint, requests) VALUES ('2025-10', 'user_tweets', 2);
+		INSERT INTO fetch_runs (id, started_at, finished_at, status, new_tweets) VALUES (1, ?, ?, 'success', 2);
+		INSERT INTO fetch_run_accounts (run_id, account_id, username, new_tweets) VALUES (1, 1, 'alice', 1), (1, 2, 'bob', 1);
+		INSERT INTO fetch_runs (started_at, status) VALUES (?, 'running');
+	`, started, created, created, created, started, started.Add(time.Minute), started.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func count(t *testing.T, db *database.DB, query string) int {
+	t.Helper()
+	var n int
+	if err := db.QueryRow(query).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestRoundTrip(t *testing.T) {
+	src := setupTestDB(t)
+	seed(t, src)
+
+	var buf bytes.Buffer
+	counts, err := Write(&buf, src, Options{})
+	if err != nil {
+		t.Fatalf("dump failed: %v", err)
+	}
+	if counts[TypeAccount] != 2 || counts[TypeTweet] != 2 || counts[TypeFetchRun] != 1 {
+		t.Errorf("unexpected counts %v", counts)
+	}
+	if lines := strings.Count(buf.String(), "\n"); lines != 11 {
+		t.Errorf("expected header and 10 records, got %d lines", lines)
+	}
+
+	dst := setupTestDB(t)
+	// Local ids differ from the source, so tweets must be matched by user_id
+	if _, err := dst.Exec(`INSERT INTO accounts (id, user_id, username) VALUES (7, '222', 'bob')`); err != nil {
+		t.Fatal(err)
+	}
+
+	stats, err := Load(bytes.NewReader(buf.Bytes()), dst, LoadOptions{})
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if stats[TypeAccount].Added != 1 || stats[TypeAccount].Merged != 1 {
+		t.Errorf("expected one new and one merged account, got %+v", *stats[TypeAccount])
+	}
+
+	var owner string
+	dst.QueryRow(`SELECT a.username FROM tweets t JOIN accounts a ON a.id = t.account_id WHERE t.tweet_id = '1002'`).Scan(&owner)
+	if owner != "bob" {
+		t.Errorf("expected tweet 1002 to belong to bob, got %q", owner)
+	}
+	if n := count(t, dst, `SELECT COUNT(*) FROM tweets WHERE starred_at IS NOT NULL`); n != 1 {
+		t.Errorf("expected star to survive, got %d starred", n)
+	}
+	if n := count(t, dst, `SELECT COUNT(*) FROM tweet_references tr JOIN referenced_tweets rt ON rt.tweet_id = tr.referenced_tweet_id WHERE rt.content = 'the original post'`); n != 1 {
+		t.Errorf("expected retweet to be linked to its post, got %d links", n)
+	}
+	if n := count(t, dst, `SELECT COUNT(*) FROM fetch_run_accounts`); n != 2 {
+		t.Errorf("expected 2 fetch run accounts, got %d", n)
+	}
+	if n := count(t, dst, `SELECT COUNT(*) FROM account_daily_topics WHERE day = '2025-01-01' AND topic = '#history'`); n != 1 {
+		t.Errorf("expected topics of pruned days to be carried over, got %d", n)
+	}
+	if n := count(t, dst, `SELECT COUNT(*) FROM account_daily WHERE day = '2025-10-01' AND pruned_at IS NULL`); n != 2 {
+		t.Errorf("expected loaded tweets to be rolled up per account, got %d days", n)
+	}
+	if n := count(t, dst, `SELECT COUNT(*) FROM tweets_fts WHERE tweets_fts MATCH 'hello'`); n != 1 {
+		t.Errorf("expected loaded tweets to be searchable, got %d", n)
+	}
+}
+
+func TestLoadIsIdempotent(t *testing.T) {
+	src := setupTestDB(t)
+	seed(t, src)
+
+	var buf bytes.Buffer
+	if _, err := Write(&buf, src, Options{}); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := setupTestDB(t)
+	if _, err := Load(bytes.NewReader(buf.Bytes()), dst, LoadOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	stats, err := Load(bytes.NewReader(buf.Bytes()), dst, LoadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for kind, tally := range stats {
+		if tally.Added != 0 {
+			t.Errorf("expected nothing new on second load, got %d %s", tally.Added, kind)
+		}
+	}
+	if n := count(t, dst, `SELECT COUNT(*) FROM tweets`); n != 2 {
+		t.Errorf("expected 2 tweets, got %d", n)
+	}
+	if n := count(t, dst, `SELECT COUNT(*) FROM fetch_runs`); n != 1 {
+		t.Errorf("expected 1 fetch run, got %d", n)
+	}
+	if n := count(t, dst, `SELECT tweets_read FROM api_usage WHERE month = '2025-10'`); n != 40 {
+		t.Errorf("expected usage not to be added twice, got %d", n)
+	}
+}
+
+func TestLoadKeepsLargerCounters(t *testing.T) {
+	src := setupTestDB(t)
+	seed(t, src)
+
+	var buf bytes.Buffer
+	if _, err := Write(&buf, src, Options{}); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := setupTestDB(t)
+	_, err := dst.Exec(`
+		INSERT INTO accounts (id, user_id, username, name, last_fetched) VALUES (1, '111', 'alice_old', 'Old', '2025-09-01 00:00:00');
+		INSERT INTO tweets (account_id, tweet_id, tweet_type, likes, retweets) VALUES (1, '1001', 'original', 9, 0);
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(bytes.NewReader(buf.Bytes()), dst, LoadOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	var likes, retweets int
+	dst.QueryRow(`SELECT likes, retweets FROM tweets WHERE tweet_id = '1001'`).Scan(&likes, &retweets)
+	if likes != 9 || retweets != 1 {
+		t.Errorf("expected likes 9 and retweets 1, got %d and %d", likes, retweets)
+	}
+
+	var username string
+	dst.QueryRow(`SELECT username FROM accounts WHERE user_id = '111'`).Scan(&username)
+	if username != "alice" {
+		t.Errorf("expected the fresher profile to win, got @%s", username)
+	}
+}
+
+func TestWriteFiltersAccounts(t *testing.T) {
+	src := setupTestDB(t)
+	seed(t, src)
+
+	var buf bytes.Buffer
+	counts, err := Write(&buf, src, Options{Usernames: []string{"@Alice"}, NoUsage: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if counts[TypeAccount] != 1 || counts[TypeTweet] != 1 || counts[TypeUsageCycle] != 0 {
+		t.Errorf("unexpected counts %v", counts)
+	}
+	if strings.Contains(buf.String(), `"bob"`) {
+		t.Error("expected bob to be left out of the dump")
+	}
+}
+
+func TestLoadOptions(t *testing.T) {
+	src := setupTestDB(t)
+	seed(t, src)
+
+	var buf bytes.Buffer
+	if _, err := Write(&buf, src, Options{}); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := setupTestDB(t)
+	if _, err := Load(bytes.NewReader(buf.Bytes()), dst, LoadOptions{DryRun: true}); err != nil {
+		t.Fatal(err)
+	}
+	if n := count(t, dst, `SELECT COUNT(*) FROM tweets`); n != 0 {
+		t.Errorf("expected dry run to change nothing, got %d tweets", n)
+	}
+
+	if _, err := Load(bytes.NewReader(buf.Bytes()), dst, LoadOptions{ArchiveNew: true}); err != nil {
+		t.Fatal(err)
+	}
+	if n := count(t, dst, `SELECT COUNT(*) FROM accounts WHERE archived_at IS NULL`); n != 0 {
+		t.Errorf("expected new accounts to be archived, got %d active", n)
+	}
+}
+
+func TestLoadRejectsBadInput(t *testing.T) {
+	db := setupTestDB(t)
+
+	_, err := Load(strings.NewReader(`{"type":"header","format":"xmon-dump","version":99}`+"\n"), db, LoadOptions{})
+	if !errors.Is(err, ErrFormatTooNew) {
+		t.Errorf("expected ErrFormatTooNew, got %v", err)
+	}
+
+	if _, err := Load(strings.NewReader(`{"type":"tweet"}`+"\n"), db, LoadOptions{}); err == nil {
+		t.Error("expected dump without header to be rejected")
+	}
+
+	orphan := `{"type":"header","format":"xmon-dump","version":1}
+{"type":"tweet","tweet_id":"1","user_id":"404","tweet_type":"original"}
+`
+	if _, err := Load(strings.NewReader(orphan), db, LoadOptions{}); err == nil {
+		t.Error("expected tweet for unknown account to be rejected")
+	}
+}
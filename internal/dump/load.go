@@ -0,0 +1,437 @@
+package dump
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/jpequegn/xmon/internal/database"
+	"github.com/jpequegn/xmon/internal/rollup"
+	"github.com/jpequegn/xmon/internal/tweet"
+)
+
+// LoadOptions control how a dump is merged
+type LoadOptions struct {
+	DryRun     bool // merge inside a transaction and roll it back
+	ArchiveNew bool // add accounts that are not already known as archived, so fetch does not poll them
+}
+
+// Tally counts what loading did with one record type
+type Tally struct {
+	Added   int // not present before
+	Merged  int // already present, merged into the existing row
+	Skipped int // unknown type or nothing to attach to
+}
+
+// Stats is the Tally per record type
+type Stats map[string]*Tally
+
+func (s Stats) tally(kind string) *Tally {
+	if s[kind] == nil {
+		s[kind] = &Tally{}
+	}
+	return s[kind]
+}
+
+// Load merges a dump into db. Accounts are matched on user_id and tweets on
+// tweet_id; counters only ever grow to the larger of both sides, so loading
+// the same dump twice changes nothing the second time. Everything is applied
+// in one transaction.
+func Load(r io.Reader, db *database.DB, opts LoadOptions) (Stats, error) {
+	dec := json.NewDecoder(r)
+
+	var header Header
+	if err := dec.Decode(&header); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("empty dump")
+		}
+		return nil, fmt.Errorf("failed to read dump header: %w", err)
+	}
+	if header.Type != TypeHeader || header.Format != Format {
+		return nil, fmt.Errorf("not an xmon dump (missing header)")
+	}
+	if header.Version > FormatVersion {
+		return nil, fmt.Errorf("%w: version %d, this xmon reads up to %d", ErrFormatTooNew, header.Version, FormatVersion)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	l := &loader{tx: tx, tweets: tweet.NewRepository(db), rollups: rollup.NewRepository(db), opts: opts,
+		stats: Stats{}, accounts: map[string]int64{}, now: time.Now().UTC()}
+	for line := 2; ; line++ {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return l.stats, fmt.Errorf("record %d: %w", line, err)
+		}
+		if err := l.record(raw); err != nil {
+			return l.stats, fmt.Errorf("record %d: %w", line, err)
+		}
+	}
+
+	// Daily records carry what was pruned on the other side; the days of
+	// loaded tweets are then recomputed on top of them
+	if err := l.rollups.RefreshTweetsTx(tx, l.loaded); err != nil {
+		return l.stats, fmt.Errorf("failed to update daily rollups: %w", err)
+	}
+
+	if opts.DryRun {
+		return l.stats, nil
+	}
+	if err := tx.Commit(); err != nil {
+		return l.stats, fmt.Errorf("failed to commit load: %w", err)
+	}
+	return l.stats, nil
+}
+
+type loader struct {
+	tx       *sql.Tx
+	tweets   *tweet.Repository
+	rollups  *rollup.Repository
+	opts     LoadOptions
+	stats    Stats
+	accounts map[string]int64 // user_id -> local account id
+	loaded   []tweet.Tweet    // account and day of every tweet loaded
+	now      time.Time
+}
+
+func (l *loader) record(raw json.RawMessage) error {
+	var kind struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(raw, &kind); err != nil {
+		return err
+	}
+
+	decode := func(v any) error { return json.Unmarshal(raw, v) }
+	switch kind.Type {
+	case TypeAccount:
+		var rec Account
+		if err := decode(&rec); err != nil {
+			return err
+		}
+		return l.account(rec)
+	case TypeTweet:
+		var rec Tweet
+		if err := decode(&rec); err != nil {
+			return err
+		}
+		return l.tweet(rec)
+	case TypeReferenced:
+		var rec Referenced
+		if err := decode(&rec); err != nil {
+			return err
+		}
+		found, err := l.exists(`SELECT 1 FROM referenced_tweets WHERE tweet_id = ?`, rec.TweetID)
+		if err != nil {
+			return err
+		}
+		post := tweet.Referenced{
+			TweetID:        rec.TweetID,
+			AuthorID:       rec.AuthorID,
+			AuthorUsername: rec.AuthorUsername,
+			Content:        rec.Content,
+			Likes:          rec.Likes,
+			Retweets:       rec.Retweets,
+		}
+		if rec.CreatedAt != nil {
+			post.CreatedAt = *rec.CreatedAt
+		}
+		if err := l.tweets.UpsertReferencedTx(l.tx, []tweet.Referenced{post}); err != nil {
+			return fmt.Errorf("failed to merge referenced tweet %s: %w", rec.TweetID, err)
+		}
+		if found {
+			l.stats.tally(TypeReferenced).Merged++
+		} else {
+			l.stats.tally(TypeReferenced).Added++
+		}
+		return nil
+	case TypeAccountDaily:
+		var rec AccountDaily
+		if err := decode(&rec); err != nil {
+			return err
+		}
+		return l.daily(rec)
+	case TypeUsageCycle:
+		var rec UsageCycle
+		if err := decode(&rec); err != nil {
+			return err
+		}
+		return l.upsert(TypeUsageCycle, `SELECT 1 FROM api_usage WHERE month = ?`, []any{rec.Cycle}, `
+			INSERT INTO api_usage (month, tweets_read) VALUES (?, ?)
+			ON CONFLICT(month) DO UPDATE SET tweets_read = MAX(api_usage.tweets_read, excluded.tweets_read)
+		`, rec.Cycle, rec.TweetsRead)
+	case TypeUsageDay:
+		var rec UsageDay
+		if err := decode(&rec); err != nil {
+			return err
+		}
+		return l.upsert(TypeUsageDay, `SELECT 1 FROM api_usage_daily WHERE day = ?`, []any{rec.Day}, `
+			INSERT INTO api_usage_daily (day, tweets_read, requests) VALUES (?, ?, ?)
+			ON CONFLICT(day) DO UPDATE SET
+				tweets_read = MAX(api_usage_daily.tweets_read, excluded.tweets_read),
+				requests = MAX(api_usage_daily.requests, excluded.requests)
+		`, rec.Day, rec.TweetsRead, rec.Requests)
+	case TypeUsageEndpoint:
+		var rec UsageEndpoint
+		if err := decode(&rec); err != nil {
+			return err
+		}
+		return l.upsert(TypeUsageEndpoint, `SELECT 1 FROM api_requests WHERE cycle = ? AND endpoint = ?`, []any{rec.Cycle, rec.Endpoint}, `
+			INSERT INTO api_requests (cycle, endpoint, requests) VALUES (?, ?, ?)
+			ON CONFLICT(cycle, endpoint) DO UPDATE SET requests = MAX(api_requests.requests, excluded.requests)
+		`, rec.Cycle, rec.Endpoint, rec.Requests)
+	case TypeFetchRun:
+		var rec FetchRun
+		if err := decode(&rec); err != nil {
+			return err
+		}
+		return l.fetchRun(rec)
+	default:
+		// Records from a newer minor revision of the format
+		l.stats.tally(kind.Type).Skipped++
+		return nil
+	}
+}
+
+// exists runs a SELECT 1 query and reports whether it found a row
+func (l *loader) exists(query string, args ...any) (bool, error) {
+	var one int
+	err := l.tx.QueryRow(query, args...).Scan(&one)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// upsert runs an idempotent insert and tallies whether the row was new
+func (l *loader) upsert(kind, existsQuery string, existsArgs []any, query string, args ...any) error {
+	found, err := l.exists(existsQuery, existsArgs...)
+	if err != nil {
+		return err
+	}
+	if _, err := l.tx.Exec(query, args...); err != nil {
+		return fmt.Errorf("failed to merge %s: %w", kind, err)
+	}
+	if found {
+		l.stats.tally(kind).Merged++
+	} else {
+		l.stats.tally(kind).Added++
+	}
+	return nil
+}
+
+// accountID resolves a user_id to the local account id
+func (l *loader) accountID(userID string) (int64, bool, error) {
+	if id, ok := l.accounts[userID]; ok {
+		return id, true, nil
+	}
+	var id int64
+	err := l.tx.QueryRow(`SELECT id FROM accounts WHERE user_id = ?`, userID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	l.accounts[userID] = id
+	return id, true, nil
+}
+
+// fetchedLater is true in an account upsert when the dump's copy of the
+// profile is fresher than the local one
+const fetchedLater = `(excluded.last_fetched IS NOT NULL AND (accounts.last_fetched IS NULL
+	OR julianday(excluded.last_fetched) > julianday(accounts.last_fetched)))`
+
+// account merges an account. The profile comes from whichever side fetched
+// it last; local priority and archive state are kept.
+func (l *loader) account(rec Account) error {
+	if rec.UserID == "" || rec.Username == "" {
+		return fmt.Errorf("account without user_id or username")
+	}
+	_, found, err := l.accountID(rec.UserID)
+	if err != nil {
+		return err
+	}
+
+	archived := utc(rec.ArchivedAt)
+	if l.opts.ArchiveNew && rec.ArchivedAt == nil {
+		archived = l.now
+	}
+	priority := rec.Priority
+	if priority == "" {
+		priority = "normal"
+	}
+
+	_, err = l.tx.Exec(strings.ReplaceAll(`
+		INSERT INTO accounts (user_id, username, name, bio, followers, priority, since_id, added_at, last_fetched, archived_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, COALESCE(?, CURRENT_TIMESTAMP), ?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET
+			username = CASE WHEN newer THEN excluded.username ELSE accounts.username END,
+			name = CASE WHEN newer THEN excluded.name ELSE accounts.name END,
+			bio = CASE WHEN newer THEN excluded.bio ELSE accounts.bio END,
+			followers = CASE WHEN newer THEN excluded.followers ELSE accounts.followers END,
+			since_id = CASE
+				WHEN length(excluded.since_id) > length(COALESCE(accounts.since_id, ''))
+					OR (length(excluded.since_id) = length(accounts.since_id) AND excluded.since_id > accounts.since_id)
+				THEN excluded.since_id ELSE accounts.since_id END,
+			added_at = CASE WHEN julianday(excluded.added_at) < julianday(accounts.added_at) THEN excluded.added_at ELSE accounts.added_at END,
+			last_fetched = CASE WHEN newer THEN excluded.last_fetched ELSE accounts.last_fetched END
+	`, "newer", fetchedLater), rec.UserID, rec.Username, rec.Name, rec.Bio, rec.Followers, priority, rec.SinceID,
+		utc(rec.AddedAt), utc(rec.LastFetched), archived)
+	if err != nil {
+		return fmt.Errorf("failed to merge account @%s: %w", rec.Username, err)
+	}
+
+	if found {
+		l.stats.tally(TypeAccount).Merged++
+	} else {
+		l.stats.tally(TypeAccount).Added++
+	}
+	return nil
+}
+
+func (l *loader) tweet(rec Tweet) error {
+	accountID, ok, err := l.accountID(rec.UserID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("tweet %s references unknown account %s", rec.TweetID, rec.UserID)
+	}
+
+	err = l.upsert(TypeTweet, `SELECT 1 FROM tweets WHERE tweet_id = ?`, []any{rec.TweetID}, `
+		INSERT INTO tweets (account_id, tweet_id, tweet_type, content, referenced_user, referenced_tweet_id, likes, retweets, created_at, fetched_at, starred_at, lang)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, COALESCE(?, CURRENT_TIMESTAMP), ?, ?)
+		ON CONFLICT(tweet_id) DO UPDATE SET
+			lang = CASE WHEN tweets.lang = '' THEN excluded.lang ELSE tweets.lang END,
+			likes = MAX(tweets.likes, excluded.likes),
+			retweets = MAX(tweets.retweets, excluded.retweets),
+			starred_at = COALESCE(tweets.starred_at, excluded.starred_at)
+	`, accountID, rec.TweetID, rec.TweetType, rec.Content, rec.ReferencedUser, rec.ReferencedTweetID,
+		rec.Likes, rec.Retweets, utc(rec.CreatedAt), utc(rec.FetchedAt), utc(rec.StarredAt), rec.Lang)
+	if err != nil {
+		return err
+	}
+	if rec.CreatedAt != nil {
+		l.loaded = append(l.loaded, tweet.Tweet{AccountID: accountID, CreatedAt: *rec.CreatedAt})
+	}
+
+	// Links are derived from the tweet itself rather than dumped
+	return l.tweets.LinkReferencesTx(l.tx, []tweet.Tweet{{
+		TweetID:           rec.TweetID,
+		TweetType:         rec.TweetType,
+		ReferencedUser:    rec.ReferencedUser,
+		ReferencedTweetID: rec.ReferencedTweetID,
+	}})
+}
+
+func (l *loader) daily(rec AccountDaily) error {
+	accountID, ok, err := l.accountID(rec.UserID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("daily aggregate for %s references unknown account %s", rec.Day, rec.UserID)
+	}
+
+	err = l.upsert(TypeAccountDaily, `SELECT 1 FROM account_daily WHERE day = ? AND account_id = ?`, []any{rec.Day, accountID}, `
+		INSERT INTO account_daily (day, account_id, originals, retweets, quotes, likes_received, retweets_received, pruned_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(day, account_id) DO UPDATE SET
+			originals = MAX(account_daily.originals, excluded.originals),
+			retweets = MAX(account_daily.retweets, excluded.retweets),
+			quotes = MAX(account_daily.quotes, excluded.quotes),
+			likes_received = MAX(account_daily.likes_received, excluded.likes_received),
+			retweets_received = MAX(account_daily.retweets_received, excluded.retweets_received),
+			pruned_at = COALESCE(account_daily.pruned_at, excluded.pruned_at),
+			updated_at = CURRENT_TIMESTAMP
+	`, rec.Day, accountID, rec.Originals, rec.Retweets, rec.Quotes, rec.LikesReceived, rec.RetweetsReceived, utc(rec.PrunedAt))
+	if err != nil {
+		return err
+	}
+
+	for topic, n := range rec.Topics {
+		_, err := l.tx.Exec(`
+			INSERT INTO account_daily_topics (day, account_id, topic, count) VALUES (?, ?, ?, ?)
+			ON CONFLICT(day, account_id, topic) DO UPDATE SET count = MAX(count, excluded.count)
+		`, rec.Day, accountID, topic, n)
+		if err != nil {
+			return fmt.Errorf("failed to merge topics for %s: %w", rec.Day, err)
+		}
+	}
+	for username, n := range rec.Amplified {
+		_, err := l.tx.Exec(`
+			INSERT INTO account_daily_amplified (day, account_id, username, count) VALUES (?, ?, ?, ?)
+			ON CONFLICT(day, account_id, username) DO UPDATE SET count = MAX(count, excluded.count)
+		`, rec.Day, accountID, username, n)
+		if err != nil {
+			return fmt.Errorf("failed to merge amplified users for %s: %w", rec.Day, err)
+		}
+	}
+	return nil
+}
+
+// fetchRun adds a run unless one with the same start time is already
+// recorded. Per-account results for accounts not in the database are dropped.
+func (l *loader) fetchRun(rec FetchRun) error {
+	started := rec.StartedAt.UTC()
+	found, err := l.exists(`SELECT 1 FROM fetch_runs WHERE started_at = ?`, started)
+	if err != nil {
+		return err
+	}
+	if found {
+		l.stats.tally(TypeFetchRun).Merged++
+		return nil
+	}
+
+	res, err := l.tx.Exec(`
+		INSERT INTO fetch_runs (started_at, finished_at, status, new_tweets, tweets_read, errors, skipped, rate_limit_remaining, rate_limit_reset, error)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, started, utc(rec.FinishedAt), rec.Status, rec.NewTweets, rec.TweetsRead, rec.Errors, rec.Skipped,
+		rec.RateLimitRemaining, utc(rec.RateLimitReset), rec.Error)
+	if err != nil {
+		return fmt.Errorf("failed to add fetch run: %w", err)
+	}
+	runID, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	for _, a := range rec.Accounts {
+		accountID, ok, err := l.accountID(a.UserID)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		if _, err := l.tx.Exec(`
+			INSERT INTO fetch_run_accounts (run_id, account_id, username, new_tweets, tweets_read, http_status, error, skipped)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		`, runID, accountID, a.Username, a.NewTweets, a.TweetsRead, a.HTTPStatus, a.Error, a.Skipped); err != nil {
+			return fmt.Errorf("failed to add fetch run account: %w", err)
+		}
+	}
+
+	l.stats.tally(TypeFetchRun).Added++
+	return nil
+}
+
+// utc normalises an optional time for storage; nil stays NULL
+func utc(t *time.Time) any {
+	if t == nil {
+		return nil
+	}
+	return t.UTC()
+}
@@ -0,0 +1,245 @@
+package fetchrun
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/jpequegn/xmon/internal/database"
+)
+
+// Run statuses
+const (
+	StatusRunning = "running"
+	StatusOK      = "ok"
+	StatusPartial = "partial"
+	StatusFailed  = "failed"
+)
+
+// Run is one invocation of fetch, manual or from the daemon
+type Run struct {
+	ID                 int64
+	StartedAt          time.Time
+	FinishedAt         *time.Time
+	Status             string
+	NewTweets          int
+	TweetsRead         int
+	Errors             int
+	Skipped            int
+	RateLimitRemaining *int
+	RateLimitReset     *time.Time
+	Error              string
+}
+
+// AccountResult is the outcome of fetching a single account within a run
+type AccountResult struct {
+	RunID      int64
+	AccountID  int64
+	Username   string
+	NewTweets  int
+	TweetsRead int
+	HTTPStatus int
+	Error      string
+	// Skipped holds the reason the account was not fetched at all, e.g. the
+	// quota guard refusing the request
+	Skipped string
+}
+
+// Failed reports whether fetching the account went wrong
+func (a AccountResult) Failed() bool {
+	return a.Error != ""
+}
+
+type Repository struct {
+	db *database.DB
+}
+
+func NewRepository(db *database.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// Start opens a new run and returns its ID
+func (r *Repository) Start() (int64, error) {
+	res, err := r.db.Exec(
+		`INSERT INTO fetch_runs (started_at, status) VALUES (?, ?)`,
+		time.Now().UTC(), StatusRunning,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// RecordAccount stores the result for one account and rolls it up into the run totals
+func (r *Repository) RecordAccount(res AccountResult) error {
+	_, err := r.db.Exec(`
+		INSERT INTO fetch_run_accounts (run_id, account_id, username, new_tweets, tweets_read, http_status, error, skipped)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, res.RunID, res.AccountID, res.Username, res.NewTweets, res.TweetsRead, res.HTTPStatus, res.Error, res.Skipped)
+	if err != nil {
+		return err
+	}
+
+	failed, skipped := 0, 0
+	if res.Failed() {
+		failed = 1
+	}
+	if res.Skipped != "" {
+		skipped = 1
+	}
+	_, err = r.db.Exec(`
+		UPDATE fetch_runs SET
+			new_tweets = new_tweets + ?,
+			tweets_read = tweets_read + ?,
+			errors = errors + ?,
+			skipped = skipped + ?
+		WHERE id = ?
+	`, res.NewTweets, res.TweetsRead, failed, skipped, res.RunID)
+	return err
+}
+
+// Finish closes a run, deriving its status from the recorded account results.
+// A run-level error (e.g. no accounts could be listed) marks the whole run
+// failed; errors or skipped accounts make it partial.
+func (r *Repository) Finish(id int64, rateLimitRemaining int, rateLimitReset time.Time, runErr error) error {
+	var accounts, errs, skipped int
+	if err := r.db.QueryRow(`
+		SELECT COUNT(*),
+			COALESCE(SUM(CASE WHEN error != '' THEN 1 ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN skipped != '' THEN 1 ELSE 0 END), 0)
+		FROM fetch_run_accounts WHERE run_id = ?
+	`, id).Scan(&accounts, &errs, &skipped); err != nil {
+		return err
+	}
+
+	status := StatusOK
+	switch {
+	case runErr != nil || (accounts > 0 && errs == accounts):
+		status = StatusFailed
+	case errs > 0 || skipped > 0:
+		status = StatusPartial
+	}
+
+	errMsg := ""
+	if runErr != nil {
+		errMsg = runErr.Error()
+	}
+
+	var remaining any
+	var reset any
+	if !rateLimitReset.IsZero() {
+		remaining = rateLimitRemaining
+		reset = rateLimitReset.UTC()
+	}
+
+	_, err := r.db.Exec(`
+		UPDATE fetch_runs SET finished_at = ?, status = ?, rate_limit_remaining = ?, rate_limit_reset = ?, error = ?
+		WHERE id = ?
+	`, time.Now().UTC(), status, remaining, reset, errMsg, id)
+	return err
+}
+
+// List returns the most recent runs, newest first. With failedOnly set, only
+// runs that had at least one error are returned.
+func (r *Repository) List(limit int, failedOnly bool) ([]Run, error) {
+	query := `
+		SELECT id, started_at, finished_at, status, new_tweets, tweets_read, errors, skipped, rate_limit_remaining, rate_limit_reset, error
+		FROM fetch_runs`
+	if failedOnly {
+		query += ` WHERE status IN ('partial', 'failed')`
+	}
+	query += ` ORDER BY started_at DESC, id DESC LIMIT ?`
+
+	rows, err := r.db.Query(query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var runs []Run
+	for rows.Next() {
+		var run Run
+		if err := rows.Scan(&run.ID, &run.StartedAt, &run.FinishedAt, &run.Status, &run.NewTweets, &run.TweetsRead, &run.Errors, &run.Skipped, &run.RateLimitRemaining, &run.RateLimitReset, &run.Error); err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
+
+// Accounts returns the per-account results of a run
+func (r *Repository) Accounts(runID int64) ([]AccountResult, error) {
+	rows, err := r.db.Query(`
+		SELECT run_id, account_id, username, new_tweets, tweets_read, http_status, error, skipped
+		FROM fetch_run_accounts
+		WHERE run_id = ?
+		ORDER BY username
+	`, runID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []AccountResult
+	for rows.Next() {
+		var a AccountResult
+		if err := rows.Scan(&a.RunID, &a.AccountID, &a.Username, &a.NewTweets, &a.TweetsRead, &a.HTTPStatus, &a.Error, &a.Skipped); err != nil {
+			return nil, err
+		}
+		results = append(results, a)
+	}
+	return results, rows.Err()
+}
+
+// LastSuccess returns when the most recent run that fetched at least one
+// account successfully finished, or nil if there never was one
+func (r *Repository) LastSuccess() (*time.Time, error) {
+	var finished time.Time
+	err := r.db.QueryRow(`
+		SELECT finished_at FROM fetch_runs
+		WHERE status IN ('ok', 'partial')
+		ORDER BY finished_at DESC LIMIT 1
+	`).Scan(&finished)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &finished, nil
+}
+
+// FailedAccountsBetween returns the usernames whose fetch failed in any run
+// started in [since, until)
+func (r *Repository) FailedAccountsBetween(since, until time.Time) ([]string, error) {
+	return r.accountsBetween(since, until, `a.error != ''`)
+}
+
+// SkippedAccountsBetween returns the usernames that were not fetched in some
+// run started in [since, until), leaving a gap in coverage
+func (r *Repository) SkippedAccountsBetween(since, until time.Time) ([]string, error) {
+	return r.accountsBetween(since, until, `a.skipped != ''`)
+}
+
+func (r *Repository) accountsBetween(since, until time.Time, cond string) ([]string, error) {
+	rows, err := r.db.Query(`
+		SELECT DISTINCT a.username
+		FROM fetch_run_accounts a
+		JOIN fetch_runs r ON a.run_id = r.id
+		WHERE r.started_at >= ? AND r.started_at < ? AND `+cond+`
+		ORDER BY a.username
+	`, since.UTC(), until.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var usernames []string
+	for rows.Next() {
+		var u string
+		if err := rows.Scan(&u); err != nil {
+			return nil, err
+		}
+		usernames = append(usernames, u)
+	}
+	return usernames, rows.Err()
+}
@@ -0,0 +1,170 @@
+package fetchrun
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jpequegn/xmon/internal/database"
+)
+
+func setupTestDB(t *testing.T) (*database.DB, func()) {
+	tmpfile, err := os.CreateTemp("", "xmon-fetchrun-test-*.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := database.New(tmpfile.Name())
+	if err != nil {
+		os.Remove(tmpfile.Name())
+		t.Fatal(err)
+	}
+
+	return db, func() {
+		db.Close()
+		os.Remove(tmpfile.Name())
+	}
+}
+
+func TestRunLifecycle(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := NewRepository(db)
+	runID, err := repo.Start()
+	if err != nil {
+		t.Fatalf("failed to start run: %v", err)
+	}
+
+	repo.RecordAccount(AccountResult{RunID: runID, AccountID: 1, Username: "alice", NewTweets: 5, TweetsRead: 8, HTTPStatus: 200})
+	repo.RecordAccount(AccountResult{RunID: runID, AccountID: 2, Username: "bob", HTTPStatus: 429, Error: "X API error 429: Too Many Requests"})
+
+	if err := repo.Finish(runID, 12, time.Now().Add(15*time.Minute), nil); err != nil {
+		t.Fatalf("failed to finish run: %v", err)
+	}
+
+	runs, err := repo.List(10, false)
+	if err != nil {
+		t.Fatalf("failed to list runs: %v", err)
+	}
+	if len(runs) != 1 {
+		t.Fatalf("expected 1 run, got %d", len(runs))
+	}
+
+	run := runs[0]
+	if run.Status != StatusPartial {
+		t.Errorf("expected status %s, got %s", StatusPartial, run.Status)
+	}
+	if run.NewTweets != 5 || run.TweetsRead != 8 || run.Errors != 1 {
+		t.Errorf("unexpected totals: %+v", run)
+	}
+	if run.FinishedAt == nil {
+		t.Error("expected finished_at to be set")
+	}
+	if run.RateLimitRemaining == nil || *run.RateLimitRemaining != 12 {
+		t.Error("expected rate limit remaining to be recorded")
+	}
+
+	results, _ := repo.Accounts(runID)
+	if len(results) != 2 {
+		t.Fatalf("expected 2 account results, got %d", len(results))
+	}
+	if results[1].HTTPStatus != 429 || !results[1].Failed() {
+		t.Errorf("expected bob to have failed with 429, got %+v", results[1])
+	}
+}
+
+func TestListFailedOnly(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := NewRepository(db)
+
+	okRun, _ := repo.Start()
+	repo.RecordAccount(AccountResult{RunID: okRun, AccountID: 1, Username: "alice", NewTweets: 1})
+	repo.Finish(okRun, 0, time.Time{}, nil)
+
+	failedRun, _ := repo.Start()
+	repo.Finish(failedRun, 0, time.Time{}, errors.New("boom"))
+
+	runs, _ := repo.List(10, true)
+	if len(runs) != 1 || runs[0].ID != failedRun {
+		t.Fatalf("expected only the failed run, got %+v", runs)
+	}
+	if runs[0].Status != StatusFailed {
+		t.Errorf("expected status %s, got %s", StatusFailed, runs[0].Status)
+	}
+	if runs[0].Error != "boom" {
+		t.Errorf("expected run error to be recorded, got %q", runs[0].Error)
+	}
+}
+
+func TestFreshnessQueries(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := NewRepository(db)
+
+	last, err := repo.LastSuccess()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last != nil {
+		t.Error("expected no successful run on empty journal")
+	}
+
+	runID, _ := repo.Start()
+	repo.RecordAccount(AccountResult{RunID: runID, AccountID: 1, Username: "alice", NewTweets: 3})
+	repo.RecordAccount(AccountResult{RunID: runID, AccountID: 2, Username: "bob", Error: "timeout"})
+	repo.Finish(runID, 0, time.Time{}, nil)
+
+	last, _ = repo.LastSuccess()
+	if last == nil {
+		t.Fatal("expected a successful run")
+	}
+
+	now := time.Now()
+	failed, _ := repo.FailedAccountsBetween(now.Add(-time.Hour), now.Add(time.Hour))
+	if len(failed) != 1 || failed[0] != "bob" {
+		t.Errorf("expected [bob], got %v", failed)
+	}
+
+	failed, _ = repo.FailedAccountsBetween(now.Add(time.Hour), now.Add(2*time.Hour))
+	if len(failed) != 0 {
+		t.Errorf("expected no failures in the future, got %v", failed)
+	}
+
+	// A window that ended before the run doesn't see its failures
+	failed, _ = repo.FailedAccountsBetween(now.Add(-2*time.Hour), now.Add(-time.Hour))
+	if len(failed) != 0 {
+		t.Errorf("expected no failures in a past window, got %v", failed)
+	}
+}
+
+func TestSkippedAccounts(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := NewRepository(db)
+	runID, _ := repo.Start()
+	repo.RecordAccount(AccountResult{RunID: runID, AccountID: 1, Username: "alice", NewTweets: 3})
+	repo.RecordAccount(AccountResult{RunID: runID, AccountID: 2, Username: "bob", Skipped: "quota reserve reached"})
+	repo.Finish(runID, 0, time.Time{}, nil)
+
+	runs, _ := repo.List(1, false)
+	if runs[0].Status != StatusPartial || runs[0].Skipped != 1 {
+		t.Errorf("expected partial run with 1 skipped, got %+v", runs[0])
+	}
+
+	now := time.Now()
+	skipped, _ := repo.SkippedAccountsBetween(now.Add(-time.Hour), now.Add(time.Hour))
+	if len(skipped) != 1 || skipped[0] != "bob" {
+		t.Errorf("expected [bob], got %v", skipped)
+	}
+
+	failed, _ := repo.FailedAccountsBetween(now.Add(-time.Hour), now.Add(time.Hour))
+	if len(failed) != 0 {
+		t.Errorf("skipped accounts should not count as failed, got %v", failed)
+	}
+}
@@ -0,0 +1,85 @@
+package ingest
+
+import (
+	"fmt"
+
+	"github.com/jpequegn/xmon/internal/account"
+	"github.com/jpequegn/xmon/internal/database"
+	"github.com/jpequegn/xmon/internal/rollup"
+	"github.com/jpequegn/xmon/internal/tweet"
+	"github.com/jpequegn/xmon/internal/usage"
+)
+
+// Page is one timeline response for a single account, ready to be stored
+type Page struct {
+	AccountID  int64
+	Tweets     []tweet.Tweet
+	Referenced []tweet.Referenced // posts the page's retweets and quotes point to
+	NewestID   string             // since_id cursor for the next fetch; empty keeps the current one
+	TweetsRead int                // tweets billed against the API quota for this page
+}
+
+// Writer stores fetched pages. Each page is written in one transaction
+// together with the account's fetch cursor and the usage counters, so a crash
+// or error never leaves tweets without the bookkeeping that goes with them.
+type Writer struct {
+	db       *database.DB
+	accounts *account.Repository
+	tweets   *tweet.Repository
+	rollups  *rollup.Repository
+	usage    *usage.Repository
+}
+
+// NewWriter returns a writer that counts reads against usageRepo's billing cycle
+func NewWriter(db *database.DB, usageRepo *usage.Repository) *Writer {
+	return &Writer{
+		db:       db,
+		accounts: account.NewRepository(db),
+		tweets:   tweet.NewRepository(db),
+		rollups:  rollup.NewRepository(db),
+		usage:    usageRepo,
+	}
+}
+
+// Write stores a page and returns the number of new tweets. On error nothing
+// from the page is kept.
+func (w *Writer) Write(page Page) (int, error) {
+	tx, err := w.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	inserted, err := w.tweets.InsertTx(tx, page.Tweets)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := w.tweets.UpsertReferencedTx(tx, page.Referenced); err != nil {
+		return 0, fmt.Errorf("store referenced tweets: %w", err)
+	}
+	if err := w.tweets.LinkReferencesTx(tx, page.Tweets); err != nil {
+		return 0, fmt.Errorf("link referenced tweets: %w", err)
+	}
+
+	if inserted > 0 {
+		if err := w.rollups.RefreshTweetsTx(tx, page.Tweets); err != nil {
+			return 0, fmt.Errorf("update daily rollups: %w", err)
+		}
+	}
+
+	if err := w.accounts.UpdateFetchedTx(tx, page.AccountID, page.NewestID); err != nil {
+		return 0, fmt.Errorf("update account: %w", err)
+	}
+
+	if page.TweetsRead > 0 {
+		if err := w.usage.AddTweetsReadTx(tx, page.TweetsRead); err != nil {
+			return 0, fmt.Errorf("update usage: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return inserted, nil
+}
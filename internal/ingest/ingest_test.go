@@ -0,0 +1,156 @@
+package ingest
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jpequegn/xmon/internal/account"
+	"github.com/jpequegn/xmon/internal/database"
+	"github.com/jpequegn/xmon/internal/tweet"
+	"github.com/jpequegn/xmon/internal/usage"
+)
+
+func setupTestDB(t *testing.T) (*database.DB, func()) {
+	tmpfile, err := os.CreateTemp("", "xmon-ingest-test-*.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := database.New(tmpfile.Name())
+	if err != nil {
+		os.Remove(tmpfile.Name())
+		t.Fatal(err)
+	}
+
+	return db, func() {
+		db.Close()
+		os.Remove(tmpfile.Name())
+	}
+}
+
+func testTweets(accountID int64, ids ...string) []tweet.Tweet {
+	var tweets []tweet.Tweet
+	for _, id := range ids {
+		tweets = append(tweets, tweet.Tweet{
+			AccountID: accountID,
+			TweetID:   id,
+			TweetType: "original",
+			Content:   "tweet " + id,
+			CreatedAt: time.Now().UTC(),
+		})
+	}
+	return tweets
+}
+
+func TestWritePage(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	accounts := account.NewRepository(db)
+	accounts.Add("123", "alice", "Alice", "", 100)
+	acc, _ := accounts.Get("alice")
+
+	w := NewWriter(db, usage.NewRepository(db))
+	n, err := w.Write(Page{
+		AccountID:  acc.ID,
+		Tweets:     testTweets(acc.ID, "1", "2", "3"),
+		NewestID:   "3",
+		TweetsRead: 3,
+	})
+	if err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	if n != 3 {
+		t.Errorf("expected 3 new tweets, got %d", n)
+	}
+
+	// Overlapping page: duplicates are skipped, not errors
+	n, err = w.Write(Page{
+		AccountID:  acc.ID,
+		Tweets:     testTweets(acc.ID, "3", "4"),
+		NewestID:   "4",
+		TweetsRead: 2,
+	})
+	if err != nil {
+		t.Fatalf("write with duplicates failed: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("expected 1 new tweet, got %d", n)
+	}
+
+	acc, _ = accounts.Get("alice")
+	if acc.SinceID != "4" {
+		t.Errorf("expected since_id 4, got %q", acc.SinceID)
+	}
+	if acc.LastFetched == nil {
+		t.Error("expected last_fetched to be set")
+	}
+
+	// Empty page keeps the cursor
+	w.Write(Page{AccountID: acc.ID})
+	acc, _ = accounts.Get("alice")
+	if acc.SinceID != "4" {
+		t.Errorf("expected since_id to stay 4, got %q", acc.SinceID)
+	}
+
+	u, _ := usage.NewRepository(db).GetCurrentCycle()
+	if u.TweetsRead != 5 {
+		t.Errorf("expected 5 tweets read, got %d", u.TweetsRead)
+	}
+}
+
+func TestWritePageRollsBack(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	w := NewWriter(db, usage.NewRepository(db))
+	_, err := w.Write(Page{
+		AccountID:  42, // does not exist
+		Tweets:     testTweets(42, "1", "2"),
+		NewestID:   "2",
+		TweetsRead: 2,
+	})
+	if err == nil {
+		t.Fatal("expected error for unknown account")
+	}
+
+	var count int
+	db.QueryRow(`SELECT COUNT(*) FROM tweets`).Scan(&count)
+	if count != 0 {
+		t.Errorf("expected no tweets after rollback, got %d", count)
+	}
+
+	u, _ := usage.NewRepository(db).GetCurrentCycle()
+	if u.TweetsRead != 0 {
+		t.Errorf("expected usage to be rolled back, got %d", u.TweetsRead)
+	}
+}
+
+func TestWritePageStoresReferencedPosts(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	accounts := account.NewRepository(db)
+	accounts.Add("123", "alice", "Alice", "", 100)
+	acc, _ := accounts.Get("alice")
+
+	rt := tweet.Tweet{AccountID: acc.ID, TweetID: "1", TweetType: "retweet", Content: "RT @bob: hi", ReferencedUser: "bob", ReferencedTweetID: "99", CreatedAt: time.Now().UTC()}
+	w := NewWriter(db, usage.NewRepository(db))
+	_, err := w.Write(Page{
+		AccountID:  acc.ID,
+		Tweets:     []tweet.Tweet{rt},
+		Referenced: []tweet.Referenced{{TweetID: "99", AuthorUsername: "bob", Content: "hi there, the full post"}},
+	})
+	if err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+
+	var content string
+	var links int
+	db.QueryRow(`SELECT content FROM referenced_tweets WHERE tweet_id = '99'`).Scan(&content)
+	db.QueryRow(`SELECT COUNT(*) FROM tweet_references WHERE tweet_id = '1'`).Scan(&links)
+	if content != "hi there, the full post" || links != 1 {
+		t.Errorf("expected referenced post and link, got %q and %d links", content, links)
+	}
+}
@@ -0,0 +1,90 @@
+// Package lock provides a cross-process file lock, so only one xmon process
+// fetches at a time
+package lock
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrLocked is returned when another process holds the lock
+var ErrLocked = errors.New("lock is held by another process")
+
+// pollInterval is how often Wait retries a held lock
+const pollInterval = 500 * time.Millisecond
+
+// Lock is a held lock file. The holder's pid is written into it.
+type Lock struct {
+	path string
+	file *os.File
+}
+
+// TryAcquire takes the lock at path without blocking. If another process
+// holds it, ErrLocked is returned together with the holder's pid (0 if
+// unknown).
+func TryAcquire(path string) (*Lock, int, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to open lock file: %w", err)
+	}
+
+	if err := lockFile(f); err != nil {
+		f.Close()
+		if errors.Is(err, ErrLocked) {
+			return nil, Holder(path), ErrLocked
+		}
+		return nil, 0, fmt.Errorf("failed to lock %s: %w", path, err)
+	}
+
+	if err := f.Truncate(0); err == nil {
+		f.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0)
+	}
+
+	return &Lock{path: path, file: f}, 0, nil
+}
+
+// Wait blocks until the lock at path is free or timeout passes, without
+// taking it. A zero timeout waits forever.
+func Wait(path string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		l, _, err := TryAcquire(path)
+		if err == nil {
+			return l.Release()
+		}
+		if !errors.Is(err, ErrLocked) {
+			return err
+		}
+		if timeout > 0 && time.Now().After(deadline) {
+			return err
+		}
+		time.Sleep(pollInterval)
+	}
+}
+
+// Holder returns the pid recorded in the lock file at path, or 0
+func Holder(path string) int {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+	pid, _ := strconv.Atoi(strings.TrimSpace(string(data)))
+	return pid
+}
+
+// Release frees the lock. The file is left in place; holding it open and
+// locked is what matters, not its existence.
+func (l *Lock) Release() error {
+	if l == nil || l.file == nil {
+		return nil
+	}
+	l.file.Truncate(0)
+	unlockFile(l.file)
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
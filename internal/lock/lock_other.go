@@ -0,0 +1,25 @@
+//go:build !unix
+
+package lock
+
+import (
+	"os"
+)
+
+// Without flock, locking is best effort: a marker file next to the lock
+// file is created exclusively and removed on release. A crash can leave it
+// behind, in which case it has to be deleted by hand.
+func lockFile(f *os.File) error {
+	marker, err := os.OpenFile(f.Name()+".held", os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if os.IsExist(err) {
+		return ErrLocked
+	}
+	if err != nil {
+		return err
+	}
+	return marker.Close()
+}
+
+func unlockFile(f *os.File) error {
+	return os.Remove(f.Name() + ".held")
+}
@@ -0,0 +1,60 @@
+package lock
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTryAcquire(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fetch.lock")
+
+	l, _, err := TryAcquire(path)
+	if err != nil {
+		t.Fatalf("acquire failed: %v", err)
+	}
+	if pid := Holder(path); pid != os.Getpid() {
+		t.Errorf("expected holder pid %d, got %d", os.Getpid(), pid)
+	}
+
+	_, holder, err := TryAcquire(path)
+	if !errors.Is(err, ErrLocked) {
+		t.Fatalf("expected ErrLocked, got %v", err)
+	}
+	if holder != os.Getpid() {
+		t.Errorf("expected holder pid %d, got %d", os.Getpid(), holder)
+	}
+
+	if err := l.Release(); err != nil {
+		t.Fatalf("release failed: %v", err)
+	}
+
+	l, _, err = TryAcquire(path)
+	if err != nil {
+		t.Fatalf("expected lock to be free after release: %v", err)
+	}
+	l.Release()
+}
+
+func TestWait(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fetch.lock")
+
+	l, _, err := TryAcquire(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Wait(path, 100*time.Millisecond); !errors.Is(err, ErrLocked) {
+		t.Errorf("expected timeout with ErrLocked, got %v", err)
+	}
+
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		l.Release()
+	}()
+	if err := Wait(path, 5*time.Second); err != nil {
+		t.Errorf("expected wait to succeed after release: %v", err)
+	}
+}
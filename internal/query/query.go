@@ -0,0 +1,254 @@
+// Package query runs ad-hoc SQL against the xmon database and renders the
+// result as a table, CSV or JSON
+package query
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jpequegn/xmon/internal/database"
+)
+
+// Views are the documented views queries should prefer over raw tables.
+// Their column names are stable.
+var Views = []View{
+	{"v_tweets", "one row per stored tweet",
+		[]string{"tweet_id", "username", "type", "text", "likes", "retweets", "engagement", "lang",
+			"referenced_user", "referenced_tweet_id", "starred", "created_at", "url"}},
+	{"v_amplification", "one row per retweet or quote, with the post it amplifies",
+		[]string{"tweet_id", "amplified_by", "kind", "amplified_at", "post_id", "post_author",
+			"post_text", "post_likes", "post_retweets", "post_created_at"}},
+	{"v_account_daily", "daily rollups per account, including pruned days",
+		[]string{"day", "username", "originals", "retweets", "quotes", "tweets",
+			"likes_received", "retweets_received", "pruned"}},
+}
+
+// View describes one documented view
+type View struct {
+	Name        string
+	Description string
+	Columns     []string
+}
+
+// ErrNotReadOnly is returned for statements that do not just read
+var ErrNotReadOnly = errors.New("only SELECT queries are allowed")
+
+// Result holds the columns and rows a query returned. Values are nil,
+// int64, float64, string or time.Time.
+type Result struct {
+	Columns []string
+	Rows    [][]any
+}
+
+// Run executes a single read-only statement with args bound to its
+// placeholders. db should be opened with database.OpenReadOnly; the
+// statement check here only gives a clearer error.
+func Run(db *database.DB, sql string, args ...any) (*Result, error) {
+	sql = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(sql), ";"))
+	body := stripComments(sql)
+	if body == "" {
+		return nil, fmt.Errorf("empty query")
+	}
+	switch strings.ToUpper(strings.Fields(body)[0]) {
+	case "SELECT", "WITH", "VALUES", "EXPLAIN":
+	default:
+		return nil, ErrNotReadOnly
+	}
+
+	rows, err := db.Query(sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	result := &Result{Columns: columns}
+	for rows.Next() {
+		values := make([]any, len(columns))
+		ptrs := make([]any, len(columns))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, err
+		}
+		for i, v := range values {
+			if b, ok := v.([]byte); ok {
+				values[i] = string(b)
+			}
+		}
+		result.Rows = append(result.Rows, values)
+	}
+	return result, rows.Err()
+}
+
+// stripComments drops the comments that lead a statement
+func stripComments(sql string) string {
+	for {
+		sql = strings.TrimSpace(sql)
+		switch {
+		case strings.HasPrefix(sql, "--"):
+			end := strings.IndexByte(sql, '\n')
+			if end < 0 {
+				return ""
+			}
+			sql = sql[end+1:]
+		case strings.HasPrefix(sql, "/*"):
+			end := strings.Index(sql, "*/")
+			if end < 0 {
+				return ""
+			}
+			sql = sql[end+2:]
+		default:
+			return sql
+		}
+	}
+}
+
+// Formats lists the output formats Write accepts
+var Formats = []string{"table", "csv", "json"}
+
+// Write renders r in format. Table cells longer than maxWidth runes are
+// cut, and table times are shown in loc; CSV and JSON keep full values with
+// times in UTC.
+func Write(w io.Writer, r *Result, format string, loc *time.Location, maxWidth int) error {
+	switch format {
+	case "table":
+		return writeTable(w, r, loc, maxWidth)
+	case "csv":
+		return writeCSV(w, r)
+	case "json":
+		return writeJSON(w, r)
+	}
+	return fmt.Errorf("unknown format %q (expected %s)", format, strings.Join(Formats, ", "))
+}
+
+func writeTable(w io.Writer, r *Result, loc *time.Location, maxWidth int) error {
+	cells := make([][]string, len(r.Rows))
+	widths := make([]int, len(r.Columns))
+	for i, c := range r.Columns {
+		widths[i] = utf8.RuneCountInString(c)
+	}
+	for i, row := range r.Rows {
+		cells[i] = make([]string, len(row))
+		for j, v := range row {
+			s := tableValue(v, loc)
+			if maxWidth > 0 && utf8.RuneCountInString(s) > maxWidth {
+				s = string([]rune(s)[:maxWidth-1]) + "…"
+			}
+			cells[i][j] = s
+			widths[j] = max(widths[j], utf8.RuneCountInString(s))
+		}
+	}
+
+	line := func(values []string) string {
+		padded := make([]string, len(values))
+		for i, v := range values {
+			padded[i] = v + strings.Repeat(" ", widths[i]-utf8.RuneCountInString(v))
+		}
+		return strings.TrimRight(strings.Join(padded, "  "), " ") + "\n"
+	}
+	rule := make([]string, len(widths))
+	for i, n := range widths {
+		rule[i] = strings.Repeat("─", n)
+	}
+
+	if _, err := io.WriteString(w, line(r.Columns)+line(rule)); err != nil {
+		return err
+	}
+	for _, row := range cells {
+		if _, err := io.WriteString(w, line(row)); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "(%d row%s)\n", len(r.Rows), plural(len(r.Rows)))
+	return err
+}
+
+func tableValue(v any, loc *time.Location) string {
+	switch v := v.(type) {
+	case nil:
+		return "NULL"
+	case time.Time:
+		return v.In(loc).Format("2006-01-02 15:04")
+	case string:
+		return strings.Join(strings.Fields(v), " ")
+	}
+	return fmt.Sprint(v)
+}
+
+func writeCSV(w io.Writer, r *Result) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(r.Columns); err != nil {
+		return err
+	}
+	record := make([]string, len(r.Columns))
+	for _, row := range r.Rows {
+		for i, v := range row {
+			switch v := v.(type) {
+			case nil:
+				record[i] = ""
+			case time.Time:
+				record[i] = v.UTC().Format(time.RFC3339)
+			default:
+				record[i] = fmt.Sprint(v)
+			}
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeJSON writes an array of objects whose keys keep the column order
+func writeJSON(w io.Writer, r *Result) error {
+	var sb strings.Builder
+	sb.WriteString("[")
+	for i, row := range r.Rows {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		sb.WriteString("\n  {")
+		for j, v := range row {
+			if j > 0 {
+				sb.WriteString(", ")
+			}
+			if t, ok := v.(time.Time); ok {
+				v = t.UTC()
+			}
+			key, _ := json.Marshal(r.Columns[j])
+			value, err := json.Marshal(v)
+			if err != nil {
+				return fmt.Errorf("column %s: %w", r.Columns[j], err)
+			}
+			sb.Write(key)
+			sb.WriteString(": ")
+			sb.Write(value)
+		}
+		sb.WriteString("}")
+	}
+	if len(r.Rows) > 0 {
+		sb.WriteString("\n")
+	}
+	sb.WriteString("]\n")
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func plural(n int) string {
+	if n == 1 {
+		return ""
+	}
+	return "s"
+}
@@ -0,0 +1,131 @@
+package query
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jpequegn/xmon/internal/database"
+)
+
+// setupTestDB creates a migrated database with one tweet and returns a
+// read-only connection to it
+func setupTestDB(t *testing.T) *database.DB {
+	t.Helper()
+	tmpfile, err := os.CreateTemp("", "xmon-query-test-*.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpfile.Close()
+	t.Cleanup(func() { os.Remove(tmpfile.Name()) })
+
+	db, err := database.New(tmpfile.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec(`
+		INSERT INTO accounts (id, user_id, username) VALUES (1, '1', 'alice');
+		INSERT INTO tweets (account_id, tweet_id, tweet_type, content, likes, retweets, created_at)
+		VALUES (1, '10', 'original', 'hello, "world"
+second line', 5, 2, ?);
+		INSERT INTO account_daily (day, account_id, originals) VALUES ('2025-10-01', 1, 1);
+	`, time.Date(2025, 10, 1, 12, 0, 0, 0, time.UTC))
+	db.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ro, err := database.OpenReadOnly(tmpfile.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ro.Close() })
+	return ro
+}
+
+func TestViewsHaveDocumentedColumns(t *testing.T) {
+	db := setupTestDB(t)
+
+	for _, v := range Views {
+		r, err := Run(db, "SELECT * FROM "+v.Name)
+		if err != nil {
+			t.Fatalf("%s: %v", v.Name, err)
+		}
+		if strings.Join(r.Columns, ",") != strings.Join(v.Columns, ",") {
+			t.Errorf("%s: expected columns %v, got %v", v.Name, v.Columns, r.Columns)
+		}
+	}
+
+	r, _ := Run(db, "SELECT username, engagement, url FROM v_tweets")
+	if len(r.Rows) != 1 || r.Rows[0][0] != "alice" || r.Rows[0][1] != int64(7) || r.Rows[0][2] != "https://x.com/alice/status/10" {
+		t.Errorf("unexpected v_tweets row %v", r.Rows)
+	}
+}
+
+func TestRunIsReadOnly(t *testing.T) {
+	db := setupTestDB(t)
+
+	if _, err := Run(db, "DELETE FROM tweets"); !errors.Is(err, ErrNotReadOnly) {
+		t.Errorf("expected ErrNotReadOnly, got %v", err)
+	}
+	// Passes the statement check, so the connection has to refuse it
+	if _, err := Run(db, "WITH doomed AS (SELECT 1) DELETE FROM tweets"); err == nil {
+		t.Error("expected the read-only connection to refuse a write")
+	}
+
+	r, err := Run(db, "-- count\nSELECT COUNT(*) AS n FROM tweets WHERE likes >= ?;", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Rows[0][0] != int64(1) {
+		t.Errorf("expected the tweet to survive and match, got %v", r.Rows)
+	}
+}
+
+func TestWriteFormats(t *testing.T) {
+	db := setupTestDB(t)
+	r, err := Run(db, "SELECT username, text, created_at FROM v_tweets")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, r, "table", time.UTC, 10); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `hello, "w…`) || !strings.Contains(buf.String(), "(1 row)") {
+		t.Errorf("unexpected table:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	Write(&buf, r, "csv", time.UTC, 0)
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[1][1] != "hello, \"world\"\nsecond line" || records[1][2] != "2025-10-01T12:00:00Z" {
+		t.Errorf("unexpected csv %q", records)
+	}
+
+	buf.Reset()
+	Write(&buf, r, "json", time.UTC, 0)
+	var rows []map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &rows); err != nil {
+		t.Fatalf("invalid json: %v\n%s", err, buf.String())
+	}
+	if len(rows) != 1 || rows[0]["username"] != "alice" {
+		t.Errorf("unexpected json %v", rows)
+	}
+	if !strings.HasPrefix(strings.TrimSpace(strings.Split(buf.String(), "\n")[1]), `{"username"`) {
+		t.Errorf("expected keys in column order, got %s", buf.String())
+	}
+
+	if err := Write(&buf, r, "xml", time.UTC, 0); err == nil {
+		t.Error("expected unknown format to be rejected")
+	}
+}
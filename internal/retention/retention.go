@@ -0,0 +1,152 @@
+package retention
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jpequegn/xmon/internal/config"
+	"github.com/jpequegn/xmon/internal/database"
+	"github.com/jpequegn/xmon/internal/rollup"
+)
+
+// Policy decides which raw tweets are pruned. Daily aggregates are always
+// kept.
+type Policy struct {
+	TweetDays   int // keep raw tweets this many days, 0 keeps them forever
+	KeepStarred bool
+}
+
+// PolicyFromConfig validates the retention section of the config
+func PolicyFromConfig(c config.RetentionConfig) (Policy, error) {
+	if c.TweetDays < 0 {
+		return Policy{}, fmt.Errorf("tweet_days must not be negative, got %d", c.TweetDays)
+	}
+	return Policy{TweetDays: c.TweetDays, KeepStarred: c.KeepStarred}, nil
+}
+
+// Enabled reports whether any tweets are ever pruned
+func (p Policy) Enabled() bool {
+	return p.TweetDays > 0
+}
+
+// Cutoff returns the start of the oldest UTC day that is kept. Aligning to
+// midnight means every pruned day is rolled up whole.
+func (p Policy) Cutoff(now time.Time) time.Time {
+	now = now.UTC()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, -p.TweetDays)
+}
+
+// Result describes what a prune did, or would do on a dry run
+type Result struct {
+	Cutoff  time.Time
+	Tweets  int // tweets deleted
+	Starred int // tweets older than the cutoff kept because they are starred
+	Days    int // account-days rolled up into aggregates
+}
+
+type Pruner struct {
+	db     *database.DB
+	policy Policy
+}
+
+func NewPruner(db *database.DB, policy Policy) *Pruner {
+	return &Pruner{db: db, policy: policy}
+}
+
+// Plan reports what Prune would delete without changing anything
+func (p *Pruner) Plan(now time.Time) (Result, error) {
+	result := Result{Cutoff: p.policy.Cutoff(now)}
+	if !p.policy.Enabled() {
+		return result, nil
+	}
+
+	err := p.db.QueryRow(`
+		SELECT
+			COALESCE(SUM(CASE WHEN starred_at IS NULL THEN 1 ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN starred_at IS NOT NULL THEN 1 ELSE 0 END), 0),
+			COUNT(DISTINCT date(created_at) || ':' || account_id)
+		FROM tweets
+		WHERE created_at < ?
+	`, result.Cutoff).Scan(&result.Tweets, &result.Starred, &result.Days)
+	if err != nil {
+		return result, err
+	}
+
+	if !p.policy.KeepStarred {
+		result.Tweets += result.Starred
+		result.Starred = 0
+	}
+	return result, nil
+}
+
+// Prune rolls tweets older than the cutoff up into daily aggregates and then
+// deletes them, in one transaction
+func (p *Pruner) Prune(now time.Time) (Result, error) {
+	result := Result{Cutoff: p.policy.Cutoff(now)}
+	if !p.policy.Enabled() {
+		return result, nil
+	}
+
+	tx, err := p.db.Begin()
+	if err != nil {
+		return result, err
+	}
+	defer tx.Rollback()
+
+	// A day can be rolled up again after an earlier prune removed some of
+	// its tweets. Once marked pruned its counts only grow, so this never
+	// loses what was pruned before.
+	rollups := rollup.NewRepository(p.db)
+	days, err := rollups.RefreshBeforeTx(tx, result.Cutoff)
+	if err != nil {
+		return result, fmt.Errorf("failed to roll up daily aggregates: %w", err)
+	}
+	if err := rollups.MarkPrunedBeforeTx(tx, result.Cutoff); err != nil {
+		return result, fmt.Errorf("failed to mark pruned days: %w", err)
+	}
+	result.Days = days
+
+	if p.policy.KeepStarred {
+		if err := tx.QueryRow(`SELECT COUNT(*) FROM tweets WHERE created_at < ? AND starred_at IS NOT NULL`, result.Cutoff).Scan(&result.Starred); err != nil {
+			return result, err
+		}
+	}
+
+	query := `DELETE FROM tweets WHERE created_at < ?`
+	if p.policy.KeepStarred {
+		query += ` AND starred_at IS NULL`
+	}
+	res, err := tx.Exec(query, result.Cutoff)
+	if err != nil {
+		return result, fmt.Errorf("failed to delete tweets: %w", err)
+	}
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return result, err
+	}
+
+	// Deleting tweets drops their reference links; drop posts nothing links
+	// to any more
+	_, err = tx.Exec(`
+		DELETE FROM referenced_tweets
+		WHERE NOT EXISTS (SELECT 1 FROM tweet_references tr WHERE tr.referenced_tweet_id = referenced_tweets.tweet_id)
+	`)
+	if err != nil {
+		return result, fmt.Errorf("failed to delete unreferenced posts: %w", err)
+	}
+	result.Tweets = int(deleted)
+
+	return result, tx.Commit()
+}
+
+// Optimize reclaims the space freed by pruning and refreshes the query
+// planner's statistics
+func (p *Pruner) Optimize() error {
+	if _, err := p.db.Exec(`VACUUM`); err != nil {
+		return fmt.Errorf("failed to vacuum: %w", err)
+	}
+	if _, err := p.db.Exec(`ANALYZE`); err != nil {
+		return fmt.Errorf("failed to analyze: %w", err)
+	}
+	return nil
+}
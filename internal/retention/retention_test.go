@@ -0,0 +1,141 @@
+package retention
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jpequegn/xmon/internal/database"
+)
+
+func setupTestDB(t *testing.T) (*database.DB, func()) {
+	tmpfile, err := os.CreateTemp("", "xmon-retention-test-*.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := database.New(tmpfile.Name())
+	if err != nil {
+		os.Remove(tmpfile.Name())
+		t.Fatal(err)
+	}
+
+	return db, func() {
+		db.Close()
+		os.Remove(tmpfile.Name())
+	}
+}
+
+func insertTweet(t *testing.T, db *database.DB, tweetID, tweetType string, likes int, createdAt time.Time) {
+	t.Helper()
+	_, err := db.Exec(`INSERT INTO tweets (account_id, tweet_id, tweet_type, content, likes, retweets, created_at) VALUES (1, ?, ?, 'text', ?, 1, ?)`,
+		tweetID, tweetType, likes, createdAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCutoff(t *testing.T) {
+	p := Policy{TweetDays: 30}
+	now := time.Date(2025, 6, 15, 13, 45, 0, 0, time.UTC)
+
+	want := time.Date(2025, 5, 16, 0, 0, 0, 0, time.UTC)
+	if got := p.Cutoff(now); !got.Equal(want) {
+		t.Errorf("expected cutoff %v, got %v", want, got)
+	}
+}
+
+func TestPruneKeepsAggregatesAndStarred(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	db.Exec(`INSERT INTO accounts (id, user_id, username) VALUES (1, '1', 'alice')`)
+
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	old := time.Date(2025, 5, 1, 10, 0, 0, 0, time.UTC)
+	insertTweet(t, db, "1", "original", 10, old)
+	insertTweet(t, db, "2", "retweet", 0, old.Add(time.Hour))
+	insertTweet(t, db, "3", "original", 5, old)
+	insertTweet(t, db, "4", "original", 1, now.Add(-time.Hour))
+	db.Exec(`UPDATE tweets SET starred_at = CURRENT_TIMESTAMP WHERE tweet_id = '3'`)
+	db.Exec(`INSERT INTO referenced_tweets (tweet_id) VALUES ('99')`)
+	db.Exec(`INSERT INTO tweet_references (tweet_id, referenced_tweet_id, kind) VALUES ('2', '99', 'retweet')`)
+
+	pruner := NewPruner(db, Policy{TweetDays: 30, KeepStarred: true})
+
+	plan, err := pruner.Plan(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.Tweets != 2 || plan.Starred != 1 {
+		t.Errorf("expected plan to delete 2 and keep 1 starred, got %+v", plan)
+	}
+
+	var count int
+	db.QueryRow(`SELECT COUNT(*) FROM tweets`).Scan(&count)
+	if count != 4 {
+		t.Fatalf("plan must not delete anything, %d tweets left", count)
+	}
+
+	result, err := pruner.Prune(now)
+	if err != nil {
+		t.Fatalf("prune failed: %v", err)
+	}
+	if result.Tweets != 2 || result.Starred != 1 || result.Days != 1 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	db.QueryRow(`SELECT COUNT(*) FROM tweets`).Scan(&count)
+	if count != 2 {
+		t.Errorf("expected starred and recent tweets to remain, got %d", count)
+	}
+	db.QueryRow(`SELECT COUNT(*) FROM referenced_tweets`).Scan(&count)
+	if count != 0 {
+		t.Errorf("expected posts only pruned tweets referenced to be removed, got %d", count)
+	}
+
+	var originals, retweets, likes int
+	err = db.QueryRow(`SELECT originals, retweets, likes_received FROM account_daily WHERE day = '2025-05-01' AND account_id = 1`).
+		Scan(&originals, &retweets, &likes)
+	if err != nil {
+		t.Fatalf("expected daily aggregate: %v", err)
+	}
+	if originals != 2 || retweets != 1 || likes != 15 {
+		t.Errorf("expected 2 originals, 1 retweet, 15 likes, got %d, %d, %d", originals, retweets, likes)
+	}
+
+	var topic int
+	db.QueryRow(`SELECT count FROM account_daily_topics WHERE day = '2025-05-01' AND account_id = 1 AND topic = 'text'`).Scan(&topic)
+	if topic != 3 {
+		t.Errorf("expected topics of pruned tweets to be rolled up, got %d", topic)
+	}
+
+	// Pruning again must not shrink the aggregate to the starred tweet alone
+	if _, err := pruner.Prune(now); err != nil {
+		t.Fatal(err)
+	}
+	db.QueryRow(`SELECT originals FROM account_daily WHERE day = '2025-05-01' AND account_id = 1`).Scan(&originals)
+	if originals != 2 {
+		t.Errorf("expected aggregate to keep 2 originals, got %d", originals)
+	}
+
+	if err := pruner.Optimize(); err != nil {
+		t.Errorf("optimize failed: %v", err)
+	}
+}
+
+func TestPruneDisabled(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	db.Exec(`INSERT INTO accounts (id, user_id, username) VALUES (1, '1', 'alice')`)
+	insertTweet(t, db, "1", "original", 0, time.Now().AddDate(-5, 0, 0))
+
+	result, err := NewPruner(db, Policy{}).Prune(time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Tweets != 0 {
+		t.Errorf("expected nothing pruned when retention is disabled, got %d", result.Tweets)
+	}
+}
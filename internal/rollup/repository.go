@@ -0,0 +1,447 @@
+// Package rollup maintains per-account daily aggregates of tweets: counts by
+// type, engagement, topics and amplified users. They are refreshed whenever
+// tweets are stored and outlive the raw tweets retention prunes, so long
+// ranges can be charted without scanning the tweets table.
+package rollup
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jpequegn/xmon/internal/analysis"
+	"github.com/jpequegn/xmon/internal/database"
+	"github.com/jpequegn/xmon/internal/tweet"
+)
+
+// TopicsPerDay caps the topics kept for one account-day. Counts compared
+// with rollups should be capped the same way; see analysis.Corpus.Counts.
+const TopicsPerDay = 20
+
+const dayFormat = "2006-01-02"
+
+// Totals are summed daily counts
+type Totals struct {
+	Originals        int
+	Retweets         int
+	Quotes           int
+	LikesReceived    int
+	RetweetsReceived int
+}
+
+// Tweets is the number of tweets of all types
+func (t Totals) Tweets() int {
+	return t.Originals + t.Retweets + t.Quotes
+}
+
+// Month is the totals for one UTC calendar month, e.g. "2025-10"
+type Month struct {
+	Month string
+	Totals
+}
+
+// Count is a topic or username with how often it came up
+type Count struct {
+	Name  string
+	Count int
+}
+
+type Repository struct {
+	db *database.DB
+}
+
+func NewRepository(db *database.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// RefreshTweetsTx refreshes the rollups of the account-days tweets fall on
+func (r *Repository) RefreshTweetsTx(tx *sql.Tx, tweets []tweet.Tweet) error {
+	type accountDay struct {
+		accountID int64
+		day       time.Time
+	}
+	seen := make(map[accountDay]bool)
+	for _, t := range tweets {
+		c := t.CreatedAt.UTC()
+		key := accountDay{t.AccountID, time.Date(c.Year(), c.Month(), c.Day(), 0, 0, 0, 0, time.UTC)}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		if _, err := r.refreshTx(tx, "t.account_id = ? AND t.created_at >= ? AND t.created_at < ?",
+			key.accountID, key.day, key.day.AddDate(0, 0, 1)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RefreshBeforeTx refreshes the rollups of every account-day before cutoff,
+// which must be a UTC midnight, and returns how many there are
+func (r *Repository) RefreshBeforeTx(tx *sql.Tx, cutoff time.Time) (int, error) {
+	return r.refreshTx(tx, "t.created_at < ?", cutoff.UTC())
+}
+
+// MarkPrunedBeforeTx records that the raw tweets of days before cutoff are
+// being deleted, so later refreshes only merge into those days
+func (r *Repository) MarkPrunedBeforeTx(tx *sql.Tx, cutoff time.Time) error {
+	_, err := tx.Exec(`UPDATE account_daily SET pruned_at = COALESCE(pruned_at, CURRENT_TIMESTAMP) WHERE day < ?`,
+		cutoff.UTC().Format(dayFormat))
+	return err
+}
+
+// Rebuild recomputes all rollups from the stored tweets. Days retention
+// pruned keep what they had, merged with any tweets still stored for them.
+// It returns the number of account-days written.
+func (r *Repository) Rebuild() (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	// Rows for unpruned days with no tweets left would otherwise linger
+	for _, table := range []string{"account_daily_topics", "account_daily_amplified"} {
+		_, err := tx.Exec(`
+			DELETE FROM ` + table + `
+			WHERE NOT EXISTS (
+				SELECT 1 FROM account_daily d
+				WHERE d.day = ` + table + `.day AND d.account_id = ` + table + `.account_id AND d.pruned_at IS NOT NULL
+			)`)
+		if err != nil {
+			return 0, fmt.Errorf("failed to clear %s: %w", table, err)
+		}
+	}
+	if _, err := tx.Exec(`DELETE FROM account_daily WHERE pruned_at IS NULL`); err != nil {
+		return 0, fmt.Errorf("failed to clear account_daily: %w", err)
+	}
+
+	n, err := r.refreshTx(tx, "1 = 1")
+	if err != nil {
+		return 0, err
+	}
+	return n, tx.Commit()
+}
+
+// refreshTx recomputes the rollups of the account-days that have a tweet
+// matching where, a condition on tweets aliased t. where must select whole
+// account-days: a day is rebuilt from the tweets it matches. Unpruned days
+// are replaced; pruned ones keep the larger of old and new values.
+func (r *Repository) refreshTx(tx *sql.Tx, where string, args ...any) (int, error) {
+	// Rows from old versions may lack a usable timestamp
+	where = "(" + where + ") AND date(t.created_at) IS NOT NULL"
+	touched := `(day, account_id) IN (SELECT date(t.created_at), t.account_id FROM tweets t WHERE ` + where + `)`
+
+	for _, table := range []string{"account_daily_topics", "account_daily_amplified"} {
+		_, err := tx.Exec(`
+			DELETE FROM `+table+`
+			WHERE `+touched+`
+				AND NOT EXISTS (
+					SELECT 1 FROM account_daily d
+					WHERE d.day = `+table+`.day AND d.account_id = `+table+`.account_id AND d.pruned_at IS NOT NULL
+				)`, args...)
+		if err != nil {
+			return 0, fmt.Errorf("failed to clear %s: %w", table, err)
+		}
+	}
+	if _, err := tx.Exec(`DELETE FROM account_daily WHERE pruned_at IS NULL AND `+touched, args...); err != nil {
+		return 0, fmt.Errorf("failed to clear account_daily: %w", err)
+	}
+
+	res, err := tx.Exec(`
+		INSERT INTO account_daily (day, account_id, originals, retweets, quotes, likes_received, retweets_received, updated_at)
+		SELECT
+			date(t.created_at),
+			t.account_id,
+			SUM(CASE WHEN t.tweet_type = 'original' THEN 1 ELSE 0 END),
+			SUM(CASE WHEN t.tweet_type = 'retweet' THEN 1 ELSE 0 END),
+			SUM(CASE WHEN t.tweet_type = 'quote' THEN 1 ELSE 0 END),
+			SUM(t.likes),
+			SUM(t.retweets),
+			CURRENT_TIMESTAMP
+		FROM tweets t
+		WHERE `+where+`
+		GROUP BY date(t.created_at), t.account_id
+		ON CONFLICT(day, account_id) DO UPDATE SET
+			originals = MAX(originals, excluded.originals),
+			retweets = MAX(retweets, excluded.retweets),
+			quotes = MAX(quotes, excluded.quotes),
+			likes_received = MAX(likes_received, excluded.likes_received),
+			retweets_received = MAX(retweets_received, excluded.retweets_received),
+			updated_at = CURRENT_TIMESTAMP
+	`, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to roll up daily counts: %w", err)
+	}
+	days, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO account_daily_amplified (day, account_id, username, count)
+		SELECT date(t.created_at), t.account_id, t.referenced_user, COUNT(*)
+		FROM tweets t
+		WHERE `+where+` AND t.tweet_type IN ('retweet', 'quote') AND t.referenced_user != ''
+		GROUP BY date(t.created_at), t.account_id, t.referenced_user
+		ON CONFLICT(day, account_id, username) DO UPDATE SET
+			count = MAX(count, excluded.count)
+	`, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to roll up amplified users: %w", err)
+	}
+
+	if err := r.refreshTopicsTx(tx, where, args); err != nil {
+		return 0, fmt.Errorf("failed to roll up topics: %w", err)
+	}
+	return int(days), nil
+}
+
+// refreshTopicsTx counts the topics of the tweets matching where per
+// account-day and keeps the most frequent. Tweets arrive grouped by
+// account-day, so only one day's counts are held at a time.
+func (r *Repository) refreshTopicsTx(tx *sql.Tx, where string, args []any) error {
+	stmt, err := tx.Prepare(`
+		INSERT INTO account_daily_topics (day, account_id, topic, count) VALUES (?, ?, ?, ?)
+		ON CONFLICT(day, account_id, topic) DO UPDATE SET count = MAX(count, excluded.count)
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	rows, err := tx.Query(`
+		SELECT date(t.created_at) AS day, t.account_id, t.content, t.lang
+		FROM tweets t
+		WHERE `+where+` AND t.content != ''
+		ORDER BY t.account_id, day
+	`, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var day string
+	var accountID int64
+	counts := make(map[string]int)
+	flush := func() error {
+		for _, c := range topCounts(counts, TopicsPerDay) {
+			if _, err := stmt.Exec(day, accountID, c.Name, c.Count); err != nil {
+				return err
+			}
+		}
+		clear(counts)
+		return nil
+	}
+
+	for rows.Next() {
+		var rowDay, content, lang string
+		var rowAccount int64
+		if err := rows.Scan(&rowDay, &rowAccount, &content, &lang); err != nil {
+			return err
+		}
+		if rowDay != day || rowAccount != accountID {
+			if err := flush(); err != nil {
+				return err
+			}
+			day, accountID = rowDay, rowAccount
+		}
+		for _, term := range analysis.Terms(content, lang) {
+			counts[term]++
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	return flush()
+}
+
+// topCounts returns the limit most frequent entries, ties alphabetically
+func topCounts(counts map[string]int, limit int) []Count {
+	sorted := make([]Count, 0, len(counts))
+	for name, n := range counts {
+		sorted = append(sorted, Count{name, n})
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Count != sorted[j].Count {
+			return sorted[i].Count > sorted[j].Count
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+	if len(sorted) > limit {
+		sorted = sorted[:limit]
+	}
+	return sorted
+}
+
+// LastMonths returns the UTC range covering the n calendar months up to
+// and including the one now falls in
+func LastMonths(now time.Time, n int) (time.Time, time.Time) {
+	now = now.UTC()
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return start.AddDate(0, -(n - 1), 0), start.AddDate(0, 1, 0)
+}
+
+// rangeFilter builds the day range and optional account condition shared by
+// the readers below, on a rollup table aliased r
+func rangeFilter(accountIDs []int64, since, until time.Time) (string, []any) {
+	where := "r.day >= ? AND r.day < ?"
+	args := []any{since.UTC().Format(dayFormat), until.UTC().Format(dayFormat)}
+	if len(accountIDs) > 0 {
+		where += " AND r.account_id IN (?" + strings.Repeat(", ?", len(accountIDs)-1) + ")"
+		for _, id := range accountIDs {
+			args = append(args, id)
+		}
+	}
+	return where, args
+}
+
+// Monthly returns totals per calendar month in [since, until) for the given
+// accounts, or all accounts when none are given. Months without activity
+// are included with zero totals.
+func (r *Repository) Monthly(accountIDs []int64, since, until time.Time) ([]Month, error) {
+	where, args := rangeFilter(accountIDs, since, until)
+	rows, err := r.db.Query(`
+		SELECT substr(r.day, 1, 7) AS month,
+			SUM(r.originals), SUM(r.retweets), SUM(r.quotes), SUM(r.likes_received), SUM(r.retweets_received)
+		FROM account_daily r
+		WHERE `+where+`
+		GROUP BY month
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	byMonth := make(map[string]Totals)
+	for rows.Next() {
+		var month string
+		var t Totals
+		if err := rows.Scan(&month, &t.Originals, &t.Retweets, &t.Quotes, &t.LikesReceived, &t.RetweetsReceived); err != nil {
+			return nil, err
+		}
+		byMonth[month] = t
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var months []Month
+	since = since.UTC()
+	for m := time.Date(since.Year(), since.Month(), 1, 0, 0, 0, 0, time.UTC); m.Before(until); m = m.AddDate(0, 1, 0) {
+		key := m.Format("2006-01")
+		months = append(months, Month{Month: key, Totals: byMonth[key]})
+	}
+	return months, nil
+}
+
+// TopTopics returns the most frequent topics in [since, until)
+func (r *Repository) TopTopics(accountIDs []int64, since, until time.Time, limit int) ([]Count, error) {
+	return r.top("account_daily_topics", "topic", accountIDs, since, until, limit)
+}
+
+// TopAmplified returns the users retweeted or quoted most in [since, until)
+func (r *Repository) TopAmplified(accountIDs []int64, since, until time.Time, limit int) ([]Count, error) {
+	return r.top("account_daily_amplified", "username", accountIDs, since, until, limit)
+}
+
+// TermCounts returns how many tweets in [since, until) mention each topic,
+// and from how many accounts, as a baseline for analysis.DetectTrends. Only
+// account-days with topics rolled up count, so days pruned before topics
+// were kept don't dilute the rates. Days are UTC and whole: a day counts if
+// it starts in the range.
+func (r *Repository) TermCounts(since, until time.Time) (analysis.TermCounts, error) {
+	tc := analysis.TermCounts{Counts: make(map[string]int), Accounts: make(map[string]int)}
+	where, args := rangeFilter(nil, since, until)
+
+	err := r.db.QueryRow(`
+		SELECT COALESCE(SUM(r.originals + r.retweets + r.quotes), 0)
+		FROM account_daily r
+		WHERE `+where+`
+			AND EXISTS (SELECT 1 FROM account_daily_topics t WHERE t.day = r.day AND t.account_id = r.account_id)
+	`, args...).Scan(&tc.Tweets)
+	if err != nil {
+		return tc, err
+	}
+
+	rows, err := r.db.Query(`
+		SELECT r.topic, SUM(r.count), COUNT(DISTINCT r.account_id)
+		FROM account_daily_topics r
+		WHERE `+where+`
+		GROUP BY r.topic
+	`, args...)
+	if err != nil {
+		return tc, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var topic string
+		var count, accounts int
+		if err := rows.Scan(&topic, &count, &accounts); err != nil {
+			return tc, err
+		}
+		tc.Counts[topic] = count
+		tc.Accounts[topic] = accounts
+	}
+	return tc, rows.Err()
+}
+
+// AccountTerms returns how many of each account's tweets in [since, until)
+// mention each topic, for analysis.SignatureTopics
+func (r *Repository) AccountTerms(since, until time.Time) (map[int64]map[string]int, error) {
+	where, args := rangeFilter(nil, since, until)
+	rows, err := r.db.Query(`
+		SELECT r.account_id, r.topic, SUM(r.count)
+		FROM account_daily_topics r
+		WHERE `+where+`
+		GROUP BY r.account_id, r.topic
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[int64]map[string]int)
+	for rows.Next() {
+		var accountID int64
+		var topic string
+		var count int
+		if err := rows.Scan(&accountID, &topic, &count); err != nil {
+			return nil, err
+		}
+		if counts[accountID] == nil {
+			counts[accountID] = make(map[string]int)
+		}
+		counts[accountID][topic] = count
+	}
+	return counts, rows.Err()
+}
+
+func (r *Repository) top(table, column string, accountIDs []int64, since, until time.Time, limit int) ([]Count, error) {
+	where, args := rangeFilter(accountIDs, since, until)
+	rows, err := r.db.Query(`
+		SELECT r.`+column+`, SUM(r.count) AS n
+		FROM `+table+` r
+		WHERE `+where+`
+		GROUP BY r.`+column+`
+		ORDER BY n DESC, r.`+column+`
+		LIMIT ?
+	`, append(args, limit)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var counts []Count
+	for rows.Next() {
+		var c Count
+		if err := rows.Scan(&c.Name, &c.Count); err != nil {
+			return nil, err
+		}
+		counts = append(counts, c)
+	}
+	return counts, rows.Err()
+}
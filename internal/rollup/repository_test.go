@@ -0,0 +1,333 @@
+package rollup
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jpequegn/xmon/internal/analysis"
+	"github.com/jpequegn/xmon/internal/database"
+	"github.com/jpequegn/xmon/internal/tweet"
+)
+
+func setupTestDB(t *testing.T) (*database.DB, func()) {
+	tmpfile, err := os.CreateTemp("", "xmon-rollup-test-*.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := database.New(tmpfile.Name())
+	if err != nil {
+		os.Remove(tmpfile.Name())
+		t.Fatal(err)
+	}
+
+	return db, func() {
+		db.Close()
+		os.Remove(tmpfile.Name())
+	}
+}
+
+// store inserts tweets and refreshes their rollups as ingest does
+func store(t *testing.T, db *database.DB, tweets []tweet.Tweet) {
+	t.Helper()
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	if _, err := tweet.NewRepository(db).InsertTx(tx, tweets); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewRepository(db).RefreshTweetsTx(tx, tweets); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func daily(t *testing.T, db *database.DB, day string) (originals, retweets, likes int) {
+	t.Helper()
+	err := db.QueryRow(`SELECT originals, retweets, likes_received FROM account_daily WHERE day = ? AND account_id = 1`, day).
+		Scan(&originals, &retweets, &likes)
+	if err != nil {
+		t.Fatalf("no rollup for %s: %v", day, err)
+	}
+	return originals, retweets, likes
+}
+
+func TestRefreshOnIngest(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+	db.Exec(`INSERT INTO accounts (id, user_id, username) VALUES (1, '1', 'alice')`)
+
+	day := time.Date(2025, 10, 1, 9, 0, 0, 0, time.UTC)
+	store(t, db, []tweet.Tweet{
+		{AccountID: 1, TweetID: "1", TweetType: "original", Content: "Shipping #agents today", Likes: 10, CreatedAt: day},
+		{AccountID: 1, TweetID: "2", TweetType: "retweet", Content: "RT @dan: agents", ReferencedUser: "dan", CreatedAt: day.Add(time.Hour)},
+	})
+	// A later page for the same day adds to it rather than replacing it
+	store(t, db, []tweet.Tweet{
+		{AccountID: 1, TweetID: "3", TweetType: "original", Content: "more #agents", Likes: 5, CreatedAt: day.Add(2 * time.Hour)},
+		{AccountID: 1, TweetID: "4", TweetType: "original", Content: "next day", CreatedAt: day.AddDate(0, 0, 1)},
+	})
+
+	originals, retweets, likes := daily(t, db, "2025-10-01")
+	if originals != 2 || retweets != 1 || likes != 15 {
+		t.Errorf("expected 2 originals, 1 retweet, 15 likes, got %d, %d, %d", originals, retweets, likes)
+	}
+	if originals, _, _ := daily(t, db, "2025-10-02"); originals != 1 {
+		t.Errorf("expected 1 original on Oct 2, got %d", originals)
+	}
+
+	repo := NewRepository(db)
+	since := time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC)
+	topics, err := repo.TopTopics(nil, since, since.AddDate(0, 1, 0), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(topics) != 1 || topics[0].Name != "#agents" || topics[0].Count != 2 {
+		t.Errorf("expected #agents twice, got %+v", topics)
+	}
+	amplified, _ := repo.TopAmplified([]int64{1}, since, since.AddDate(0, 1, 0), 5)
+	if len(amplified) != 1 || amplified[0].Name != "dan" {
+		t.Errorf("expected dan amplified, got %+v", amplified)
+	}
+}
+
+func TestRebuildKeepsPrunedDays(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+	db.Exec(`INSERT INTO accounts (id, user_id, username) VALUES (1, '1', 'alice')`)
+
+	// A pruned day with only a starred tweet left, and a day whose tweets
+	// were inserted without rollups
+	db.Exec(`INSERT INTO account_daily (day, account_id, originals, likes_received, pruned_at) VALUES ('2025-05-01', 1, 7, 70, CURRENT_TIMESTAMP)`)
+	db.Exec(`INSERT INTO account_daily_topics (day, account_id, topic, count) VALUES ('2025-05-01', 1, 'history', 4)`)
+	db.Exec(`INSERT INTO account_daily (day, account_id, originals) VALUES ('2025-05-03', 1, 9)`)
+	tweets := tweet.NewRepository(db)
+	tweets.Add(1, "1", "original", "starred keeper", "", "", 1, 0, time.Date(2025, 5, 1, 8, 0, 0, 0, time.UTC))
+	tweets.Add(1, "2", "quote", "fresh take", "erin", "9", 3, 0, time.Date(2025, 5, 2, 8, 0, 0, 0, time.UTC))
+
+	days, err := NewRepository(db).Rebuild()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if days != 2 {
+		t.Errorf("expected 2 account-days rebuilt, got %d", days)
+	}
+
+	if originals, _, likes := daily(t, db, "2025-05-01"); originals != 7 || likes != 70 {
+		t.Errorf("expected pruned day to keep 7 originals and 70 likes, got %d and %d", originals, likes)
+	}
+	var n int
+	db.QueryRow(`SELECT count FROM account_daily_topics WHERE day = '2025-05-01' AND topic = 'history'`).Scan(&n)
+	if n != 4 {
+		t.Errorf("expected pruned topics to survive, got %d", n)
+	}
+	db.QueryRow(`SELECT COUNT(*) FROM account_daily WHERE day = '2025-05-03'`).Scan(&n)
+	if n != 0 {
+		t.Error("expected unpruned day without tweets to be dropped")
+	}
+	db.QueryRow(`SELECT COUNT(*) FROM account_daily_amplified WHERE day = '2025-05-02' AND username = 'erin'`).Scan(&n)
+	if n != 1 {
+		t.Error("expected quote on May 2 to be rolled up")
+	}
+}
+
+func TestTermCounts(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+	db.Exec(`INSERT INTO accounts (id, user_id, username) VALUES (1, '1', 'alice'), (2, '2', 'bob')`)
+
+	day := time.Date(2025, 10, 1, 9, 0, 0, 0, time.UTC)
+	tweets := []tweet.Tweet{
+		{AccountID: 1, TweetID: "1", TweetType: "original", Content: "Shipping #agents today", CreatedAt: day},
+		{AccountID: 1, TweetID: "2", TweetType: "original", Content: "agents everywhere", CreatedAt: day.Add(time.Hour)},
+		{AccountID: 2, TweetID: "3", TweetType: "quote", Content: "agents? never", CreatedAt: day.AddDate(0, 0, 1)},
+	}
+	store(t, db, tweets)
+	// A day pruned before topics were rolled up has nothing to compare
+	db.Exec(`INSERT INTO account_daily (day, account_id, originals, pruned_at) VALUES ('2025-10-02', 1, 50, CURRENT_TIMESTAMP)`)
+
+	tc, err := NewRepository(db).TermCounts(day, day.AddDate(0, 0, 7))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tc.Tweets != 3 || tc.Counts["agents"] != 2 || tc.Accounts["agents"] != 2 || tc.Counts["#agents"] != 1 {
+		t.Errorf("unexpected counts %+v", tc)
+	}
+
+	// The same tweets counted from their text agree
+	docs := make([]analysis.Doc, len(tweets))
+	for i, tw := range tweets {
+		docs[i] = analysis.Doc{AccountID: tw.AccountID, Text: tw.Content, CreatedAt: tw.CreatedAt}
+	}
+	if want := analysis.NewCorpus(docs).Counts(TopicsPerDay); !maps.Equal(tc.Counts, want.Counts) || !maps.Equal(tc.Accounts, want.Accounts) {
+		t.Errorf("expected rollup counts %+v to match the text's %+v", tc, want)
+	}
+
+	byAccount, err := NewRepository(db).AccountTerms(day, day.AddDate(0, 0, 7))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if byAccount[1]["agents"] != 1 || byAccount[1]["#agents"] != 1 || byAccount[2]["agents"] != 1 || len(byAccount) != 2 {
+		t.Errorf("unexpected account counts %+v", byAccount)
+	}
+
+	if tc, _ := NewRepository(db).TermCounts(day.AddDate(0, 0, 7), day.AddDate(0, 0, 14)); tc.Tweets != 0 || len(tc.Counts) != 0 {
+		t.Errorf("expected an empty window, got %+v", tc)
+	}
+}
+
+func TestMonthly(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+	db.Exec(`
+		INSERT INTO account_daily (day, account_id, originals, retweets) VALUES
+			('2025-08-31', 1, 1, 0), ('2025-09-01', 1, 2, 1), ('2025-09-15', 2, 3, 0), ('2025-11-02', 1, 4, 0);
+	`)
+
+	now := time.Date(2025, 11, 20, 0, 0, 0, 0, time.UTC)
+	since, until := LastMonths(now, 3)
+	if since.Format("2006-01-02") != "2025-09-01" || until.Format("2006-01-02") != "2025-12-01" {
+		t.Fatalf("unexpected range %s to %s", since, until)
+	}
+
+	months, err := NewRepository(db).Monthly(nil, since, until)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(months) != 3 {
+		t.Fatalf("expected 3 months including the empty one, got %+v", months)
+	}
+	if months[0].Month != "2025-09" || months[0].Tweets() != 6 || months[1].Tweets() != 0 || months[2].Tweets() != 4 {
+		t.Errorf("unexpected months %+v", months)
+	}
+
+	months, _ = NewRepository(db).Monthly([]int64{2}, since, until)
+	if months[0].Tweets() != 3 {
+		t.Errorf("expected account filter to apply, got %+v", months[0])
+	}
+}
+
+// benchTweets is the size of the generated archive, as in tweet's
+// BenchmarkDigestAggregates: 200 accounts posting for a year
+const benchTweets = 1_000_000
+
+func setupBenchDB(b *testing.B) *database.DB {
+	b.Helper()
+	db, err := database.New(b.TempDir() + "/bench.db")
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { db.Close() })
+
+	// One tweet every 31 seconds spans about a year. Each account sticks
+	// to a few of 50 subjects and hashtags, and words drift over the year
+	// so windows differ.
+	_, err = db.Exec(`
+		WITH RECURSIVE seq(n) AS (SELECT 1 UNION ALL SELECT n + 1 FROM seq WHERE n < ?)
+		INSERT INTO accounts (id, user_id, username)
+		SELECT n, 'u' || n, 'user' || n FROM seq WHERE n <= 200;
+
+		WITH RECURSIVE seq(n) AS (SELECT 1 UNION ALL SELECT n + 1 FROM seq WHERE n < ?)
+		INSERT INTO tweets (account_id, tweet_id, tweet_type, content, likes, retweets, created_at)
+		SELECT n % 200 + 1, 'b' || n,
+			CASE n % 5 WHEN 0 THEN 'retweet' WHEN 1 THEN 'quote' ELSE 'original' END,
+			'notes on subject' || (n % 200 % 50) || ' and word' || (n / 20000 + n % 7) || ' #topic' || (n % 50),
+			n % 1000, n % 100,
+			strftime('%Y-%m-%d %H:%M:%S+00:00', '2025-01-01', '+' || (n * 31) || ' seconds')
+		FROM seq;
+	`, 200, benchTweets)
+	if err != nil {
+		b.Fatal(err)
+	}
+	if _, err := NewRepository(db).Rebuild(); err != nil {
+		b.Fatal(err)
+	}
+	if _, err := db.Exec(`ANALYZE`); err != nil {
+		b.Fatal(err)
+	}
+	return db
+}
+
+// BenchmarkDigestTopics times the topic analyses of a 90 day digest: only
+// the window's own tweets are read and tokenized, once, and everything older
+// comes from rollups
+func BenchmarkDigestTopics(b *testing.B) {
+	if testing.Short() {
+		b.Skip("generates a 1M tweet archive")
+	}
+	db := setupBenchDB(b)
+	tweets := tweet.NewRepository(db)
+	rollups := NewRepository(db)
+
+	until := time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC)
+	since := until.AddDate(0, 0, -90)
+	window := tweet.Filter{Since: since, Until: until}
+
+	windowDocs := func() ([]analysis.Doc, error) {
+		texts, err := tweets.Texts(window)
+		docs := make([]analysis.Doc, len(texts))
+		for i, t := range texts {
+			docs[i] = analysis.Doc{AccountID: t.AccountID, Text: t.Content, Lang: t.Lang, CreatedAt: t.CreatedAt}
+		}
+		return docs, err
+	}
+
+	// Themes cluster the corpus trends already tokenized
+	docs, err := windowDocs()
+	if err != nil {
+		b.Fatal(err)
+	}
+	corpus := analysis.NewCorpus(docs)
+
+	cases := []struct {
+		name string
+		run  func() error
+	}{
+		{"Trends", func() error {
+			docs, err := windowDocs()
+			if err != nil {
+				return err
+			}
+			length := until.Sub(since)
+			baseline := make([]analysis.TermCounts, 4)
+			for i := range baseline {
+				end := since.Add(-time.Duration(i) * length)
+				if baseline[i], err = rollups.TermCounts(end.Add(-length), end); err != nil {
+					return err
+				}
+			}
+			analysis.DetectTrends(analysis.NewCorpus(docs).Counts(TopicsPerDay), baseline, analysis.TrendOptions{Limit: 5})
+			return nil
+		}},
+		{"Signatures", func() error {
+			for _, start := range []time.Time{since, since.Add(-until.Sub(since))} {
+				counts, err := rollups.AccountTerms(start, start.Add(until.Sub(since)))
+				if err != nil {
+					return err
+				}
+				analysis.SignatureTopics(counts, 5)
+			}
+			return nil
+		}},
+		{"Themes", func() error {
+			analysis.DetectThemes(corpus, 6)
+			return nil
+		}},
+	}
+	for _, c := range cases {
+		b.Run(fmt.Sprintf("%s/90d", c.name), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if err := c.run(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+// Package timerange resolves the --days, --week, --month, --since and
+// --until flags into an absolute time range. Calendar boundaries are taken
+// in the display timezone; the resulting range is compared against UTC
+// timestamps in the database.
+package timerange
+
+import (
+	"fmt"
+	"time"
+)
+
+// Range is the half-open interval [Start, End)
+type Range struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Options are the raw flag values. At most one of Week, Month and
+// Since/Until may be set; otherwise the range is the last Days days.
+type Options struct {
+	Days  int
+	Week  string // ISO week, e.g. 2025-W40
+	Month string // e.g. 2025-10
+	Since string // YYYY-MM-DD, inclusive
+	Until string // YYYY-MM-DD, inclusive
+}
+
+const dateLayout = "2006-01-02"
+
+// Resolve turns opts into a range. now and loc come from the caller so the
+// result is reproducible.
+func Resolve(opts Options, now time.Time, loc *time.Location) (Range, error) {
+	if loc == nil {
+		loc = time.UTC
+	}
+	now = now.In(loc)
+
+	set := 0
+	for _, v := range []string{opts.Week, opts.Month, opts.Since + opts.Until} {
+		if v != "" {
+			set++
+		}
+	}
+	if set > 1 {
+		return Range{}, fmt.Errorf("use only one of --week, --month and --since/--until")
+	}
+
+	switch {
+	case opts.Week != "":
+		start, err := ParseISOWeek(opts.Week, loc)
+		if err != nil {
+			return Range{}, err
+		}
+		return Range{Start: start, End: start.AddDate(0, 0, 7)}, nil
+
+	case opts.Month != "":
+		month, err := time.ParseInLocation("2006-01", opts.Month, loc)
+		if err != nil {
+			return Range{}, fmt.Errorf("invalid month %q (expected YYYY-MM)", opts.Month)
+		}
+		return Range{Start: month, End: month.AddDate(0, 1, 0)}, nil
+
+	case opts.Since != "" || opts.Until != "":
+		r := Range{End: now}
+		if opts.Until != "" {
+			until, err := time.ParseInLocation(dateLayout, opts.Until, loc)
+			if err != nil {
+				return Range{}, fmt.Errorf("invalid --until date %q (expected YYYY-MM-DD)", opts.Until)
+			}
+			r.End = until.AddDate(0, 0, 1)
+		}
+		if opts.Since != "" {
+			since, err := time.ParseInLocation(dateLayout, opts.Since, loc)
+			if err != nil {
+				return Range{}, fmt.Errorf("invalid --since date %q (expected YYYY-MM-DD)", opts.Since)
+			}
+			r.Start = since
+		} else {
+			r.Start = r.End.AddDate(0, 0, -days(opts.Days))
+		}
+		if !r.Start.Before(r.End) {
+			return Range{}, fmt.Errorf("--since must be before --until")
+		}
+		return r, nil
+	}
+
+	return Range{Start: now.AddDate(0, 0, -days(opts.Days)), End: now}, nil
+}
+
+func days(n int) int {
+	if n <= 0 {
+		return 7
+	}
+	return n
+}
+
+// ParseISOWeek returns the Monday that starts an ISO 8601 week such as
+// 2025-W40, at midnight in loc
+func ParseISOWeek(s string, loc *time.Location) (time.Time, error) {
+	var year, week int
+	if _, err := fmt.Sscanf(s, "%4d-W%2d", &year, &week); err != nil || len(s) != 8 {
+		return time.Time{}, fmt.Errorf("invalid week %q (expected YYYY-Www, e.g. 2025-W40)", s)
+	}
+
+	// January 4th is always in week 1
+	jan4 := time.Date(year, time.January, 4, 0, 0, 0, 0, loc)
+	offset := (int(jan4.Weekday()) + 6) % 7 // days since Monday
+	start := jan4.AddDate(0, 0, -offset+(week-1)*7)
+
+	if y, w := start.ISOWeek(); y != year || w != week {
+		return time.Time{}, fmt.Errorf("invalid week %q: %d has no week %d", s, year, week)
+	}
+	return start, nil
+}
+
+// UTC returns the range with both ends in UTC, for querying
+func (r Range) UTC() Range {
+	return Range{Start: r.Start.UTC(), End: r.End.UTC()}
+}
+
+// Days is the length of the range in whole days, rounded up
+func (r Range) Days() int {
+	d := r.End.Sub(r.Start)
+	n := int(d / (24 * time.Hour))
+	if d%(24*time.Hour) != 0 {
+		n++
+	}
+	return n
+}
+
+// Label renders the range for headings, e.g. "Oct 1 - Oct 7, 2025". The
+// end is exclusive, so the last day shown is the one before it.
+func (r Range) Label(loc *time.Location, long bool) string {
+	start, end := r.Start.In(loc), r.End.In(loc)
+	if end.Equal(time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, loc)) {
+		end = end.AddDate(0, 0, -1)
+	}
+	if long {
+		return start.Format("January 2, 2006") + " - " + end.Format("January 2, 2006")
+	}
+	return start.Format("Jan 2") + " - " + end.Format("Jan 2, 2006")
+}
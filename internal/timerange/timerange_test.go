@@ -0,0 +1,135 @@
+// internal/timerange/timerange_test.go
+package timerange
+
+import (
+	"testing"
+	"time"
+)
+
+func mustLoad(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+	return loc
+}
+
+func TestParseISOWeek(t *testing.T) {
+	tests := []struct {
+		week string
+		want string
+	}{
+		{"2025-W40", "2025-09-29"},
+		{"2025-W01", "2024-12-30"},
+		{"2020-W53", "2020-12-28"},
+		{"2026-W01", "2025-12-29"},
+	}
+	for _, tt := range tests {
+		got, err := ParseISOWeek(tt.week, time.UTC)
+		if err != nil {
+			t.Errorf("%s: %v", tt.week, err)
+			continue
+		}
+		if got.Format(dateLayout) != tt.want {
+			t.Errorf("%s: expected %s, got %s", tt.week, tt.want, got.Format(dateLayout))
+		}
+	}
+
+	for _, bad := range []string{"2025-40", "2025-W00", "2025-W53", "25-W10", "2025-W4"} {
+		if _, err := ParseISOWeek(bad, time.UTC); err == nil {
+			t.Errorf("expected %q to be rejected", bad)
+		}
+	}
+}
+
+func TestResolveWeekInTimezone(t *testing.T) {
+	paris := mustLoad(t, "Europe/Paris")
+
+	r, err := Resolve(Options{Week: "2025-W40"}, time.Now(), paris)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Midnight in Paris on Monday Sep 29 is 22:00 UTC the day before
+	if want := time.Date(2025, 9, 28, 22, 0, 0, 0, time.UTC); !r.Start.Equal(want) {
+		t.Errorf("expected start %s, got %s", want, r.Start.UTC())
+	}
+	if r.Days() != 7 {
+		t.Errorf("expected 7 days, got %d", r.Days())
+	}
+	if got := r.Label(paris, false); got != "Sep 29 - Oct 5, 2025" {
+		t.Errorf("unexpected label %q", got)
+	}
+}
+
+func TestResolveMonth(t *testing.T) {
+	r, err := Resolve(Options{Month: "2024-02"}, time.Now(), time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Days() != 29 {
+		t.Errorf("expected 29 days in February 2024, got %d", r.Days())
+	}
+	if _, err := Resolve(Options{Month: "2024-13"}, time.Now(), time.UTC); err == nil {
+		t.Error("expected invalid month to be rejected")
+	}
+}
+
+func TestResolveSinceUntil(t *testing.T) {
+	now := time.Date(2025, 10, 18, 15, 0, 0, 0, time.UTC)
+
+	r, err := Resolve(Options{Since: "2025-10-01", Until: "2025-10-07"}, now, time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.End.Equal(time.Date(2025, 10, 8, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected --until to include the whole day, got end %s", r.End)
+	}
+	if got := r.Label(time.UTC, true); got != "October 1, 2025 - October 7, 2025" {
+		t.Errorf("unexpected label %q", got)
+	}
+
+	r, _ = Resolve(Options{Since: "2025-10-10"}, now, time.UTC)
+	if !r.End.Equal(now) {
+		t.Errorf("expected open range to end now, got %s", r.End)
+	}
+
+	r, _ = Resolve(Options{Until: "2025-10-07", Days: 3}, now, time.UTC)
+	if !r.Start.Equal(time.Date(2025, 10, 5, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected --until with --days to count back from the end, got %s", r.Start)
+	}
+
+	if _, err := Resolve(Options{Since: "2025-10-07", Until: "2025-10-01"}, now, time.UTC); err == nil {
+		t.Error("expected reversed range to be rejected")
+	}
+	if _, err := Resolve(Options{Since: "10/01/2025"}, now, time.UTC); err == nil {
+		t.Error("expected malformed date to be rejected")
+	}
+}
+
+func TestResolveDays(t *testing.T) {
+	now := time.Date(2025, 10, 18, 15, 0, 0, 0, time.UTC)
+
+	r, err := Resolve(Options{Days: 3}, now, time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.Start.Equal(now.AddDate(0, 0, -3)) || !r.End.Equal(now) {
+		t.Errorf("unexpected range %s - %s", r.Start, r.End)
+	}
+
+	r, _ = Resolve(Options{}, now, time.UTC)
+	if r.Days() != 7 {
+		t.Errorf("expected default of 7 days, got %d", r.Days())
+	}
+}
+
+func TestResolveExclusive(t *testing.T) {
+	if _, err := Resolve(Options{Week: "2025-W40", Month: "2025-10"}, time.Now(), time.UTC); err == nil {
+		t.Error("expected --week and --month together to be rejected")
+	}
+	if _, err := Resolve(Options{Month: "2025-10", Since: "2025-10-01"}, time.Now(), time.UTC); err == nil {
+		t.Error("expected --month and --since together to be rejected")
+	}
+}
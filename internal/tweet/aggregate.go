@@ -0,0 +1,132 @@
+package tweet
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// ActiveAccount is an account with the number of tweets it posted
+type ActiveAccount struct {
+	AccountID int64
+	Username  string
+	Count     int
+}
+
+// MostActive ranks accounts by how many tweets matching f they posted.
+// Counting happens in SQL, so only the top limit rows leave the database.
+func (r *Repository) MostActive(f Filter, limit int) ([]ActiveAccount, error) {
+	query, args, err := mostActiveQuery(f, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []ActiveAccount
+	for rows.Next() {
+		var a ActiveAccount
+		if err := rows.Scan(&a.AccountID, &a.Username, &a.Count); err != nil {
+			return nil, err
+		}
+		results = append(results, a)
+	}
+	return results, rows.Err()
+}
+
+func mostActiveQuery(f Filter, limit int) (string, []any, error) {
+	where, args, err := f.where()
+	if err != nil {
+		return "", nil, err
+	}
+
+	query := "SELECT t.account_id, a.username, COUNT(*) AS n FROM " + f.from()
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " GROUP BY t.account_id ORDER BY n DESC, a.username LIMIT ?"
+	return query, append(args, limit), nil
+}
+
+// Text is the text of a tweet with who posted it, when and in what language
+type Text struct {
+	AccountID int64
+	Content   string
+	Lang      string
+	CreatedAt time.Time
+}
+
+// Texts returns the text of tweets matching f, oldest first, for topic and
+// trend extraction, without loading the rest of each row
+func (r *Repository) Texts(f Filter) ([]Text, error) {
+	where, args, err := f.where()
+	if err != nil {
+		return nil, err
+	}
+	where = append(where, "t.content != ''")
+
+	rows, err := r.db.Query("SELECT t.account_id, t.content, t.lang, t.created_at FROM "+f.from()+
+		" WHERE "+strings.Join(where, " AND ")+" ORDER BY t.created_at", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var texts []Text
+	for rows.Next() {
+		var t Text
+		if err := rows.Scan(&t.AccountID, &t.Content, &t.Lang, &t.CreatedAt); err != nil {
+			return nil, err
+		}
+		texts = append(texts, t)
+	}
+	return texts, rows.Err()
+}
+
+// GetAmplifiedWithSources returns the users retweeted or quoted by at least
+// minAmplifiers tracked accounts in [since, until), with who amplified them,
+// most amplified first. A limit of 0 returns them all
+func (r *Repository) GetAmplifiedWithSources(since, until time.Time, minAmplifiers, limit int) ([]AmplifiedUser, error) {
+	query, args := amplifiedQuery(since, until, minAmplifiers, limit)
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []AmplifiedUser
+	for rows.Next() {
+		var u AmplifiedUser
+		var amplifiers string
+		if err := rows.Scan(&u.Username, &amplifiers, &u.Count); err != nil {
+			return nil, err
+		}
+		u.AmplifiedBy = strings.Split(amplifiers, ",")
+		sort.Strings(u.AmplifiedBy)
+		results = append(results, u)
+	}
+	return results, rows.Err()
+}
+
+func amplifiedQuery(since, until time.Time, minAmplifiers, limit int) (string, []any) {
+	query := `
+		SELECT t.referenced_user, GROUP_CONCAT(DISTINCT a.username), COUNT(DISTINCT t.account_id) AS amplifiers
+		FROM tweets t
+		JOIN accounts a ON t.account_id = a.id
+		WHERE t.tweet_type IN ('retweet', 'quote')
+			AND t.created_at >= ? AND t.created_at < ?
+			AND t.referenced_user != ''
+		GROUP BY t.referenced_user
+		HAVING amplifiers >= ?
+		ORDER BY amplifiers DESC, t.referenced_user`
+	args := []any{since.UTC(), until.UTC(), minAmplifiers}
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+	return query, args
+}
@@ -0,0 +1,203 @@
+package tweet
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jpequegn/xmon/internal/database"
+)
+
+func TestMostActive(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+	repo := NewRepository(db)
+	base := seedQuery(t, repo)
+	repo.Add(2, "104", "original", "", "", "", 0, 0, base.Add(4*time.Hour))
+
+	active, err := repo.MostActive(Filter{}, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(active) != 2 || active[0].Username != "Bob" || active[0].Count != 3 || active[1].Count != 2 {
+		t.Errorf("unexpected ranking %+v", active)
+	}
+
+	active, _ = repo.MostActive(Filter{Types: []string{"original"}}, 1)
+	if len(active) != 1 || active[0].Username != "alice" {
+		t.Errorf("expected alice to lead originals, got %+v", active)
+	}
+}
+
+func TestTexts(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+	repo := NewRepository(db)
+	base := seedQuery(t, repo)
+	repo.Add(2, "104", "original", "", "", "", 0, 0, base.Add(4*time.Hour))
+
+	texts, err := repo.Texts(Filter{AccountIDs: []int64{2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(texts) != 2 {
+		t.Fatalf("expected 2 non-empty texts, got %+v", texts)
+	}
+	if texts[0].AccountID != 2 || texts[1].CreatedAt.Before(texts[0].CreatedAt) {
+		t.Errorf("expected account 2's texts oldest first, got %+v", texts)
+	}
+}
+
+func TestGetAmplifiedWithSources(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	db.Exec(`INSERT INTO accounts (id, user_id, username) VALUES (1, '1', 'alice'), (2, '2', 'bob'), (3, '3', 'carol')`)
+	repo := NewRepository(db)
+	now := time.Now()
+	repo.Add(2, "11", "retweet", "RT @dan: a", "dan", "100", 0, 0, now)
+	repo.Add(1, "12", "quote", "yes", "dan", "101", 0, 0, now)
+	repo.Add(1, "13", "retweet", "RT @dan: b", "dan", "102", 0, 0, now)
+	repo.Add(3, "14", "retweet", "RT @erin: c", "erin", "200", 0, 0, now)
+	repo.Add(3, "15", "original", "mentions dan", "", "", 0, 0, now)
+
+	users, err := repo.GetAmplifiedWithSources(now.Add(-time.Hour), now.Add(time.Hour), 1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 2 || users[0].Username != "dan" || users[0].Count != 2 {
+		t.Fatalf("expected dan amplified by 2 accounts first, got %+v", users)
+	}
+	if strings.Join(users[0].AmplifiedBy, ",") != "alice,bob" {
+		t.Errorf("expected sorted amplifiers, got %v", users[0].AmplifiedBy)
+	}
+
+	users, _ = repo.GetAmplifiedWithSources(now.Add(-time.Hour), now.Add(time.Hour), 2, 0)
+	if len(users) != 1 {
+		t.Errorf("expected minimum amplifiers to apply, got %+v", users)
+	}
+
+	users, _ = repo.GetAmplifiedWithSources(now.Add(-time.Hour), now.Add(time.Hour), 1, 1)
+	if len(users) != 1 || users[0].Username != "dan" {
+		t.Errorf("expected limit to keep the most amplified user, got %+v", users)
+	}
+}
+
+func TestAggregatesUseIndexes(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	plan := func(query string, args ...any) string {
+		rows, err := db.Query("EXPLAIN QUERY PLAN "+query, args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rows.Close()
+		var steps []string
+		for rows.Next() {
+			var id, parent, unused int
+			var detail string
+			rows.Scan(&id, &parent, &unused, &detail)
+			steps = append(steps, detail)
+		}
+		return strings.Join(steps, "; ")
+	}
+
+	now := time.Now().UTC()
+	window := Filter{Since: now.Add(-24 * time.Hour), Until: now}
+
+	query, args, err := countByTypeQuery(window)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if counts := plan(query, args...); !strings.Contains(counts, "COVERING INDEX idx_tweets_created_account_type") {
+		t.Errorf("expected CountByType to use the covering index, got %s", counts)
+	}
+
+	query, args, err = mostActiveQuery(window, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if active := plan(query, args...); !strings.Contains(active, "COVERING INDEX idx_tweets_created_account_type") {
+		t.Errorf("expected MostActive to use the covering index, got %s", active)
+	}
+
+	query, args = amplifiedQuery(window.Since, window.Until, 2, 10)
+	if amplified := plan(query, args...); !strings.Contains(amplified, "idx_tweets_type_created_ref") {
+		t.Errorf("expected amplification to use the type index, got %s", amplified)
+	}
+}
+
+// benchTweets is the size of the generated archive: 200 accounts posting
+// for a year, enough to show whether the digest queries stay indexed
+const benchTweets = 1_000_000
+
+func setupBenchDB(b *testing.B) *database.DB {
+	b.Helper()
+	path := b.TempDir() + "/bench.db"
+	db, err := database.New(path)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { db.Close() })
+
+	// One tweet every 31 seconds spans about a year; every fifth is a
+	// retweet and every fifth a quote of one of ~1000 users
+	_, err = db.Exec(`
+		WITH RECURSIVE seq(n) AS (SELECT 1 UNION ALL SELECT n + 1 FROM seq WHERE n < ?)
+		INSERT INTO accounts (id, user_id, username)
+		SELECT n, 'u' || n, 'user' || n FROM seq WHERE n <= 200;
+
+		WITH RECURSIVE seq(n) AS (SELECT 1 UNION ALL SELECT n + 1 FROM seq WHERE n < ?)
+		INSERT INTO tweets (account_id, tweet_id, tweet_type, content, referenced_user, likes, retweets, created_at)
+		SELECT n % 200 + 1, 'b' || n,
+			CASE n % 5 WHEN 0 THEN 'retweet' WHEN 1 THEN 'quote' ELSE 'original' END,
+			'tweet ' || n || ' about #topic' || (n % 50),
+			CASE WHEN n % 5 < 2 THEN 'amp' || (n % 997) ELSE '' END,
+			n % 1000, n % 100,
+			strftime('%Y-%m-%d %H:%M:%S+00:00', '2025-01-01', '+' || (n * 31) || ' seconds')
+		FROM seq;
+
+		ANALYZE;
+	`, 200, benchTweets)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return db
+}
+
+func BenchmarkDigestAggregates(b *testing.B) {
+	if testing.Short() {
+		b.Skip("generates a 1M tweet archive")
+	}
+	db := setupBenchDB(b)
+	repo := NewRepository(db)
+
+	until := time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC)
+	since := until.AddDate(0, 0, -90)
+	window := Filter{Since: since, Until: until}
+
+	cases := []struct {
+		name string
+		run  func() error
+	}{
+		{"CountByType", func() error { _, err := repo.CountByType(window); return err }},
+		{"MostActive", func() error { _, err := repo.MostActive(window, 5); return err }},
+		{"GetMostAmplified", func() error { _, err := repo.GetMostAmplified(since, until, 5); return err }},
+		{"GetAmplifiedWithSources", func() error { _, err := repo.GetAmplifiedWithSources(since, until, 2, 10); return err }},
+		{"Notable", func() error {
+			_, err := repo.Query(Filter{Since: since, Until: until, Types: []string{"original"}, Sort: SortEngagement, Limit: 3})
+			return err
+		}},
+	}
+	for _, c := range cases {
+		b.Run(fmt.Sprintf("%s/90d", c.name), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if err := c.run(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
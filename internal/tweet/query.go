@@ -0,0 +1,381 @@
+package tweet
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Sort orders Query results
+type Sort string
+
+const (
+	SortNewest     Sort = "newest"
+	SortOldest     Sort = "oldest"
+	SortEngagement Sort = "engagement" // likes + retweets, highest first
+)
+
+// ParseSort accepts the names above; empty means newest
+func ParseSort(s string) (Sort, error) {
+	switch Sort(strings.ToLower(s)) {
+	case "", SortNewest:
+		return SortNewest, nil
+	case SortOldest:
+		return SortOldest, nil
+	case SortEngagement:
+		return SortEngagement, nil
+	}
+	return "", fmt.Errorf("unknown sort %q (expected newest, oldest or engagement)", s)
+}
+
+// ErrInvalidCursor is returned for a cursor that was not produced by Query
+// with the same sort
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Filter selects tweets. Zero values match everything; lists match any of
+// their entries, and all set fields must hold.
+type Filter struct {
+	AccountIDs    []int64
+	Usernames     []string // matched case-insensitively, without @
+	Tags          []string // hashtags, without #
+	Types         []string // original, retweet, quote
+	Since         time.Time
+	Until         time.Time // exclusive
+	MinLikes      int
+	MinRetweets   int
+	MinEngagement int    // likes + retweets
+	Text          string // FTS5 match expression
+	Langs         []string
+	StarredOnly   bool
+
+	Sort   Sort
+	Limit  int    // 0 returns every match
+	Cursor string // from a previous QueryResult.Next
+}
+
+// QueryResult is one page of Query results
+type QueryResult struct {
+	Tweets []Tweet
+	Next   string // cursor for the following page, empty on the last one
+}
+
+// tweetColumns are the columns scanTweet reads, on tweets aliased t
+const tweetColumns = `t.id, t.account_id, t.tweet_id, t.tweet_type, COALESCE(t.content, ''), COALESCE(t.referenced_user, ''),
+	COALESCE(t.referenced_tweet_id, ''), COALESCE(t.likes, 0), COALESCE(t.retweets, 0), t.created_at, t.lang`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanTweet(s scanner, extra ...any) (Tweet, error) {
+	var t Tweet
+	var createdAt sql.NullTime
+	dest := []any{&t.ID, &t.AccountID, &t.TweetID, &t.TweetType, &t.Content, &t.ReferencedUser,
+		&t.ReferencedTweetID, &t.Likes, &t.Retweets, &createdAt, &t.Lang}
+	err := s.Scan(append(dest, extra...)...)
+	t.CreatedAt = createdAt.Time
+	return t, err
+}
+
+var tagRe = regexp.MustCompile(`^[\p{L}\p{N}_]+$`)
+
+// tagEnd is a GLOB class matching any character tagRe does not, so a tag
+// ends at "," or " " but not inside "#aiart" or "#café"
+var tagEnd = "[^a-z0-9_" + nonASCIIWordRanges() + "]"
+
+// nonASCIIWordRanges lists the letters and numbers above ASCII as GLOB
+// class ranges, which SQLite compares by code point
+func nonASCIIWordRanges() string {
+	type span struct{ lo, hi rune }
+	var spans []span
+	for _, table := range []*unicode.RangeTable{unicode.L, unicode.N} {
+		add := func(lo, hi, stride rune) {
+			for ; lo <= hi; lo += stride {
+				if stride == 1 {
+					spans = append(spans, span{max(lo, 0x80), hi})
+					break
+				}
+				spans = append(spans, span{lo, lo})
+			}
+		}
+		for _, r := range table.R16 {
+			add(rune(r.Lo), rune(r.Hi), rune(r.Stride))
+		}
+		for _, r := range table.R32 {
+			add(rune(r.Lo), rune(r.Hi), rune(r.Stride))
+		}
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i].lo < spans[j].lo })
+
+	var b strings.Builder
+	var cur span
+	flush := func() {
+		if cur.hi >= 0x80 {
+			b.WriteString(fmt.Sprintf("%c-%c", cur.lo, cur.hi))
+		}
+	}
+	for _, s := range spans {
+		if s.hi < 0x80 {
+			continue
+		}
+		if s.lo > cur.hi+1 {
+			flush()
+			cur = s
+		} else if s.hi > cur.hi {
+			cur.hi = s.hi
+		}
+	}
+	flush()
+	return b.String()
+}
+
+// where builds the WHERE conditions for f on tweets aliased t, accounts
+// aliased a and, when f.Text is set, tweets_fts
+func (f Filter) where() ([]string, []any, error) {
+	var where []string
+	var args []any
+
+	if f.Text != "" {
+		where = append(where, "tweets_fts MATCH ?")
+		args = append(args, f.Text)
+	}
+	if len(f.AccountIDs) > 0 {
+		where = append(where, "t.account_id IN ("+placeholders(len(f.AccountIDs))+")")
+		for _, id := range f.AccountIDs {
+			args = append(args, id)
+		}
+	}
+	if len(f.Usernames) > 0 {
+		where = append(where, "a.username COLLATE NOCASE IN ("+placeholders(len(f.Usernames))+")")
+		for _, u := range f.Usernames {
+			args = append(args, strings.TrimPrefix(u, "@"))
+		}
+	}
+	if len(f.Tags) > 0 {
+		// GLOB with a trailing class so #ai does not match #aiart
+		var tags []string
+		for _, tag := range f.Tags {
+			tag = strings.ToLower(strings.TrimPrefix(tag, "#"))
+			if !tagRe.MatchString(tag) {
+				return nil, nil, fmt.Errorf("invalid tag %q", tag)
+			}
+			tags = append(tags, "(lower(t.content) || ' ') GLOB ?")
+			args = append(args, "*#"+tag+tagEnd+"*")
+		}
+		where = append(where, "("+strings.Join(tags, " OR ")+")")
+	}
+	if len(f.Types) > 0 {
+		where = append(where, "t.tweet_type IN ("+placeholders(len(f.Types))+")")
+		for _, t := range f.Types {
+			args = append(args, t)
+		}
+	}
+	if !f.Since.IsZero() {
+		where = append(where, "t.created_at >= ?")
+		args = append(args, f.Since.UTC())
+	}
+	if !f.Until.IsZero() {
+		where = append(where, "t.created_at < ?")
+		args = append(args, f.Until.UTC())
+	}
+	if f.MinLikes > 0 {
+		where = append(where, "t.likes >= ?")
+		args = append(args, f.MinLikes)
+	}
+	if f.MinRetweets > 0 {
+		where = append(where, "t.retweets >= ?")
+		args = append(args, f.MinRetweets)
+	}
+	if f.MinEngagement > 0 {
+		where = append(where, "t.likes + t.retweets >= ?")
+		args = append(args, f.MinEngagement)
+	}
+	if len(f.Langs) > 0 {
+		where = append(where, "t.lang IN ("+placeholders(len(f.Langs))+")")
+		for _, l := range f.Langs {
+			args = append(args, strings.ToLower(l))
+		}
+	}
+	if f.StarredOnly {
+		where = append(where, "t.starred_at IS NOT NULL")
+	}
+	return where, args, nil
+}
+
+// from returns the FROM clause the conditions from where need
+func (f Filter) from() string {
+	if f.Text != "" {
+		return `tweets_fts
+			JOIN tweets t ON t.id = tweets_fts.rowid
+			JOIN accounts a ON a.id = t.account_id`
+	}
+	return `tweets t
+		JOIN accounts a ON a.id = t.account_id`
+}
+
+// Query returns tweets matching f in f.Sort order. With a Limit, results
+// come in pages: pass QueryResult.Next back as Cursor for the next one.
+// Paging is keyset based, so it stays stable while new tweets arrive.
+func (r *Repository) Query(f Filter) (QueryResult, error) {
+	by, err := ParseSort(string(f.Sort))
+	if err != nil {
+		return QueryResult{}, err
+	}
+
+	where, args, err := f.where()
+	if err != nil {
+		return QueryResult{}, err
+	}
+
+	// The sort key is read back as stored so the cursor compares exactly.
+	// Undated tweets key as '' so the row-value comparison never sees NULL.
+	key, order, cmp := "CAST(COALESCE(t.created_at, '') AS TEXT)", "COALESCE(t.created_at, '') DESC, t.id DESC", "<"
+	switch by {
+	case SortOldest:
+		order, cmp = "COALESCE(t.created_at, '') ASC, t.id ASC", ">"
+	case SortEngagement:
+		key, order = "t.likes + t.retweets", "t.likes + t.retweets DESC, t.id DESC"
+	}
+
+	if f.Cursor != "" {
+		value, id, err := decodeCursor(f.Cursor, by)
+		if err != nil {
+			return QueryResult{}, err
+		}
+		keyExpr := "COALESCE(t.created_at, '')"
+		var keyArg any = value
+		if by == SortEngagement {
+			keyExpr = "t.likes + t.retweets"
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return QueryResult{}, ErrInvalidCursor
+			}
+			keyArg = n
+		}
+		where = append(where, fmt.Sprintf("(%s, t.id) %s (?, ?)", keyExpr, cmp))
+		args = append(args, keyArg, id)
+	}
+
+	query := "SELECT " + tweetColumns + ", " + key + " FROM " + f.from()
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY " + order
+	if f.Limit > 0 {
+		// One extra row tells whether there is another page
+		query += fmt.Sprintf(" LIMIT %d", f.Limit+1)
+	}
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return QueryResult{}, err
+	}
+	defer rows.Close()
+
+	var result QueryResult
+	var keys []string
+	for rows.Next() {
+		var k sql.NullString
+		t, err := scanTweet(rows, &k)
+		if err != nil {
+			return QueryResult{}, err
+		}
+		result.Tweets = append(result.Tweets, t)
+		keys = append(keys, k.String)
+	}
+	if err := rows.Err(); err != nil {
+		return QueryResult{}, err
+	}
+
+	if f.Limit > 0 && len(result.Tweets) > f.Limit {
+		result.Tweets = result.Tweets[:f.Limit]
+		last := result.Tweets[f.Limit-1]
+		result.Next = encodeCursor(by, keys[f.Limit-1], last.ID)
+	}
+	return result, nil
+}
+
+// TypeCounts is the number of tweets of each type
+type TypeCounts struct {
+	Originals int
+	Retweets  int
+	Quotes    int
+}
+
+// Total is the number of tweets of all types
+func (c TypeCounts) Total() int {
+	return c.Originals + c.Retweets + c.Quotes
+}
+
+// CountByType counts tweets matching f by type. Sort, Limit and Cursor are
+// ignored.
+func (r *Repository) CountByType(f Filter) (TypeCounts, error) {
+	query, args, err := countByTypeQuery(f)
+	if err != nil {
+		return TypeCounts{}, err
+	}
+
+	var c TypeCounts
+	err = r.db.QueryRow(query, args...).Scan(&c.Originals, &c.Retweets, &c.Quotes)
+	return c, err
+}
+
+func countByTypeQuery(f Filter) (string, []any, error) {
+	where, args, err := f.where()
+	if err != nil {
+		return "", nil, err
+	}
+
+	query := `
+		SELECT
+			COALESCE(SUM(CASE WHEN t.tweet_type = 'original' THEN 1 ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN t.tweet_type = 'retweet' THEN 1 ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN t.tweet_type = 'quote' THEN 1 ELSE 0 END), 0)
+		FROM ` + f.from()
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	return query, args, nil
+}
+
+func encodeCursor(by Sort, key string, id int64) string {
+	raw := fmt.Sprintf("%s\x00%s\x00%d", by, key, id)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeCursor(cursor string, by Sort) (string, int64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", 0, ErrInvalidCursor
+	}
+	parts := strings.Split(string(raw), "\x00")
+	if len(parts) != 3 || Sort(parts[0]) != by {
+		return "", 0, ErrInvalidCursor
+	}
+	id, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return "", 0, ErrInvalidCursor
+	}
+	return parts[1], id, nil
+}
+
+// normalizeLang lowercases a language code and checks it looks like one
+func normalizeLang(s string) (string, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" || len(s) > 8 {
+		return "", fmt.Errorf("invalid language %q", s)
+	}
+	for _, r := range s {
+		if !unicode.IsLetter(r) && r != '-' {
+			return "", fmt.Errorf("invalid language %q", s)
+		}
+	}
+	return s, nil
+}
@@ -0,0 +1,245 @@
+package tweet
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func seedQuery(t *testing.T, repo *Repository) time.Time {
+	t.Helper()
+	base := time.Date(2025, 10, 1, 12, 0, 0, 0, time.UTC)
+	repo.db.Exec(`INSERT INTO accounts (id, user_id, username) VALUES (1, '1', 'alice'), (2, '2', 'Bob')`)
+	repo.Add(1, "100", "original", "shipping #ai today", "", "", 10, 2, base)
+	repo.Add(1, "101", "original", "new #AIArt drop", "", "", 1, 0, base.Add(time.Hour))
+	repo.Add(2, "102", "retweet", "RT @carol: #ai, again", "carol", "900", 0, 0, base.Add(2*time.Hour))
+	repo.Add(2, "103", "quote", "bonjour", "", "", 30, 5, base.Add(3*time.Hour))
+	repo.db.Exec(`UPDATE tweets SET lang = 'fr' WHERE tweet_id = '103'`)
+	repo.db.Exec(`UPDATE tweets SET lang = 'en' WHERE tweet_id != '103'`)
+	return base
+}
+
+func tweetIDs(tweets []Tweet) []string {
+	var ids []string
+	for _, t := range tweets {
+		ids = append(ids, t.TweetID)
+	}
+	return ids
+}
+
+func TestQueryFilters(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+	repo := NewRepository(db)
+	base := seedQuery(t, repo)
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   []string
+	}{
+		{"everything newest first", Filter{}, []string{"103", "102", "101", "100"}},
+		{"tag does not match longer tags", Filter{Tags: []string{"#AI"}}, []string{"102", "100"}},
+		{"tag any of", Filter{Tags: []string{"ai", "aiart"}}, []string{"102", "101", "100"}},
+		{"types", Filter{Types: []string{"retweet", "quote"}}, []string{"103", "102"}},
+		{"usernames ignore case and @", Filter{Usernames: []string{"@bob"}}, []string{"103", "102"}},
+		{"accounts", Filter{AccountIDs: []int64{1}}, []string{"101", "100"}},
+		{"min engagement", Filter{MinEngagement: 12}, []string{"103", "100"}},
+		{"min likes and retweets", Filter{MinLikes: 5, MinRetweets: 3}, []string{"103"}},
+		{"lang", Filter{Langs: []string{"FR"}}, []string{"103"}},
+		{"range", Filter{Since: base.Add(time.Hour), Until: base.Add(3 * time.Hour)}, []string{"102", "101"}},
+		{"text", Filter{Text: "shipping"}, []string{"100"}},
+		{"combined", Filter{AccountIDs: []int64{1}, Tags: []string{"ai"}, Text: "today"}, []string{"100"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := repo.Query(tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := tweetIDs(res.Tweets)
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("expected %v, got %v", tt.want, got)
+				}
+			}
+		})
+	}
+
+	if _, err := repo.Query(Filter{Tags: []string{"bad'tag"}}); err == nil {
+		t.Error("expected invalid tag to be rejected")
+	}
+}
+
+func TestQuerySortAndPaging(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+	repo := NewRepository(db)
+	seedQuery(t, repo)
+
+	for _, tt := range []struct {
+		sort Sort
+		want []string
+	}{
+		{SortNewest, []string{"103", "102", "101", "100"}},
+		{SortOldest, []string{"100", "101", "102", "103"}},
+		{SortEngagement, []string{"103", "100", "101", "102"}},
+	} {
+		var got []string
+		cursor := ""
+		for pages := 0; ; pages++ {
+			if pages > 4 {
+				t.Fatalf("%s: paging did not terminate", tt.sort)
+			}
+			res, err := repo.Query(Filter{Sort: tt.sort, Limit: 3, Cursor: cursor})
+			if err != nil {
+				t.Fatalf("%s: %v", tt.sort, err)
+			}
+			got = append(got, tweetIDs(res.Tweets)...)
+			if res.Next == "" {
+				break
+			}
+			cursor = res.Next
+		}
+		if len(got) != len(tt.want) {
+			t.Fatalf("%s: expected %v, got %v", tt.sort, tt.want, got)
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Fatalf("%s: expected %v, got %v", tt.sort, tt.want, got)
+			}
+		}
+	}
+}
+
+func TestQueryCursorStableAcrossInserts(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+	repo := NewRepository(db)
+	base := seedQuery(t, repo)
+
+	first, _ := repo.Query(Filter{Limit: 2})
+	// A newer tweet arriving between pages must not shift the next page
+	repo.Add(1, "104", "original", "fresh", "", "", 0, 0, base.Add(4*time.Hour))
+
+	second, err := repo.Query(Filter{Limit: 2, Cursor: first.Next})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := tweetIDs(second.Tweets); len(got) != 2 || got[0] != "101" || got[1] != "100" {
+		t.Errorf("expected [101 100], got %v", got)
+	}
+	if second.Next != "" {
+		t.Errorf("expected last page, got cursor %q", second.Next)
+	}
+}
+
+func TestQueryPagesThroughUndatedTweets(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+	repo := NewRepository(db)
+	seedQuery(t, repo)
+	db.Exec(`INSERT INTO tweets (account_id, tweet_id, tweet_type, content) VALUES (1, '104', 'original', 'undated'), (2, '105', 'original', 'undated too')`)
+
+	for _, tt := range []struct {
+		sort Sort
+		want []string
+	}{
+		{SortNewest, []string{"103", "102", "101", "100", "105", "104"}},
+		{SortOldest, []string{"104", "105", "100", "101", "102", "103"}},
+	} {
+		var got []string
+		cursor := ""
+		for pages := 0; ; pages++ {
+			if pages > 6 {
+				t.Fatalf("%s: paging did not terminate", tt.sort)
+			}
+			res, err := repo.Query(Filter{Sort: tt.sort, Limit: 1, Cursor: cursor})
+			if err != nil {
+				t.Fatalf("%s: %v", tt.sort, err)
+			}
+			got = append(got, tweetIDs(res.Tweets)...)
+			if res.Next == "" {
+				break
+			}
+			cursor = res.Next
+		}
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("%s: expected %v, got %v", tt.sort, tt.want, got)
+		}
+	}
+}
+
+func TestQueryTagsEndAtNonWordCharacters(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+	repo := NewRepository(db)
+	now := time.Now()
+	db.Exec(`INSERT INTO accounts (id, user_id, username) VALUES (1, '1', 'alice')`)
+	repo.Add(1, "100", "original", "menu #café", "", "", 0, 0, now)
+	repo.Add(1, "101", "original", "le #cafés du coin", "", "", 0, 0, now.Add(time.Minute))
+	repo.Add(1, "102", "original", "#caf… ou pas", "", "", 0, 0, now.Add(2*time.Minute))
+	repo.Add(1, "103", "original", "#東京タワー", "", "", 0, 0, now.Add(3*time.Minute))
+
+	for _, tt := range []struct {
+		tag  string
+		want string
+	}{
+		{"caf", "102"},
+		{"café", "100"},
+		{"東京", ""},
+		{"東京タワー", "103"},
+	} {
+		res, err := repo.Query(Filter{Tags: []string{tt.tag}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.Join(tweetIDs(res.Tweets), ","); got != tt.want {
+			t.Errorf("#%s: expected [%s], got [%s]", tt.tag, tt.want, got)
+		}
+	}
+}
+
+func TestQueryRejectsBadCursor(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+	repo := NewRepository(db)
+	seedQuery(t, repo)
+
+	if _, err := repo.Query(Filter{Cursor: "not-a-cursor"}); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("expected ErrInvalidCursor, got %v", err)
+	}
+
+	res, _ := repo.Query(Filter{Limit: 1})
+	if _, err := repo.Query(Filter{Sort: SortEngagement, Cursor: res.Next}); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("expected cursor from another sort to be rejected, got %v", err)
+	}
+
+	if _, err := repo.Query(Filter{Sort: "random"}); err == nil {
+		t.Error("expected unknown sort to be rejected")
+	}
+}
+
+func TestCountByTypeFilter(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+	repo := NewRepository(db)
+	seedQuery(t, repo)
+
+	c, err := repo.CountByType(Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Originals != 2 || c.Retweets != 1 || c.Quotes != 1 || c.Total() != 4 {
+		t.Errorf("unexpected counts %+v", c)
+	}
+
+	c, _ = repo.CountByType(Filter{Tags: []string{"ai"}, Limit: 1})
+	if c.Originals != 1 || c.Retweets != 1 || c.Quotes != 0 {
+		t.Errorf("expected tag filter to apply and limit to be ignored, got %+v", c)
+	}
+}
@@ -0,0 +1,139 @@
+package tweet
+
+import (
+	"database/sql"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Referenced is a post that tracked accounts retweeted or quoted. It is
+// stored once however many accounts amplify it.
+type Referenced struct {
+	TweetID        string
+	AuthorID       string
+	AuthorUsername string
+	Content        string
+	Likes          int
+	Retweets       int
+	CreatedAt      time.Time
+}
+
+// AmplifiedPost is a referenced post with the tracked accounts that
+// retweeted or quoted it
+type AmplifiedPost struct {
+	Post        Referenced
+	AmplifiedBy []string
+	Retweets    int // tracked accounts that retweeted it
+	Quotes      int // tracked accounts that quoted it
+}
+
+// UpsertReferencedTx stores referenced posts within tx. Text and author are
+// refreshed when known; metrics keep the largest value seen.
+func (r *Repository) UpsertReferencedTx(tx *sql.Tx, posts []Referenced) error {
+	if len(posts) == 0 {
+		return nil
+	}
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO referenced_tweets (tweet_id, author_id, author_username, content, likes, retweets, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(tweet_id) DO UPDATE SET
+			author_id = CASE WHEN excluded.author_id != '' THEN excluded.author_id ELSE referenced_tweets.author_id END,
+			author_username = CASE WHEN excluded.author_username != '' THEN excluded.author_username ELSE referenced_tweets.author_username END,
+			content = CASE WHEN excluded.content != '' THEN excluded.content ELSE referenced_tweets.content END,
+			likes = MAX(referenced_tweets.likes, excluded.likes),
+			retweets = MAX(referenced_tweets.retweets, excluded.retweets),
+			created_at = COALESCE(excluded.created_at, referenced_tweets.created_at),
+			updated_at = CURRENT_TIMESTAMP
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, p := range posts {
+		var created any
+		if !p.CreatedAt.IsZero() {
+			created = p.CreatedAt.UTC()
+		}
+		if _, err := stmt.Exec(p.TweetID, p.AuthorID, p.AuthorUsername, p.Content, p.Likes, p.Retweets, created); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LinkReferencesTx links retweets and quotes among tweets to the posts they
+// reference. A post missing from referenced_tweets, e.g. because it was
+// deleted before it could be fetched, gets a stub with what the tweet knows.
+func (r *Repository) LinkReferencesTx(tx *sql.Tx, tweets []Tweet) error {
+	stub, err := tx.Prepare(`INSERT OR IGNORE INTO referenced_tweets (tweet_id, author_username) VALUES (?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stub.Close()
+
+	link, err := tx.Prepare(`INSERT OR IGNORE INTO tweet_references (tweet_id, referenced_tweet_id, kind) VALUES (?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer link.Close()
+
+	for _, t := range tweets {
+		if t.ReferencedTweetID == "" || (t.TweetType != "retweet" && t.TweetType != "quote") {
+			continue
+		}
+		if _, err := stub.Exec(t.ReferencedTweetID, t.ReferencedUser); err != nil {
+			return err
+		}
+		if _, err := link.Exec(t.TweetID, t.ReferencedTweetID, t.TweetType); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetAmplifiedPosts returns posts retweeted or quoted by at least
+// minAccounts tracked accounts in [since, until), most amplified first
+func (r *Repository) GetAmplifiedPosts(since, until time.Time, minAccounts, limit int) ([]AmplifiedPost, error) {
+	rows, err := r.db.Query(`
+		SELECT rt.tweet_id, rt.author_id, rt.author_username, rt.content, COALESCE(rt.likes, 0), COALESCE(rt.retweets, 0), rt.created_at,
+			GROUP_CONCAT(DISTINCT a.username),
+			COUNT(DISTINCT CASE WHEN tr.kind = 'retweet' THEN t.account_id END),
+			COUNT(DISTINCT CASE WHEN tr.kind = 'quote' THEN t.account_id END),
+			COUNT(DISTINCT t.account_id) AS accounts
+		FROM tweet_references tr
+		JOIN tweets t ON t.tweet_id = tr.tweet_id
+		JOIN accounts a ON a.id = t.account_id
+		JOIN referenced_tweets rt ON rt.tweet_id = tr.referenced_tweet_id
+		WHERE t.created_at >= ? AND t.created_at < ?
+		GROUP BY rt.tweet_id
+		HAVING accounts >= ?
+		ORDER BY accounts DESC, rt.likes + rt.retweets DESC
+		LIMIT ?
+	`, since.UTC(), until.UTC(), minAccounts, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var posts []AmplifiedPost
+	for rows.Next() {
+		var p AmplifiedPost
+		var created sql.NullTime
+		var amplifiers string
+		var accounts int
+		if err := rows.Scan(&p.Post.TweetID, &p.Post.AuthorID, &p.Post.AuthorUsername, &p.Post.Content, &p.Post.Likes, &p.Post.Retweets, &created,
+			&amplifiers, &p.Retweets, &p.Quotes, &accounts); err != nil {
+			return nil, err
+		}
+		if created.Valid {
+			p.Post.CreatedAt = created.Time
+		}
+		p.AmplifiedBy = strings.Split(amplifiers, ",")
+		sort.Strings(p.AmplifiedBy)
+		posts = append(posts, p)
+	}
+	return posts, rows.Err()
+}
@@ -0,0 +1,85 @@
+package tweet
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAmplifiedPosts(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	db.Exec(`INSERT INTO accounts (id, user_id, username) VALUES (1, '1', 'alice'), (2, '2', 'bob'), (3, '3', 'carol')`)
+	repo := NewRepository(db)
+	now := time.Now().UTC()
+
+	tweets := []Tweet{
+		{AccountID: 1, TweetID: "11", TweetType: "retweet", Content: "RT @dan: big news…", ReferencedUser: "dan", ReferencedTweetID: "100", CreatedAt: now},
+		{AccountID: 2, TweetID: "12", TweetType: "retweet", Content: "RT @dan: big news…", ReferencedUser: "dan", ReferencedTweetID: "100", CreatedAt: now},
+		{AccountID: 3, TweetID: "13", TweetType: "quote", Content: "agreed", ReferencedUser: "dan", ReferencedTweetID: "100", CreatedAt: now},
+		{AccountID: 1, TweetID: "14", TweetType: "retweet", Content: "RT @erin: gone", ReferencedUser: "erin", ReferencedTweetID: "200", CreatedAt: now},
+	}
+
+	tx, _ := db.Begin()
+	if _, err := repo.InsertTx(tx, tweets); err != nil {
+		t.Fatal(err)
+	}
+	err := repo.UpsertReferencedTx(tx, []Referenced{
+		{TweetID: "100", AuthorID: "9", AuthorUsername: "dan", Content: "big news, in full", Likes: 500, Retweets: 40, CreatedAt: now.Add(-time.Hour)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.LinkReferencesTx(tx, tweets); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	var stored int
+	db.QueryRow(`SELECT COUNT(*) FROM referenced_tweets`).Scan(&stored)
+	if stored != 2 {
+		t.Errorf("expected each post stored once plus a stub, got %d rows", stored)
+	}
+
+	posts, err := repo.GetAmplifiedPosts(now.Add(-time.Hour), now.Add(time.Hour), 2, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(posts) != 1 {
+		t.Fatalf("expected 1 post amplified by 2+ accounts, got %d", len(posts))
+	}
+	p := posts[0]
+	if p.Post.Content != "big news, in full" || p.Post.Likes != 500 {
+		t.Errorf("expected the full post, got %+v", p.Post)
+	}
+	if len(p.AmplifiedBy) != 3 || p.AmplifiedBy[0] != "alice" || p.Retweets != 2 || p.Quotes != 1 {
+		t.Errorf("unexpected amplifiers %+v", p)
+	}
+
+	// Deleting an amplifying tweet drops its link
+	db.Exec(`DELETE FROM tweets WHERE tweet_id IN ('12', '13')`)
+	posts, _ = repo.GetAmplifiedPosts(now.Add(-time.Hour), now.Add(time.Hour), 2, 10)
+	if len(posts) != 0 {
+		t.Errorf("expected no post amplified by 2+ accounts after delete, got %d", len(posts))
+	}
+}
+
+func TestUpsertReferencedKeepsKnownFields(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := NewRepository(db)
+	tx, _ := db.Begin()
+	repo.UpsertReferencedTx(tx, []Referenced{{TweetID: "1", AuthorUsername: "dan", Content: "full text", Likes: 10}})
+	repo.UpsertReferencedTx(tx, []Referenced{{TweetID: "1", Likes: 4, Retweets: 2}})
+	tx.Commit()
+
+	var author, content string
+	var likes, retweets int
+	db.QueryRow(`SELECT author_username, content, likes, retweets FROM referenced_tweets WHERE tweet_id = '1'`).Scan(&author, &content, &likes, &retweets)
+	if author != "dan" || content != "full text" || likes != 10 || retweets != 2 {
+		t.Errorf("unexpected merge: %s %q %d %d", author, content, likes, retweets)
+	}
+}
@@ -0,0 +1,70 @@
+package tweet
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSetStarred(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	db.Exec(`INSERT INTO accounts (id, user_id, username) VALUES (1, '1', 'alice')`)
+	repo := NewRepository(db)
+	repo.Add(1, "100", "original", "keep me", "", "", 0, 0, time.Now())
+
+	if err := repo.SetStarred("100", true); err != nil {
+		t.Fatalf("star failed: %v", err)
+	}
+	starred, err := repo.Query(Filter{StarredOnly: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(starred.Tweets) != 1 || starred.Tweets[0].TweetID != "100" {
+		t.Errorf("expected tweet 100 starred, got %+v", starred.Tweets)
+	}
+
+	if err := repo.SetStarred("100", false); err != nil {
+		t.Fatalf("unstar failed: %v", err)
+	}
+	starred, _ = repo.Query(Filter{StarredOnly: true})
+	if len(starred.Tweets) != 0 {
+		t.Errorf("expected no starred tweets, got %d", len(starred.Tweets))
+	}
+
+	if err := repo.SetStarred("missing", true); err == nil {
+		t.Error("expected error for unknown tweet")
+	}
+}
+
+func TestQueryComparesInUTC(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	db.Exec(`INSERT INTO accounts (id, user_id, username) VALUES (1, '1', 'alice')`)
+	repo := NewRepository(db)
+
+	// 23:30 on Oct 1 in UTC-5 is 04:30 on Oct 2 in UTC
+	est := time.FixedZone("EST", -5*3600)
+	repo.Add(1, "100", "original", "late night", "", "", 0, 0, time.Date(2025, 10, 1, 23, 30, 0, 0, est))
+
+	oct2 := time.Date(2025, 10, 2, 0, 0, 0, 0, time.UTC)
+	res, err := repo.Query(Filter{Since: oct2, Until: oct2.AddDate(0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Tweets) != 1 {
+		t.Fatalf("expected tweet on Oct 2 UTC, got %d", len(res.Tweets))
+	}
+
+	// The same instant expressed in another zone must select the same rows
+	res, _ = repo.Query(Filter{Since: oct2.In(est), Until: oct2.In(est).AddDate(0, 0, 1)})
+	if len(res.Tweets) != 1 {
+		t.Errorf("expected range bounds to be normalised to UTC, got %d tweets", len(res.Tweets))
+	}
+
+	counts, _ := repo.CountByType(Filter{Since: oct2.AddDate(0, 0, -1), Until: oct2})
+	if counts.Total() != 0 {
+		t.Errorf("expected no tweets on Oct 1 UTC, got %d", counts.Total())
+	}
+}
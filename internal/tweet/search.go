@@ -0,0 +1,243 @@
+package tweet
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Markers wrapped around matched terms in SearchResult.Snippet
+const (
+	HighlightStart = "\x02"
+	HighlightEnd   = "\x03"
+)
+
+// SearchQuery is a parsed search: free text for the full-text index plus
+// structured filters
+type SearchQuery struct {
+	Text     string // FTS5 match expression, empty for filter-only searches
+	From     []string
+	Types    []string
+	Since    time.Time
+	Until    time.Time
+	MinLikes int
+	MinRTs   int
+	Tags     []string
+	Langs    []string
+	Limit    int
+}
+
+// Filter returns the query's conditions as a Filter
+func (q SearchQuery) Filter() Filter {
+	return Filter{
+		Usernames:   q.From,
+		Tags:        q.Tags,
+		Types:       q.Types,
+		Since:       q.Since,
+		Until:       q.Until,
+		MinLikes:    q.MinLikes,
+		MinRetweets: q.MinRTs,
+		Text:        q.Text,
+		Langs:       q.Langs,
+	}
+}
+
+// SearchResult is a matching tweet with its author and a highlighted snippet
+type SearchResult struct {
+	Tweet
+	Username string
+	Snippet  string
+	Score    float64 // BM25 relevance, higher is better; 0 without free text
+}
+
+// ParseSearchQuery splits a query like `agents from:naval type:quote
+// since:2025-06-01 min_likes:1000` into free text and filters. Quoted
+// phrases are kept together.
+func ParseSearchQuery(q string) (SearchQuery, error) {
+	var query SearchQuery
+	var text []string
+
+	for _, tok := range splitQuery(q) {
+		key, value, ok := strings.Cut(tok, ":")
+		if !ok || strings.HasPrefix(tok, `"`) {
+			text = append(text, ftsTerm(tok))
+			continue
+		}
+
+		switch strings.ToLower(key) {
+		case "from":
+			for _, u := range strings.Split(value, ",") {
+				if u = strings.TrimPrefix(strings.TrimSpace(u), "@"); u != "" {
+					query.From = append(query.From, u)
+				}
+			}
+		case "type":
+			for _, t := range strings.Split(value, ",") {
+				switch t = strings.ToLower(strings.TrimSpace(t)); t {
+				case "original", "retweet", "quote":
+					query.Types = append(query.Types, t)
+				case "rt":
+					query.Types = append(query.Types, "retweet")
+				default:
+					return query, fmt.Errorf("unknown tweet type %q (expected original, retweet or quote)", t)
+				}
+			}
+		case "tag":
+			for _, tag := range strings.Split(value, ",") {
+				if tag = strings.TrimPrefix(strings.TrimSpace(tag), "#"); tag != "" {
+					if !tagRe.MatchString(tag) {
+						return query, fmt.Errorf("invalid tag %q", tag)
+					}
+					query.Tags = append(query.Tags, tag)
+				}
+			}
+		case "lang":
+			for _, l := range strings.Split(value, ",") {
+				lang, err := normalizeLang(l)
+				if err != nil {
+					return query, err
+				}
+				query.Langs = append(query.Langs, lang)
+			}
+		case "since":
+			since, err := time.Parse("2006-01-02", value)
+			if err != nil {
+				return query, fmt.Errorf("invalid since date %q (expected YYYY-MM-DD)", value)
+			}
+			query.Since = since
+		case "until":
+			until, err := time.Parse("2006-01-02", value)
+			if err != nil {
+				return query, fmt.Errorf("invalid until date %q (expected YYYY-MM-DD)", value)
+			}
+			// Inclusive of the whole day
+			query.Until = until.AddDate(0, 0, 1)
+		case "min_likes", "min_rts":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return query, fmt.Errorf("invalid %s value %q", key, value)
+			}
+			if strings.ToLower(key) == "min_likes" {
+				query.MinLikes = n
+			} else {
+				query.MinRTs = n
+			}
+		default:
+			// Not a filter (e.g. a URL); search for it as a phrase so the
+			// colon isn't read as a column qualifier
+			text = append(text, `"`+strings.ReplaceAll(tok, `"`, "")+`"`)
+		}
+	}
+
+	query.Text = strings.Join(text, " ")
+	return query, nil
+}
+
+// ftsBarewordRe matches terms FTS5 accepts unquoted, optionally as a
+// prefix query
+var ftsBarewordRe = regexp.MustCompile(`^[\p{L}\p{N}_]+\*?$`)
+
+// ftsTerm quotes a free-text token FTS5 would reject as a syntax error,
+// like "gpt-4" or "don't", so it searches as a phrase. Quoted phrases,
+// AND/OR/NOT, prefix terms and parentheses pass through.
+func ftsTerm(tok string) string {
+	if strings.HasPrefix(tok, `"`) {
+		return tok
+	}
+	word := strings.TrimLeft(tok, "(")
+	open := tok[:len(tok)-len(word)]
+	word = strings.TrimRight(word, ")")
+	closing := tok[len(open)+len(word):]
+	if word == "" || word == "AND" || word == "OR" || word == "NOT" || ftsBarewordRe.MatchString(word) {
+		return tok
+	}
+	return open + `"` + strings.ReplaceAll(word, `"`, `""`) + `"` + closing
+}
+
+// splitQuery splits on whitespace, keeping "quoted phrases" as one token
+func splitQuery(q string) []string {
+	var tokens []string
+	var cur strings.Builder
+	inQuote := false
+
+	for _, r := range q {
+		switch {
+		case r == '"':
+			cur.WriteRune(r)
+			inQuote = !inQuote
+		case !inQuote && (r == ' ' || r == '\t' || r == '\n'):
+			if cur.Len() > 0 {
+				tokens = append(tokens, cur.String())
+				cur.Reset()
+			}
+		default:
+			cur.WriteRune(r)
+		}
+	}
+	if cur.Len() > 0 {
+		tok := cur.String()
+		if inQuote {
+			tok += `"`
+		}
+		tokens = append(tokens, tok)
+	}
+	return tokens
+}
+
+// Search runs a query against the full-text index. Results with free text
+// are ranked by FTS5's bm25(), filter-only searches by engagement. Both are
+// ranked and limited in SQLite, so only the returned rows are read.
+func (r *Repository) Search(q SearchQuery) ([]SearchResult, error) {
+	f := q.Filter()
+	where, args, err := f.where()
+	if err != nil {
+		return nil, err
+	}
+
+	var query string
+	if q.Text != "" {
+		query = "SELECT " + tweetColumns + `, a.username,
+				snippet(tweets_fts, 0, ?, ?, '…', 24),
+				-bm25(tweets_fts) AS score
+			FROM ` + f.from()
+		args = append([]any{HighlightStart, HighlightEnd}, args...)
+	} else {
+		query = "SELECT " + tweetColumns + ", a.username, COALESCE(t.content, ''), 0 FROM " + f.from()
+	}
+
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	if q.Text != "" {
+		query += " ORDER BY score DESC, (t.likes + t.retweets) DESC"
+	} else {
+		query += " ORDER BY (t.likes + t.retweets) DESC, t.created_at DESC"
+	}
+	if q.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", q.Limit)
+	}
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []SearchResult
+	for rows.Next() {
+		var res SearchResult
+		t, err := scanTweet(rows, &res.Username, &res.Snippet, &res.Score)
+		if err != nil {
+			return nil, err
+		}
+		res.Tweet = t
+		results = append(results, res)
+	}
+	return results, rows.Err()
+}
+
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
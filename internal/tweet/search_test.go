@@ -0,0 +1,148 @@
+package tweet
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jpequegn/xmon/internal/database"
+)
+
+func setupTestDB(t *testing.T) (*database.DB, func()) {
+	tmpfile, err := os.CreateTemp("", "xmon-tweet-test-*.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := database.New(tmpfile.Name())
+	if err != nil {
+		os.Remove(tmpfile.Name())
+		t.Fatal(err)
+	}
+
+	return db, func() {
+		db.Close()
+		os.Remove(tmpfile.Name())
+	}
+}
+
+func TestParseSearchQuery(t *testing.T) {
+	q, err := ParseSearchQuery(`"open source" agents from:@naval,pmarca type:quote since:2025-06-01 min_likes:1000`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if q.Text != `"open source" agents` {
+		t.Errorf("unexpected text %q", q.Text)
+	}
+	if len(q.From) != 2 || q.From[0] != "naval" || q.From[1] != "pmarca" {
+		t.Errorf("unexpected from %v", q.From)
+	}
+	if len(q.Types) != 1 || q.Types[0] != "quote" {
+		t.Errorf("unexpected types %v", q.Types)
+	}
+	if q.Since.Format("2006-01-02") != "2025-06-01" {
+		t.Errorf("unexpected since %s", q.Since)
+	}
+	if q.MinLikes != 1000 {
+		t.Errorf("unexpected min_likes %d", q.MinLikes)
+	}
+
+	if _, err := ParseSearchQuery("type:thread"); err == nil {
+		t.Error("expected error for unknown type")
+	}
+	if _, err := ParseSearchQuery("since:yesterday"); err == nil {
+		t.Error("expected error for bad date")
+	}
+
+	q, err = ParseSearchQuery("agents tag:#ai,llm lang:EN")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(q.Tags) != 2 || q.Tags[0] != "ai" || q.Tags[1] != "llm" {
+		t.Errorf("unexpected tags %v", q.Tags)
+	}
+	if len(q.Langs) != 1 || q.Langs[0] != "en" {
+		t.Errorf("unexpected langs %v", q.Langs)
+	}
+	if _, err := ParseSearchQuery("lang:e1"); err == nil {
+		t.Error("expected error for bad language")
+	}
+
+	q, _ = ParseSearchQuery(`gpt-4 OR don't (ai OR ml) agent*`)
+	if q.Text != `"gpt-4" OR "don't" (ai OR ml) agent*` {
+		t.Errorf("expected FTS5 syntax kept and punctuated terms quoted, got %q", q.Text)
+	}
+
+	q, _ = ParseSearchQuery("see https://example.com")
+	if q.Text != `see "https://example.com"` {
+		t.Errorf("expected URL to be quoted, got %q", q.Text)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	db.Exec(`INSERT INTO accounts (id, user_id, username) VALUES (1, '1', 'naval'), (2, '2', 'pmarca')`)
+
+	repo := NewRepository(db)
+	now := time.Now().UTC()
+	repo.Add(1, "t1", "original", "AI agents will eat software", "", "", 5000, 100, now)
+	repo.Add(1, "t2", "original", "Read more books about agents and agents of change", "", "", 10, 1, now)
+	repo.Add(2, "t3", "quote", "Open source AI agents are winning", "", "", 2000, 50, now)
+	repo.Add(2, "t4", "original", "Markets are irrational", "", "", 9000, 900, now.AddDate(0, -2, 0))
+
+	results, err := repo.Search(SearchQuery{Text: "agents"})
+	if err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("expected 3 results, got %d", len(results))
+	}
+	if results[0].TweetID != "t2" {
+		t.Errorf("expected the tweet mentioning agents twice to rank first, got %s", results[0].TweetID)
+	}
+	if !strings.Contains(results[0].Snippet, HighlightStart+"agents"+HighlightEnd) {
+		t.Errorf("expected highlighted snippet, got %q", results[0].Snippet)
+	}
+
+	// The limit applies after ranking
+	results, err = repo.Search(SearchQuery{Text: "agents", Limit: 1})
+	if err != nil || len(results) != 1 || results[0].TweetID != "t2" || results[0].Score <= 0 {
+		t.Errorf("expected only the best match t2 with a score, got %+v (%v)", results, err)
+	}
+
+	// Punctuated terms and prefixes parse as FTS5 queries
+	q, _ := ParseSearchQuery("open-source OR agent*")
+	if results, err = repo.Search(q); err != nil || len(results) != 3 {
+		t.Errorf("expected 3 results for %q, got %+v (%v)", q.Text, results, err)
+	}
+
+	q, _ = ParseSearchQuery("agents from:pmarca type:quote")
+	results, _ = repo.Search(q)
+	if len(results) != 1 || results[0].TweetID != "t3" || results[0].Username != "pmarca" {
+		t.Errorf("expected only t3, got %+v", results)
+	}
+
+	q, _ = ParseSearchQuery("min_likes:1000")
+	results, _ = repo.Search(q)
+	if len(results) != 3 || results[0].TweetID != "t4" {
+		t.Errorf("expected 3 results ordered by engagement, got %+v", results)
+	}
+
+	q, _ = ParseSearchQuery("min_likes:1000 since:" + now.AddDate(0, 0, -7).Format("2006-01-02"))
+	results, _ = repo.Search(q)
+	if len(results) != 2 {
+		t.Errorf("expected since to exclude the old tweet, got %d results", len(results))
+	}
+
+	// Index follows updates and deletes
+	db.Exec(`UPDATE tweets SET content = 'nothing to see' WHERE tweet_id = 't1'`)
+	db.Exec(`DELETE FROM tweets WHERE tweet_id = 't3'`)
+	results, _ = repo.Search(SearchQuery{Text: "agents"})
+	if len(results) != 1 || results[0].TweetID != "t2" {
+		t.Errorf("expected index to track changes, got %+v", results)
+	}
+}
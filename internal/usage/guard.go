@@ -0,0 +1,69 @@
+package usage
+
+import "fmt"
+
+// Account priorities, from first to last to be skipped when quota runs low
+const (
+	PriorityHigh   = "high"
+	PriorityNormal = "normal"
+	PriorityLow    = "low"
+)
+
+// Page sizes accepted by the timeline endpoint
+const (
+	MinPageSize = 5
+	MaxPageSize = 100
+)
+
+// Guard decides whether a fetch may spend quota. It keeps Policy.Reserve
+// tweets untouched, shrinks pages to fit what is left, and skips low-priority
+// accounts once usage passes the warning threshold.
+type Guard struct {
+	policy   Policy
+	used     int
+	override bool
+}
+
+// NewGuard starts a guard from the tweets already read this cycle. With
+// override set every request is allowed at full page size.
+func NewGuard(policy Policy, used int, override bool) *Guard {
+	return &Guard{policy: policy, used: used, override: override}
+}
+
+// Budget is how many tweets can still be read before touching the reserve
+func (g *Guard) Budget() int {
+	budget := g.policy.Limits.MonthlyReads - g.policy.Reserve - g.used
+	if budget < 0 {
+		return 0
+	}
+	return budget
+}
+
+// Allow returns the page size to request for an account with the given
+// priority, or 0 and the reason the request is refused
+func (g *Guard) Allow(priority string) (int, string) {
+	if g.override {
+		return MaxPageSize, ""
+	}
+
+	budget := g.Budget()
+	if budget < MinPageSize {
+		return 0, fmt.Sprintf("quota reserve reached (%d/%d read, %d reserved)",
+			g.used, g.policy.Limits.MonthlyReads, g.policy.Reserve)
+	}
+
+	percentUsed := float64(g.used) / float64(g.policy.Limits.MonthlyReads) * 100
+	if priority == PriorityLow && percentUsed >= g.policy.WarnPercent {
+		return 0, fmt.Sprintf("low priority skipped at %.0f%% quota used", percentUsed)
+	}
+
+	if budget > MaxPageSize {
+		return MaxPageSize, ""
+	}
+	return budget, ""
+}
+
+// Spend records tweets read so later decisions see them
+func (g *Guard) Spend(tweets int) {
+	g.used += tweets
+}
@@ -0,0 +1,50 @@
+package usage
+
+import (
+	"testing"
+
+	"github.com/jpequegn/xmon/internal/config"
+)
+
+func TestGuard(t *testing.T) {
+	policy, err := PolicyFromConfig(config.UsageConfig{
+		Tier:         "custom",
+		MonthlyReads: 1000,
+		Reserve:      100,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := NewGuard(policy, 0, false)
+	if size, reason := g.Allow(PriorityLow); size != MaxPageSize || reason != "" {
+		t.Errorf("expected full page with plenty of quota, got %d (%s)", size, reason)
+	}
+
+	// 80% used: low priority accounts are skipped first
+	g.Spend(800)
+	if size, reason := g.Allow(PriorityLow); size != 0 || reason == "" {
+		t.Errorf("expected low priority to be refused, got %d", size)
+	}
+	if size, _ := g.Allow(PriorityNormal); size != MaxPageSize {
+		t.Errorf("expected normal priority to get a full page, got %d", size)
+	}
+
+	// Only 40 left before the reserve: pages shrink to fit
+	g.Spend(60)
+	if size, _ := g.Allow(PriorityHigh); size != 40 {
+		t.Errorf("expected page of 40, got %d", size)
+	}
+
+	// Reserve reached: everything is refused
+	g.Spend(40)
+	if size, reason := g.Allow(PriorityHigh); size != 0 || reason == "" {
+		t.Errorf("expected refusal at reserve, got %d", size)
+	}
+
+	// Override ignores the reserve
+	g = NewGuard(policy, 1000, true)
+	if size, _ := g.Allow(PriorityLow); size != MaxPageSize {
+		t.Errorf("expected override to allow full page, got %d", size)
+	}
+}
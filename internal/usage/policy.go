@@ -0,0 +1,123 @@
+package usage
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jpequegn/xmon/internal/config"
+)
+
+// Limits are the per-cycle allowances of an X API plan. Endpoints maps an
+// endpoint name (see x.Endpoint*) to the number of requests allowed per
+// cycle; endpoints without an entry are not capped.
+type Limits struct {
+	MonthlyReads int
+	Endpoints    map[string]int
+}
+
+// Tiers holds the default limits for the X API plans we know about.
+// "custom" has no defaults and requires monthly_reads to be configured.
+var Tiers = map[string]Limits{
+	"free":  {MonthlyReads: 1500},
+	"basic": {MonthlyReads: 10000},
+	"pro":   {MonthlyReads: 1000000},
+}
+
+// Policy decides how usage is bucketed into billing cycles and when to warn
+type Policy struct {
+	Tier            string
+	Limits          Limits
+	CycleStartDay   int
+	WarnPercent     float64
+	CriticalPercent float64
+	// Reserve is the number of reads fetch and daemon leave untouched
+	// unless explicitly overridden
+	Reserve int
+}
+
+// DefaultPolicy matches the defaults in config.DefaultConfig
+func DefaultPolicy() Policy {
+	p, _ := PolicyFromConfig(config.DefaultConfig().Usage)
+	return p
+}
+
+// PolicyFromConfig resolves a usage config into a policy, filling in tier
+// defaults and validating the values
+func PolicyFromConfig(c config.UsageConfig) (Policy, error) {
+	p := Policy{
+		Tier:            c.Tier,
+		CycleStartDay:   c.CycleStartDay,
+		WarnPercent:     c.WarnPercent,
+		CriticalPercent: c.CriticalPercent,
+		Reserve:         c.Reserve,
+	}
+	if p.Tier == "" {
+		p.Tier = "free"
+	}
+	if p.CycleStartDay == 0 {
+		p.CycleStartDay = 1
+	}
+	if p.WarnPercent == 0 {
+		p.WarnPercent = 75
+	}
+	if p.CriticalPercent == 0 {
+		p.CriticalPercent = 90
+	}
+
+	limits, ok := Tiers[p.Tier]
+	if !ok && p.Tier != "custom" {
+		return p, fmt.Errorf("unknown usage tier %q (expected free, basic, pro or custom)", p.Tier)
+	}
+
+	p.Limits = Limits{MonthlyReads: limits.MonthlyReads, Endpoints: make(map[string]int)}
+	for endpoint, limit := range limits.Endpoints {
+		p.Limits.Endpoints[endpoint] = limit
+	}
+	if c.MonthlyReads > 0 {
+		p.Limits.MonthlyReads = c.MonthlyReads
+	}
+	for endpoint, limit := range c.EndpointLimits {
+		p.Limits.Endpoints[endpoint] = limit
+	}
+
+	if p.Limits.MonthlyReads <= 0 {
+		return p, fmt.Errorf("usage tier %q needs monthly_reads to be set", p.Tier)
+	}
+	if p.CycleStartDay < 1 || p.CycleStartDay > 28 {
+		return p, fmt.Errorf("cycle_start_day must be between 1 and 28, got %d", p.CycleStartDay)
+	}
+	if p.Reserve < 0 || p.Reserve >= p.Limits.MonthlyReads {
+		return p, fmt.Errorf("reserve must be between 0 and monthly_reads (%d), got %d", p.Limits.MonthlyReads, p.Reserve)
+	}
+	if p.WarnPercent <= 0 || p.CriticalPercent > 100 || p.WarnPercent > p.CriticalPercent {
+		return p, fmt.Errorf("invalid quota thresholds: warn %.0f%%, critical %.0f%%", p.WarnPercent, p.CriticalPercent)
+	}
+
+	return p, nil
+}
+
+// CycleStart returns the start of the billing cycle containing t. Cycles
+// start at midnight UTC on CycleStartDay.
+func (p Policy) CycleStart(t time.Time) time.Time {
+	t = t.UTC()
+	start := time.Date(t.Year(), t.Month(), p.CycleStartDay, 0, 0, 0, 0, time.UTC)
+	if t.Before(start) {
+		start = start.AddDate(0, -1, 0)
+	}
+	return start
+}
+
+// CycleKey identifies the billing cycle containing t by the month it started in
+func (p Policy) CycleKey(t time.Time) string {
+	return p.CycleStart(t).Format("2006-01")
+}
+
+// CycleBounds returns the start and (exclusive) end of the cycle with the given key
+func (p Policy) CycleBounds(key string) (time.Time, time.Time, error) {
+	month, err := time.Parse("2006-01", key)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid cycle %q: %w", key, err)
+	}
+	start := time.Date(month.Year(), month.Month(), p.CycleStartDay, 0, 0, 0, 0, time.UTC)
+	return start, start.AddDate(0, 1, 0), nil
+}
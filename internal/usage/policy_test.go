@@ -0,0 +1,72 @@
+package usage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jpequegn/xmon/internal/config"
+)
+
+func TestPolicyFromConfig(t *testing.T) {
+	p, err := PolicyFromConfig(config.UsageConfig{Tier: "basic", CycleStartDay: 15})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Limits.MonthlyReads != Tiers["basic"].MonthlyReads {
+		t.Errorf("expected basic tier reads, got %d", p.Limits.MonthlyReads)
+	}
+	if p.WarnPercent != 75 || p.CriticalPercent != 90 {
+		t.Errorf("expected default thresholds, got %.0f/%.0f", p.WarnPercent, p.CriticalPercent)
+	}
+
+	p, _ = PolicyFromConfig(config.UsageConfig{Tier: "basic", MonthlyReads: 15000})
+	if p.Limits.MonthlyReads != 15000 {
+		t.Errorf("expected override to 15000, got %d", p.Limits.MonthlyReads)
+	}
+
+	invalid := []config.UsageConfig{
+		{Tier: "enterprise-plus"},
+		{Tier: "custom"},
+		{Tier: "free", CycleStartDay: 31},
+		{Tier: "free", WarnPercent: 95, CriticalPercent: 90},
+		{Tier: "free", Reserve: 2000},
+	}
+	for _, c := range invalid {
+		if _, err := PolicyFromConfig(c); err == nil {
+			t.Errorf("expected error for %+v", c)
+		}
+	}
+}
+
+func TestCycleStart(t *testing.T) {
+	p, _ := PolicyFromConfig(config.UsageConfig{Tier: "basic", CycleStartDay: 15})
+
+	tests := []struct {
+		now      time.Time
+		expected string
+	}{
+		{time.Date(2025, 6, 20, 10, 0, 0, 0, time.UTC), "2025-06-15"},
+		{time.Date(2025, 6, 15, 0, 0, 0, 0, time.UTC), "2025-06-15"},
+		{time.Date(2025, 6, 14, 23, 59, 0, 0, time.UTC), "2025-05-15"},
+		{time.Date(2025, 1, 3, 0, 0, 0, 0, time.UTC), "2024-12-15"},
+	}
+
+	for _, tt := range tests {
+		got := p.CycleStart(tt.now).Format("2006-01-02")
+		if got != tt.expected {
+			t.Errorf("CycleStart(%s) = %s, expected %s", tt.now, got, tt.expected)
+		}
+	}
+
+	if key := p.CycleKey(time.Date(2025, 1, 3, 0, 0, 0, 0, time.UTC)); key != "2024-12" {
+		t.Errorf("expected cycle key 2024-12, got %s", key)
+	}
+
+	start, end, err := p.CycleBounds("2024-12")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if start.Format("2006-01-02") != "2024-12-15" || end.Format("2006-01-02") != "2025-01-15" {
+		t.Errorf("unexpected bounds %s - %s", start, end)
+	}
+}
@@ -0,0 +1,46 @@
+package usage
+
+import (
+	"math"
+	"time"
+)
+
+// Projection extrapolates the current cycle's burn rate linearly to its end
+type Projection struct {
+	DailyRate      float64
+	ProjectedTotal int
+	Limit          int
+	// ExhaustedAt is when the quota runs out at the current rate, or nil if
+	// it lasts until the cycle resets
+	ExhaustedAt *time.Time
+}
+
+// Project estimates where usage ends up by the end of the cycle. The rate is
+// averaged over the time elapsed since the cycle started, with a minimum of
+// one day so a burst in the first hours doesn't dominate.
+func Project(u *CycleUsage, limit int, now time.Time) Projection {
+	p := Projection{Limit: limit, ProjectedTotal: u.TweetsRead}
+
+	elapsed := now.Sub(u.Start).Hours() / 24
+	if elapsed < 1 {
+		elapsed = 1
+	}
+	p.DailyRate = float64(u.TweetsRead) / elapsed
+
+	remainingDays := u.End.Sub(now).Hours() / 24
+	if remainingDays < 0 {
+		remainingDays = 0
+	}
+	p.ProjectedTotal = u.TweetsRead + int(math.Round(p.DailyRate*remainingDays))
+
+	if u.TweetsRead >= limit {
+		exhausted := now
+		p.ExhaustedAt = &exhausted
+	} else if p.ProjectedTotal > limit && p.DailyRate > 0 {
+		daysLeft := float64(limit-u.TweetsRead) / p.DailyRate
+		exhausted := now.Add(time.Duration(daysLeft * 24 * float64(time.Hour)))
+		p.ExhaustedAt = &exhausted
+	}
+
+	return p
+}
@@ -0,0 +1,39 @@
+package usage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestProject(t *testing.T) {
+	start := time.Date(2025, 6, 15, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 1, 0) // 30 days
+	now := start.AddDate(0, 0, 10)
+
+	// 100/day over 10 days stays under 10k for the whole cycle
+	p := Project(&CycleUsage{Start: start, End: end, TweetsRead: 1000}, 10000, now)
+	if p.DailyRate != 100 {
+		t.Errorf("expected rate 100/day, got %.1f", p.DailyRate)
+	}
+	if p.ProjectedTotal != 3000 {
+		t.Errorf("expected 3000 projected, got %d", p.ProjectedTotal)
+	}
+	if p.ExhaustedAt != nil {
+		t.Errorf("expected quota to last, got exhaustion at %s", p.ExhaustedAt)
+	}
+
+	// 500/day runs out after 20 days
+	p = Project(&CycleUsage{Start: start, End: end, TweetsRead: 5000}, 10000, now)
+	if p.ExhaustedAt == nil {
+		t.Fatal("expected quota to run out")
+	}
+	if expected := start.AddDate(0, 0, 20); !p.ExhaustedAt.Equal(expected) {
+		t.Errorf("expected exhaustion at %s, got %s", expected, p.ExhaustedAt)
+	}
+
+	// Already over the limit
+	p = Project(&CycleUsage{Start: start, End: end, TweetsRead: 12000}, 10000, now)
+	if p.ExhaustedAt == nil || !p.ExhaustedAt.Equal(now) {
+		t.Errorf("expected exhaustion now, got %v", p.ExhaustedAt)
+	}
+}
@@ -1,76 +1,245 @@
-// internal/usage/repository.go
 package usage
 
 import (
+	"database/sql"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/jpequegn/xmon/internal/database"
 )
 
-const MonthlyLimit = 1500 // X API free tier limit
-
 type Repository struct {
-	db *database.DB
+	db     *database.DB
+	policy Policy
 }
 
-type MonthlyUsage struct {
-	Month      string
+// CycleUsage is what we have spent in one billing cycle
+type CycleUsage struct {
+	Cycle      string
+	Start      time.Time
+	End        time.Time
 	TweetsRead int
+	Requests   map[string]int
 	UpdatedAt  time.Time
 }
 
+// DailyUsage is what we spent on one UTC day
+type DailyUsage struct {
+	Day        time.Time
+	TweetsRead int
+	Requests   int
+}
+
 func NewRepository(db *database.DB) *Repository {
-	return &Repository{db: db}
+	return NewRepositoryWithPolicy(db, DefaultPolicy())
+}
+
+func NewRepositoryWithPolicy(db *database.DB, policy Policy) *Repository {
+	return &Repository{db: db, policy: policy}
+}
+
+// Policy returns the billing policy this repository tracks usage against
+func (r *Repository) Policy() Policy {
+	return r.policy
 }
 
-// GetCurrentMonth returns usage for the current month
-func (r *Repository) GetCurrentMonth() (*MonthlyUsage, error) {
-	month := time.Now().Format("2006-01")
-	return r.GetMonth(month)
+// GetCurrentCycle returns usage for the current billing cycle
+func (r *Repository) GetCurrentCycle() (*CycleUsage, error) {
+	return r.GetCycle(r.policy.CycleKey(time.Now()))
 }
 
-// GetMonth returns usage for a specific month
-func (r *Repository) GetMonth(month string) (*MonthlyUsage, error) {
-	row := r.db.QueryRow(`
-		SELECT month, tweets_read, updated_at
+// GetCycle returns usage for a specific billing cycle
+func (r *Repository) GetCycle(cycle string) (*CycleUsage, error) {
+	start, end, err := r.policy.CycleBounds(cycle)
+	if err != nil {
+		return nil, err
+	}
+
+	usage := CycleUsage{
+		Cycle:    cycle,
+		Start:    start,
+		End:      end,
+		Requests: make(map[string]int),
+	}
+
+	err = r.db.QueryRow(`
+		SELECT tweets_read, updated_at
 		FROM api_usage
 		WHERE month = ?
-	`, month)
+	`, cycle).Scan(&usage.TweetsRead, &usage.UpdatedAt)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
 
-	var usage MonthlyUsage
-	err := row.Scan(&usage.Month, &usage.TweetsRead, &usage.UpdatedAt)
+	rows, err := r.db.Query(`SELECT endpoint, requests FROM api_requests WHERE cycle = ?`, cycle)
 	if err != nil {
-		// Return zero usage if not found
-		return &MonthlyUsage{Month: month, TweetsRead: 0}, nil
+		return nil, err
 	}
+	defer rows.Close()
 
-	return &usage, nil
+	for rows.Next() {
+		var endpoint string
+		var count int
+		if err := rows.Scan(&endpoint, &count); err != nil {
+			return nil, err
+		}
+		usage.Requests[endpoint] = count
+	}
+
+	return &usage, rows.Err()
 }
 
-// AddTweetsRead increments the tweet count for current month
+// AddTweetsRead increments the tweet count for the current cycle
 func (r *Repository) AddTweetsRead(count int) error {
-	month := time.Now().Format("2006-01")
+	return r.addTweetsRead(r.db, count)
+}
 
-	_, err := r.db.Exec(`
+// AddTweetsReadTx increments the tweet count for the current cycle within tx
+func (r *Repository) AddTweetsReadTx(tx *sql.Tx, count int) error {
+	return r.addTweetsRead(tx, count)
+}
+
+func (r *Repository) addTweetsRead(db database.Execer, count int) error {
+	now := time.Now()
+	cycle := r.policy.CycleKey(now)
+
+	_, err := db.Exec(`
 		INSERT INTO api_usage (month, tweets_read, updated_at)
 		VALUES (?, ?, CURRENT_TIMESTAMP)
 		ON CONFLICT(month) DO UPDATE SET
 			tweets_read = tweets_read + ?,
 			updated_at = CURRENT_TIMESTAMP
-	`, month, count, count)
+	`, cycle, count, count)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`
+		INSERT INTO api_usage_daily (day, tweets_read, updated_at)
+		VALUES (?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(day) DO UPDATE SET
+			tweets_read = tweets_read + ?,
+			updated_at = CURRENT_TIMESTAMP
+	`, dayKey(now), count, count)
+
+	return err
+}
+
+// AddRequest counts one API request against an endpoint for the current cycle
+func (r *Repository) AddRequest(endpoint string) error {
+	now := time.Now()
+	cycle := r.policy.CycleKey(now)
+
+	_, err := r.db.Exec(`
+		INSERT INTO api_requests (cycle, endpoint, requests, updated_at)
+		VALUES (?, ?, 1, CURRENT_TIMESTAMP)
+		ON CONFLICT(cycle, endpoint) DO UPDATE SET
+			requests = requests + 1,
+			updated_at = CURRENT_TIMESTAMP
+	`, cycle, endpoint)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Exec(`
+		INSERT INTO api_usage_daily (day, requests, updated_at)
+		VALUES (?, 1, CURRENT_TIMESTAMP)
+		ON CONFLICT(day) DO UPDATE SET
+			requests = requests + 1,
+			updated_at = CURRENT_TIMESTAMP
+	`, dayKey(now))
 
 	return err
 }
 
-// GetRemainingQuota returns how many tweets can still be read this month
+// ListCycles returns up to limit billing cycles with recorded usage, newest first
+func (r *Repository) ListCycles(limit int) ([]CycleUsage, error) {
+	rows, err := r.db.Query(`SELECT month FROM api_usage ORDER BY month DESC LIMIT ?`, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var cycles []CycleUsage
+	for _, key := range keys {
+		usage, err := r.GetCycle(key)
+		if err != nil {
+			return nil, err
+		}
+		cycles = append(cycles, *usage)
+	}
+	return cycles, nil
+}
+
+// Daily returns usage per UTC day in [from, to), including days without any
+// usage so the result can be charted directly
+func (r *Repository) Daily(from, to time.Time) ([]DailyUsage, error) {
+	rows, err := r.db.Query(`
+		SELECT day, tweets_read, requests
+		FROM api_usage_daily
+		WHERE day >= ? AND day <= ?
+	`, dayKey(from), dayKey(to))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	byDay := make(map[string]DailyUsage)
+	for rows.Next() {
+		var key string
+		var d DailyUsage
+		if err := rows.Scan(&key, &d.TweetsRead, &d.Requests); err != nil {
+			return nil, err
+		}
+		byDay[key] = d
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var days []DailyUsage
+	for day := truncateDay(from); day.Before(to); day = day.AddDate(0, 0, 1) {
+		d := byDay[dayKey(day)]
+		d.Day = day
+		days = append(days, d)
+	}
+	return days, nil
+}
+
+func dayKey(t time.Time) string {
+	return t.UTC().Format("2006-01-02")
+}
+
+func truncateDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// GetRemainingQuota returns how many tweets can still be read this cycle
 func (r *Repository) GetRemainingQuota() (int, error) {
-	usage, err := r.GetCurrentMonth()
+	limit := r.policy.Limits.MonthlyReads
+
+	usage, err := r.GetCurrentCycle()
 	if err != nil {
-		return MonthlyLimit, err
+		return limit, err
 	}
 
-	remaining := MonthlyLimit - usage.TweetsRead
+	remaining := limit - usage.TweetsRead
 	if remaining < 0 {
 		remaining = 0
 	}
@@ -78,26 +247,58 @@ func (r *Repository) GetRemainingQuota() (int, error) {
 	return remaining, nil
 }
 
-// CheckQuota returns a warning message if quota is low, empty string otherwise
+// CheckQuota returns a warning message if the read quota or any endpoint's
+// request cap is running low, empty string otherwise
 func (r *Repository) CheckQuota() string {
-	remaining, _ := r.GetRemainingQuota()
-	usage, _ := r.GetCurrentMonth()
+	usage, err := r.GetCurrentCycle()
+	if err != nil {
+		return ""
+	}
+
+	var warnings []string
+	if w := r.checkLimit("tweets read", usage.TweetsRead, r.policy.Limits.MonthlyReads); w != "" {
+		warnings = append(warnings, w)
+	}
+
+	endpoints := make([]string, 0, len(r.policy.Limits.Endpoints))
+	for endpoint := range r.policy.Limits.Endpoints {
+		endpoints = append(endpoints, endpoint)
+	}
+	sort.Strings(endpoints)
+	for _, endpoint := range endpoints {
+		label := endpoint + " requests"
+		if w := r.checkLimit(label, usage.Requests[endpoint], r.policy.Limits.Endpoints[endpoint]); w != "" {
+			warnings = append(warnings, w)
+		}
+	}
 
-	percentUsed := float64(usage.TweetsRead) / float64(MonthlyLimit) * 100
+	return strings.Join(warnings, "\n")
+}
+
+func (r *Repository) checkLimit(label string, used, limit int) string {
+	if limit <= 0 {
+		return ""
+	}
+
+	remaining := limit - used
+	if remaining < 0 {
+		remaining = 0
+	}
+	percentUsed := float64(used) / float64(limit) * 100
 
 	if remaining == 0 {
-		return fmt.Sprintf("⚠️  Monthly API limit reached! %d/%d tweets read (%.0f%%)",
-			usage.TweetsRead, MonthlyLimit, percentUsed)
+		return fmt.Sprintf("⚠️  API limit reached for this billing cycle! %d/%d %s (%.0f%%)",
+			used, limit, label, percentUsed)
 	}
 
-	if percentUsed >= 90 {
-		return fmt.Sprintf("⚠️  API quota critical: %d/%d tweets read (%.0f%%), %d remaining",
-			usage.TweetsRead, MonthlyLimit, percentUsed, remaining)
+	if percentUsed >= r.policy.CriticalPercent {
+		return fmt.Sprintf("⚠️  API quota critical: %d/%d %s (%.0f%%), %d remaining",
+			used, limit, label, percentUsed, remaining)
 	}
 
-	if percentUsed >= 75 {
-		return fmt.Sprintf("⚠️  API quota warning: %d/%d tweets read (%.0f%%), %d remaining",
-			usage.TweetsRead, MonthlyLimit, percentUsed, remaining)
+	if percentUsed >= r.policy.WarnPercent {
+		return fmt.Sprintf("⚠️  API quota warning: %d/%d %s (%.0f%%), %d remaining",
+			used, limit, label, percentUsed, remaining)
 	}
 
 	return ""
@@ -3,8 +3,11 @@ package usage
 
 import (
 	"os"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/jpequegn/xmon/internal/config"
 	"github.com/jpequegn/xmon/internal/database"
 )
 
@@ -26,7 +29,7 @@ func TestUsageRepository(t *testing.T) {
 	repo := NewRepository(db)
 
 	// Test initial state
-	usage, err := repo.GetCurrentMonth()
+	usage, err := repo.GetCurrentCycle()
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -40,22 +43,23 @@ func TestUsageRepository(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	usage, _ = repo.GetCurrentMonth()
+	usage, _ = repo.GetCurrentCycle()
 	if usage.TweetsRead != 100 {
 		t.Errorf("expected 100 reads, got %d", usage.TweetsRead)
 	}
 
 	// Test adding more
 	repo.AddTweetsRead(50)
-	usage, _ = repo.GetCurrentMonth()
+	usage, _ = repo.GetCurrentCycle()
 	if usage.TweetsRead != 150 {
 		t.Errorf("expected 150 reads, got %d", usage.TweetsRead)
 	}
 
 	// Test remaining quota
+	limit := repo.Policy().Limits.MonthlyReads
 	remaining, _ := repo.GetRemainingQuota()
-	if remaining != MonthlyLimit-150 {
-		t.Errorf("expected %d remaining, got %d", MonthlyLimit-150, remaining)
+	if remaining != limit-150 {
+		t.Errorf("expected %d remaining, got %d", limit-150, remaining)
 	}
 }
 
@@ -83,3 +87,113 @@ func TestCheckQuota(t *testing.T) {
 		t.Error("expected warning at 80%")
 	}
 }
+
+func TestEndpointRequests(t *testing.T) {
+	tmpFile, _ := os.CreateTemp("", "xmon-requests-test-*.db")
+	defer os.Remove(tmpFile.Name())
+	tmpFile.Close()
+
+	db, _ := database.New(tmpFile.Name())
+	defer db.Close()
+
+	policy, err := PolicyFromConfig(config.UsageConfig{
+		Tier:           "basic",
+		EndpointLimits: map[string]int{"user_tweets": 10},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := NewRepositoryWithPolicy(db, policy)
+
+	for i := 0; i < 8; i++ {
+		repo.AddRequest("user_tweets")
+	}
+	repo.AddRequest("user_lookup")
+
+	usage, err := repo.GetCurrentCycle()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usage.Requests["user_tweets"] != 8 || usage.Requests["user_lookup"] != 1 {
+		t.Errorf("unexpected request counts: %v", usage.Requests)
+	}
+
+	// Reads are well under the basic tier, but user_tweets is at 80% of its cap
+	warning := repo.CheckQuota()
+	if !strings.Contains(warning, "user_tweets") {
+		t.Errorf("expected user_tweets warning, got %q", warning)
+	}
+}
+
+func TestCheckQuotaThresholds(t *testing.T) {
+	tmpFile, _ := os.CreateTemp("", "xmon-thresholds-test-*.db")
+	defer os.Remove(tmpFile.Name())
+	tmpFile.Close()
+
+	db, _ := database.New(tmpFile.Name())
+	defer db.Close()
+
+	policy, err := PolicyFromConfig(config.UsageConfig{
+		Tier:            "custom",
+		MonthlyReads:    1000,
+		WarnPercent:     50,
+		CriticalPercent: 60,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := NewRepositoryWithPolicy(db, policy)
+
+	repo.AddTweetsRead(400)
+	if warning := repo.CheckQuota(); warning != "" {
+		t.Errorf("expected no warning at 40%%, got: %s", warning)
+	}
+
+	repo.AddTweetsRead(150) // 55%
+	if warning := repo.CheckQuota(); !strings.Contains(warning, "warning") {
+		t.Errorf("expected warning at 55%%, got: %q", warning)
+	}
+
+	repo.AddTweetsRead(100) // 65%
+	if warning := repo.CheckQuota(); !strings.Contains(warning, "critical") {
+		t.Errorf("expected critical at 65%%, got: %q", warning)
+	}
+}
+
+func TestDailyAndCycles(t *testing.T) {
+	tmpFile, _ := os.CreateTemp("", "xmon-daily-test-*.db")
+	defer os.Remove(tmpFile.Name())
+	tmpFile.Close()
+
+	db, _ := database.New(tmpFile.Name())
+	defer db.Close()
+
+	repo := NewRepository(db)
+	repo.AddTweetsRead(40)
+	repo.AddRequest("user_tweets")
+	db.Exec(`INSERT INTO api_usage (month, tweets_read) VALUES ('2020-01', 900)`)
+
+	now := time.Now()
+	days, err := repo.Daily(now.AddDate(0, 0, -2), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(days) != 3 {
+		t.Fatalf("expected 3 days including empty ones, got %d", len(days))
+	}
+	today := days[len(days)-1]
+	if today.TweetsRead != 40 || today.Requests != 1 {
+		t.Errorf("unexpected usage for today: %+v", today)
+	}
+	if days[0].TweetsRead != 0 {
+		t.Errorf("expected empty earlier day, got %+v", days[0])
+	}
+
+	cycles, err := repo.ListCycles(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cycles) != 2 || cycles[1].Cycle != "2020-01" || cycles[1].TweetsRead != 900 {
+		t.Errorf("unexpected cycles: %+v", cycles)
+	}
+}
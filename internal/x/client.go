@@ -3,6 +3,7 @@ package x
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -10,13 +11,21 @@ import (
 	"time"
 )
 
-const baseURL = "https://api.twitter.com/2"
+const defaultBaseURL = "https://api.twitter.com/2"
+
+// Endpoint names used for per-endpoint request accounting
+const (
+	EndpointUserLookup = "user_lookup"
+	EndpointUserTweets = "user_tweets"
+)
 
 type Client struct {
+	baseURL            string
 	bearerToken        string
 	httpClient         *http.Client
 	rateLimitRemaining int
 	rateLimitReset     time.Time
+	onRequest          func(endpoint string)
 }
 
 type User struct {
@@ -34,7 +43,12 @@ type User struct {
 type Tweet struct {
 	ID                string    `json:"id"`
 	Text              string    `json:"text"`
+	AuthorID          string    `json:"author_id"`
+	Lang              string    `json:"lang"`
 	CreatedAt         time.Time `json:"created_at"`
+	NoteTweet         struct {
+		Text string `json:"text"`
+	} `json:"note_tweet"`
 	PublicMetrics     struct {
 		RetweetCount int `json:"retweet_count"`
 		LikeCount    int `json:"like_count"`
@@ -58,12 +72,75 @@ type TweetsResponse struct {
 		NextToken   string `json:"next_token"`
 	} `json:"meta"`
 	Includes struct {
-		Users []User `json:"users"`
+		Users  []User  `json:"users"`
+		Tweets []Tweet `json:"tweets"`
 	} `json:"includes"`
 }
 
+// FullText returns the untruncated text, which long posts carry in note_tweet
+func (t Tweet) FullText() string {
+	if t.NoteTweet.Text != "" {
+		return t.NoteTweet.Text
+	}
+	return t.Text
+}
+
+// Referenced returns the post tw retweets or quotes and its author, looked up
+// in the response's includes. Either may be nil if X left it out, e.g. when
+// the original was deleted.
+func (r *TweetsResponse) Referenced(tw Tweet) (*Tweet, *User) {
+	var refID string
+	for _, ref := range tw.ReferencedTweets {
+		if ref.Type == "retweeted" || ref.Type == "quoted" {
+			refID = ref.ID
+			break
+		}
+	}
+	if refID == "" {
+		return nil, nil
+	}
+
+	var post *Tweet
+	for i := range r.Includes.Tweets {
+		if r.Includes.Tweets[i].ID == refID {
+			post = &r.Includes.Tweets[i]
+			break
+		}
+	}
+	if post == nil {
+		return nil, nil
+	}
+	for i := range r.Includes.Users {
+		if r.Includes.Users[i].ID == post.AuthorID {
+			return post, &r.Includes.Users[i]
+		}
+	}
+	return post, nil
+}
+
+// APIError is returned when the X API responds with a non-200 status
+type APIError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("X API error %d: %s", e.StatusCode, e.Body)
+}
+
+// StatusCode extracts the HTTP status from an error returned by the client,
+// or 0 if the request never got a response
+func StatusCode(err error) int {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode
+	}
+	return 0
+}
+
 func NewClient(bearerToken string) *Client {
 	return &Client{
+		baseURL:     defaultBaseURL,
 		bearerToken: bearerToken,
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
@@ -71,7 +148,19 @@ func NewClient(bearerToken string) *Client {
 	}
 }
 
-func (c *Client) doRequest(url string) ([]byte, error) {
+// SetBaseURL points the client at another API server, e.g. a fake one in
+// tests
+func (c *Client) SetBaseURL(url string) {
+	c.baseURL = url
+}
+
+// OnRequest registers a callback invoked for every request sent to the API,
+// whether or not it succeeds
+func (c *Client) OnRequest(fn func(endpoint string)) {
+	c.onRequest = fn
+}
+
+func (c *Client) doRequest(endpoint, url string) ([]byte, error) {
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
 		return nil, err
@@ -79,6 +168,10 @@ func (c *Client) doRequest(url string) ([]byte, error) {
 
 	req.Header.Set("Authorization", "Bearer "+c.bearerToken)
 
+	if c.onRequest != nil {
+		c.onRequest(endpoint)
+	}
+
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return nil, err
@@ -99,15 +192,15 @@ func (c *Client) doRequest(url string) ([]byte, error) {
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("X API error %d: %s", resp.StatusCode, string(body))
+		return nil, &APIError{StatusCode: resp.StatusCode, Body: string(body)}
 	}
 
 	return io.ReadAll(resp.Body)
 }
 
 func (c *Client) GetUser(username string) (*User, error) {
-	url := fmt.Sprintf("%s/users/by/username/%s?user.fields=description,public_metrics", baseURL, username)
-	data, err := c.doRequest(url)
+	url := fmt.Sprintf("%s/users/by/username/%s?user.fields=description,public_metrics", c.baseURL, username)
+	data, err := c.doRequest(EndpointUserLookup, url)
 	if err != nil {
 		return nil, err
 	}
@@ -120,15 +213,23 @@ func (c *Client) GetUser(username string) (*User, error) {
 	return &resp.Data, nil
 }
 
-func (c *Client) GetUserTweets(userID string, sinceID string) (*TweetsResponse, error) {
-	url := fmt.Sprintf("%s/users/%s/tweets?max_results=100&tweet.fields=created_at,public_metrics,referenced_tweets&expansions=referenced_tweets.id.author_id&user.fields=username",
-		baseURL, userID)
+// GetUserTweets fetches up to maxResults (5-100) of a user's most recent tweets
+func (c *Client) GetUserTweets(userID string, sinceID string, maxResults int) (*TweetsResponse, error) {
+	if maxResults < 5 {
+		maxResults = 5
+	}
+	if maxResults > 100 {
+		maxResults = 100
+	}
+
+	url := fmt.Sprintf("%s/users/%s/tweets?max_results=%d&tweet.fields=created_at,public_metrics,referenced_tweets,author_id,note_tweet,lang&expansions=referenced_tweets.id,referenced_tweets.id.author_id&user.fields=username",
+		c.baseURL, userID, maxResults)
 
 	if sinceID != "" {
 		url += "&since_id=" + sinceID
 	}
 
-	data, err := c.doRequest(url)
+	data, err := c.doRequest(EndpointUserTweets, url)
 	if err != nil {
 		return nil, err
 	}
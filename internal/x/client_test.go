@@ -2,6 +2,8 @@
 package x
 
 import (
+	"encoding/json"
+	"fmt"
 	"testing"
 )
 
@@ -54,3 +56,49 @@ func TestGetTweetType(t *testing.T) {
 		})
 	}
 }
+
+func TestStatusCode(t *testing.T) {
+	err := fmt.Errorf("fetch failed: %w", &APIError{StatusCode: 429, Body: "Too Many Requests"})
+	if got := StatusCode(err); got != 429 {
+		t.Errorf("expected 429, got %d", got)
+	}
+	if got := StatusCode(fmt.Errorf("connection refused")); got != 0 {
+		t.Errorf("expected 0 for non-API error, got %d", got)
+	}
+}
+
+func TestReferenced(t *testing.T) {
+	body := `{
+		"data": [
+			{"id": "10", "text": "RT @alice: a long post that X trunc…", "referenced_tweets": [{"type": "retweeted", "id": "1"}]},
+			{"id": "11", "text": "my own take", "referenced_tweets": [{"type": "quoted", "id": "2"}]},
+			{"id": "12", "text": "original"}
+		],
+		"includes": {
+			"users": [{"id": "u2", "username": "bob"}, {"id": "u1", "username": "alice"}],
+			"tweets": [
+				{"id": "1", "author_id": "u1", "text": "a long post that X trunc…", "note_tweet": {"text": "a long post that X truncates in the timeline"}},
+				{"id": "2", "author_id": "u2", "text": "bob said this"}
+			]
+		}
+	}`
+	var resp TweetsResponse
+	if err := json.Unmarshal([]byte(body), &resp); err != nil {
+		t.Fatal(err)
+	}
+
+	post, author := resp.Referenced(resp.Data[0])
+	if post == nil || author == nil || author.Username != "alice" {
+		t.Fatalf("expected retweet of @alice, got %+v %+v", post, author)
+	}
+	if post.FullText() != "a long post that X truncates in the timeline" {
+		t.Errorf("expected full text from note_tweet, got %q", post.FullText())
+	}
+
+	if _, author := resp.Referenced(resp.Data[1]); author == nil || author.Username != "bob" {
+		t.Errorf("expected quote of @bob, got %+v", author)
+	}
+	if post, _ := resp.Referenced(resp.Data[2]); post != nil {
+		t.Errorf("expected no referenced post for an original, got %+v", post)
+	}
+}